@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// attachmentCommands are the ticket subcommands for attaching files.
+func attachmentCommands() []*cobra.Command {
+	var attachType string
+	attachCmd := &cobra.Command{
+		Use:   "attach [id-or-key] [file]",
+		Short: fmt.Sprintf("Attach a file (log, screenshot, design doc) of up to %d MB to a ticket", db.MaxAttachmentSize>>20),
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			a, err := store.AddAttachment(t.ID, models.CreateAttachmentRequest{Filename: f.Name(), ContentType: attachType}, f)
+			if err != nil {
+				return err
+			}
+			if a == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("Attached %s (%s, %s) to %s (%s)\n", a.Filename, a.ContentType, formatSize(a.Size), t.DisplayKey(), a.ID)
+			return nil
+		},
+	}
+	attachCmd.Flags().StringVar(&attachType, "type", "", "content type (default: from the file extension or content)")
+
+	attachmentsCmd := &cobra.Command{
+		Use:   "attachments [id-or-key]",
+		Short: "List the files attached to a ticket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			if len(t.Attachments) == 0 {
+				fmt.Printf("%s has no attachments.\n", t.DisplayKey())
+				return nil
+			}
+			for _, a := range t.Attachments {
+				by := ""
+				if a.Author != "" {
+					by = " by " + a.Author
+				}
+				fmt.Printf("%s (%s, %s) added %s%s (%s)\n", a.Filename, a.ContentType, formatSize(a.Size),
+					a.CreatedAt.Format("2006-01-02 15:04"), by, a.ID)
+			}
+			return nil
+		},
+	}
+
+	var downloadOutput string
+	downloadCmd := &cobra.Command{
+		Use:   "download [attachment-id]",
+		Short: "Save an attachment to a file, or print it with --output -",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			a, data, err := store.ReadAttachment(args[0])
+			if err != nil {
+				return err
+			}
+			if a == nil {
+				return fmt.Errorf("attachment not found")
+			}
+			if downloadOutput == "-" {
+				_, err := os.Stdout.Write(data)
+				return err
+			}
+			path := downloadOutput
+			if path == "" {
+				path = a.Filename
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Saved %s (%s)\n", path, formatSize(a.Size))
+			return nil
+		},
+	}
+	downloadCmd.Flags().StringVarP(&downloadOutput, "output", "o", "", "file to write (default: the attachment's filename)")
+
+	detachCmd := &cobra.Command{
+		Use:   "detach [attachment-id]",
+		Short: "Delete an attachment",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			a, err := store.GetAttachment(args[0])
+			if err != nil {
+				return err
+			}
+			if a == nil {
+				return fmt.Errorf("attachment not found")
+			}
+			if err := store.DeleteAttachment(a.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted attachment %s\n", a.Filename)
+			return nil
+		},
+	}
+
+	return []*cobra.Command{attachCmd, attachmentsCmd, downloadCmd, detachCmd}
+}
+
+// formatSize renders a byte count as B, KB or MB.
+func formatSize(size int64) string {
+	switch {
+	case size < 1<<10:
+		return fmt.Sprintf("%d B", size)
+	case size < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
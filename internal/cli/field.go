@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func fieldCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "field",
+		Short: "Manage custom ticket fields (customer, environment, affected version, ...)",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List custom fields",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			fields, err := store.ListCustomFields(listProject)
+			if err != nil {
+				return err
+			}
+			if len(fields) == 0 {
+				fmt.Println("No custom fields found.")
+				return nil
+			}
+			for _, f := range fields {
+				fmt.Printf("%s: %s (%s) (%s)\n", f.Key, f.Name, fieldTypeSummary(f), f.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "filter by project ID")
+
+	var req models.CreateCustomFieldRequest
+	createCmd := &cobra.Command{
+		Use:   "create [key]",
+		Short: "Add a custom field to a project's tickets",
+		Long: "Add a custom field to a project's tickets. Set values with taskboard ticket create or\n" +
+			"taskboard ticket fields --field [key]=[value], and filter with --field or --query '[key]:[value]'.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Key = args[0]
+			f, err := store.CreateCustomField(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created field %s (%s)\n", f.Key, f.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.ProjectID, "project", "", "project ID (required)")
+	createCmd.MarkFlagRequired("project")
+	createCmd.Flags().StringVar(&req.Name, "name", "", "display name (default: the key)")
+	createCmd.Flags().StringVar(&req.Type, "type", "", "value type: "+strings.Join(models.CustomFieldTypes(), "|")+" (required)")
+	createCmd.MarkFlagRequired("type")
+	createCmd.Flags().StringArrayVar(&req.Options, "option", nil, "choice of a select or multi_select field, repeat for several")
+
+	var updateName string
+	var updateOptions []string
+	updateCmd := &cobra.Command{
+		Use:   "update [id]",
+		Short: "Rename a custom field or replace its options",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			var req models.UpdateCustomFieldRequest
+			if cmd.Flags().Changed("name") {
+				req.Name = &updateName
+			}
+			if cmd.Flags().Changed("option") {
+				req.Options = append([]string{}, updateOptions...)
+			}
+			f, err := store.UpdateCustomField(args[0], req)
+			if err != nil {
+				return err
+			}
+			if f == nil {
+				return fmt.Errorf("field not found")
+			}
+			fmt.Printf("Updated field %s\n", f.Key)
+			return nil
+		},
+	}
+	updateCmd.Flags().StringVar(&updateName, "name", "", "new display name")
+	updateCmd.Flags().StringArrayVar(&updateOptions, "option", nil, "choice, repeat for several; replaces the current options")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a custom field and every ticket's value for it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteCustomField(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Field deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, createCmd, updateCmd, deleteCmd)
+	return cmd
+}
+
+// fieldTypeSummary describes a field's type, with the options of select
+// fields, such as "select: prod, staging".
+func fieldTypeSummary(f models.CustomField) string {
+	if len(f.Options) == 0 {
+		return f.Type
+	}
+	return f.Type + ": " + strings.Join(f.Options, ", ")
+}
+
+// parseFieldFlags reads --field key=value flags. An empty value clears the
+// field; multi_select options are separated by commas.
+func parseFieldFlags(flags []string) (map[string]any, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]any, len(flags))
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("--field %q must be written as key=value", flag)
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func inboxCommand() *cobra.Command {
+	var member string
+	var all bool
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "inbox",
+		Short: "Show unread notifications about the tickets you watch",
+		Long: "Show unread notifications about the tickets you watch: status changes, comments and\n" +
+			"tickets that are no longer blocked. You watch the tickets you create, are assigned or\n" +
+			"comment on, and any you follow with taskboard ticket watch.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			memberID, err := resolveMember(store, member)
+			if err != nil {
+				return err
+			}
+			notifications, err := store.ListNotifications(models.NotificationFilter{MemberID: memberID, Unread: !all, Limit: limit})
+			if err != nil {
+				return err
+			}
+			if len(notifications) == 0 {
+				fmt.Println("No notifications.")
+				return nil
+			}
+			for _, n := range notifications {
+				unread := "*"
+				if n.ReadAt != nil {
+					unread = " "
+				}
+				fmt.Printf("%s %s %s (%s)\n", unread, n.CreatedAt.Format("2006-01-02 15:04"), n.Message, n.ID)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&member, "member", "me", "member ID or handle whose inbox to use")
+	cmd.Flags().BoolVar(&all, "all", false, "include notifications already read")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum notifications to show (default 50)")
+
+	readCmd := &cobra.Command{
+		Use:   "read [notification-id...]",
+		Short: "Mark notifications as read, or all of them without IDs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			memberID, err := resolveMember(store, member)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				count, err := store.MarkAllNotificationsRead(memberID)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Marked %d notifications as read.\n", count)
+				return nil
+			}
+			for _, id := range args {
+				n, err := store.MarkNotificationRead(memberID, id)
+				if err != nil {
+					return err
+				}
+				if n == nil {
+					return fmt.Errorf("notification %s not found", id)
+				}
+			}
+			fmt.Printf("Marked %d notifications as read.\n", len(args))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(readCmd)
+	return cmd
+}
+
+// watchCommands are the ticket subcommands for following tickets.
+func watchCommands() []*cobra.Command {
+	watchCmd := &cobra.Command{
+		Use:   "watch [id-or-key] [member]",
+		Short: "Get notified about a ticket's changes (default member: me)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			memberID, err := resolveMember(store, watcherArg(args))
+			if err != nil {
+				return err
+			}
+			if t, err = store.WatchTicket(t.ID, models.WatchRequest{MemberID: memberID}); err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("Watching %s\n", t.DisplayKey())
+			return nil
+		},
+	}
+
+	unwatchCmd := &cobra.Command{
+		Use:   "unwatch [id-or-key] [member]",
+		Short: "Stop getting notified about a ticket (default member: me)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			memberID, err := resolveMember(store, watcherArg(args))
+			if err != nil {
+				return err
+			}
+			if t, err = store.UnwatchTicket(t.ID, memberID); err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("No longer watching %s\n", t.DisplayKey())
+			return nil
+		},
+	}
+
+	watchersCmd := &cobra.Command{
+		Use:   "watchers [id-or-key]",
+		Short: "List who is watching a ticket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			members, err := store.ListWatchers(t.ID)
+			if err != nil {
+				return err
+			}
+			if len(members) == 0 {
+				fmt.Printf("Nobody is watching %s.\n", t.DisplayKey())
+				return nil
+			}
+			for _, m := range members {
+				fmt.Printf("%s (@%s, %s)\n", m.Name, m.Handle, m.ID)
+			}
+			return nil
+		},
+	}
+
+	return []*cobra.Command{watchCmd, unwatchCmd, watchersCmd}
+}
+
+// watcherArg returns the member argument of watch and unwatch, "me" when it
+// is left out.
+func watcherArg(args []string) string {
+	if len(args) == 2 {
+		return args[1]
+	}
+	return "me"
+}
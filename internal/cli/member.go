@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func memberCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "member",
+		Short: "Manage members tickets can be assigned to",
+	}
+
+	var listTeam string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List members",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			members, err := store.ListMembers(listTeam)
+			if err != nil {
+				return err
+			}
+			if len(members) == 0 {
+				fmt.Println("No members found.")
+				return nil
+			}
+			for _, m := range members {
+				fmt.Printf("%s @%s (%s)\n", m.Name, m.Handle, m.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listTeam, "team", "", "only members of this team ID")
+
+	var handle, color string
+	var teams []string
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a new member",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			m, err := store.CreateMember(models.CreateMemberRequest{
+				Name:        args[0],
+				Handle:      strings.TrimPrefix(handle, "@"),
+				AvatarColor: color,
+				TeamIDs:     teams,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created member %s @%s (%s)\n", m.Name, m.Handle, m.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&handle, "handle", "", "unique handle (required)")
+	createCmd.MarkFlagRequired("handle")
+	createCmd.Flags().StringVar(&color, "color", "#10B981", "avatar hex color")
+	createCmd.Flags().StringSliceVar(&teams, "team", nil, "team ID to join (repeatable)")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a member",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteMember(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Member deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, createCmd, deleteCmd)
+	return cmd
+}
+
+// resolveMember accepts a member ID, a handle (with or without @) or "me",
+// which matches the handle equal to $USER.
+func resolveMember(store *db.Store, ref string) (string, error) {
+	if ref == "me" {
+		ref = os.Getenv("USER")
+	}
+	m, err := store.GetMember(ref)
+	if err != nil {
+		return "", err
+	}
+	if m == nil {
+		m, err = store.GetMemberByHandle(strings.TrimPrefix(ref, "@"))
+		if err != nil {
+			return "", err
+		}
+	}
+	if m == nil {
+		return "", fmt.Errorf("member %q not found", ref)
+	}
+	return m.ID, nil
+}
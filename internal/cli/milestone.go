@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func milestoneCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "milestone",
+		Short: "Manage milestones (release targets within a project)",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List milestones with their progress",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			milestones, err := store.ListMilestones(listProject)
+			if err != nil {
+				return err
+			}
+			if len(milestones) == 0 {
+				fmt.Println("No milestones found.")
+				return nil
+			}
+			for _, m := range milestones {
+				printMilestone(m)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "filter by project ID")
+
+	showCmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a milestone and its tickets",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			m, err := store.GetMilestone(args[0])
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				return fmt.Errorf("milestone not found")
+			}
+			printMilestone(*m)
+			if m.Description != "" {
+				fmt.Println(m.Description)
+			}
+			tickets, err := store.ListTickets(models.TicketFilter{MilestoneID: m.ID})
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			for _, t := range tickets {
+				fmt.Printf("[%s] %s - %s (%s, %s)\n", t.DisplayKey(), t.Title, t.Status, t.Priority, t.ID)
+			}
+			return nil
+		},
+	}
+
+	var req models.CreateMilestoneRequest
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a new milestone",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Name = args[0]
+			m, err := store.CreateMilestone(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created milestone %s (%s)\n", m.Name, m.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.ProjectID, "project", "", "project ID (required)")
+	createCmd.MarkFlagRequired("project")
+	createCmd.Flags().StringVar(&req.TargetDate, "target", "", "target date (YYYY-MM-DD)")
+	createCmd.Flags().StringVar(&req.Description, "description", "", "what the milestone delivers")
+
+	var updateName, updateTarget, updateDescription string
+	updateCmd := &cobra.Command{
+		Use:   "update [id]",
+		Short: "Update a milestone",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			var req models.UpdateMilestoneRequest
+			if cmd.Flags().Changed("name") {
+				req.Name = &updateName
+			}
+			if cmd.Flags().Changed("target") {
+				req.TargetDate = &updateTarget
+			}
+			if cmd.Flags().Changed("description") {
+				req.Description = &updateDescription
+			}
+			m, err := store.UpdateMilestone(args[0], req)
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				return fmt.Errorf("milestone not found")
+			}
+			fmt.Printf("Updated milestone %s\n", m.Name)
+			return nil
+		},
+	}
+	updateCmd.Flags().StringVar(&updateName, "name", "", "new name")
+	updateCmd.Flags().StringVar(&updateTarget, "target", "", "target date (YYYY-MM-DD), or \"\" to clear")
+	updateCmd.Flags().StringVar(&updateDescription, "description", "", "new description")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a milestone; its tickets are kept",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteMilestone(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Milestone deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, showCmd, createCmd, updateCmd, deleteCmd)
+	return cmd
+}
+
+func printMilestone(m models.Milestone) {
+	target := "no target date"
+	if m.TargetDate != nil {
+		target = "due " + m.TargetDate.Format("2006-01-02")
+		if m.Overdue {
+			target += ", overdue"
+		}
+	}
+	fmt.Printf("%s (%s) %d%%: %d/%d tickets, %d/%d subtasks done (%s)\n", m.Name, target, m.Progress,
+		m.DoneCount, m.TicketCount, m.SubtaskDoneCount, m.SubtaskCount, m.ID)
+}
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/tcarac/taskboard/internal/models"
@@ -40,7 +42,7 @@ func projectCommands() *cobra.Command {
 		},
 	}
 
-	var prefix, icon, color string
+	var prefix, icon, color, blockedMoves, estimateUnit string
 	createCmd := &cobra.Command{
 		Use:   "create [name]",
 		Short: "Create a new project",
@@ -51,10 +53,12 @@ func projectCommands() *cobra.Command {
 				return err
 			}
 			p, err := store.CreateProject(models.CreateProjectRequest{
-				Name:   args[0],
-				Prefix: prefix,
-				Icon:   icon,
-				Color:  color,
+				Name:              args[0],
+				Prefix:            prefix,
+				Icon:              icon,
+				Color:             color,
+				BlockedMovePolicy: blockedMoves,
+				EstimateUnit:      estimateUnit,
 			})
 			if err != nil {
 				return err
@@ -67,10 +71,42 @@ func projectCommands() *cobra.Command {
 	createCmd.MarkFlagRequired("prefix")
 	createCmd.Flags().StringVar(&icon, "icon", "", "emoji icon")
 	createCmd.Flags().StringVar(&color, "color", "#3B82F6", "hex color")
+	createCmd.Flags().StringVar(&blockedMoves, "blocked-moves", "allow", "moving blocked tickets forward: allow|warn|block")
+	createCmd.Flags().StringVar(&estimateUnit, "estimate-unit", "points", "what ticket estimates count: points|hours")
+
+	var clonePrefix string
+	var cloneTickets bool
+	cloneCmd := &cobra.Command{
+		Use:   "clone [id] [name]",
+		Short: "Copy a project's settings, workflow, milestones and templates into a new project",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			p, err := store.CloneProject(args[0], models.CloneProjectRequest{
+				Name:    args[1],
+				Prefix:  clonePrefix,
+				Tickets: cloneTickets,
+			})
+			if err != nil {
+				return err
+			}
+			if p == nil {
+				return fmt.Errorf("project not found")
+			}
+			fmt.Printf("Created project %s [%s] (%s)\n", p.Name, p.Prefix, p.ID)
+			return nil
+		},
+	}
+	cloneCmd.Flags().StringVar(&clonePrefix, "prefix", "", "prefix of the new project (required)")
+	cloneCmd.MarkFlagRequired("prefix")
+	cloneCmd.Flags().BoolVar(&cloneTickets, "tickets", false, "copy the tickets, subtasks, labels and links too")
 
 	deleteCmd := &cobra.Command{
 		Use:   "delete [id]",
-		Short: "Delete a project",
+		Short: "Move a project and its tickets to the trash",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, err := openStore()
@@ -80,11 +116,167 @@ func projectCommands() *cobra.Command {
 			if err := store.DeleteProject(args[0]); err != nil {
 				return err
 			}
-			fmt.Println("Project deleted.")
+			fmt.Println("Project moved to the trash.")
+			return nil
+		},
+	}
+
+	var setStatuses []string
+	workflowCmd := &cobra.Command{
+		Use:   "workflow [id]",
+		Short: "Show or replace a project's workflow statuses",
+		Long: "Show a project's workflow statuses. Pass --set once per status, in column order,\n" +
+			"as key[:category[:name]] to replace the workflow (categories: backlog, active, done).",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			var wf *models.Workflow
+			if len(setStatuses) > 0 {
+				req := models.UpdateWorkflowRequest{}
+				for _, spec := range setStatuses {
+					parts := strings.SplitN(spec, ":", 3)
+					st := models.WorkflowStatus{Status: parts[0]}
+					if len(parts) > 1 {
+						st.Category = parts[1]
+					}
+					if len(parts) > 2 {
+						st.Name = parts[2]
+					}
+					req.Statuses = append(req.Statuses, st)
+				}
+				wf, err = store.UpdateWorkflow(args[0], req)
+			} else {
+				wf, err = store.GetWorkflow(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if wf == nil {
+				return fmt.Errorf("project not found")
+			}
+			for _, st := range wf.Statuses {
+				fmt.Printf("%-16s %-20s (%s)\n", st.Status, st.Name, st.Category)
+			}
+			return nil
+		},
+	}
+	workflowCmd.Flags().StringArrayVar(&setStatuses, "set", nil, "status as key[:category[:name]], repeat in column order")
+
+	var setLimits []string
+	var clearLimits bool
+	wipCmd := &cobra.Command{
+		Use:   "wip [id]",
+		Short: "Show or replace a project's WIP limits",
+		Long: "Show a project's work-in-progress limits. Pass --set once per limit as\n" +
+			"status:limit[:policy[:team-id]] to replace them (policies: warn, block), or --clear\n" +
+			"to remove them all. Moving a ticket into a full status warns, or fails with block.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			var limits *models.WIPLimits
+			if len(setLimits) > 0 || clearLimits {
+				req := models.UpdateWIPLimitsRequest{Limits: []models.WIPLimit{}}
+				for _, spec := range setLimits {
+					parts := strings.SplitN(spec, ":", 4)
+					if len(parts) < 2 {
+						return fmt.Errorf("--set %q must be written as status:limit[:policy[:team-id]]", spec)
+					}
+					n, err := strconv.Atoi(parts[1])
+					if err != nil {
+						return fmt.Errorf("--set %q: limit must be a number", spec)
+					}
+					l := models.WIPLimit{Status: parts[0], Limit: n}
+					if len(parts) > 2 {
+						l.Policy = parts[2]
+					}
+					if len(parts) > 3 {
+						l.TeamID = &parts[3]
+					}
+					req.Limits = append(req.Limits, l)
+				}
+				limits, err = store.UpdateWIPLimits(args[0], req)
+			} else {
+				limits, err = store.GetWIPLimits(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if limits == nil {
+				return fmt.Errorf("project not found")
+			}
+			if len(limits.Limits) == 0 {
+				fmt.Println("No WIP limits.")
+				return nil
+			}
+			for _, l := range limits.Limits {
+				scope := "project"
+				if l.TeamID != nil {
+					scope = "team " + *l.TeamID
+				}
+				fmt.Printf("%-16s %4d  %-5s  %s\n", l.Status, l.Limit, l.Policy, scope)
+			}
 			return nil
 		},
 	}
+	wipCmd.Flags().StringArrayVar(&setLimits, "set", nil, "limit as status:limit[:policy[:team-id]], repeat for each limit")
+	wipCmd.Flags().BoolVar(&clearLimits, "clear", false, "remove all WIP limits")
 
-	cmd.AddCommand(listCmd, createCmd, deleteCmd)
+	cmd.AddCommand(listCmd, createCmd, cloneCmd, deleteCmd, workflowCmd, wipCmd, timeRollupCmd(models.RollupByProject))
 	return cmd
 }
+
+// timeRollupCmd shows estimated against logged time per project or team.
+func timeRollupCmd(by string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "time",
+		Short: "Show estimated vs logged time per " + by,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			rollups, err := store.TimeRollups(by)
+			if err != nil {
+				return err
+			}
+			if len(rollups) == 0 {
+				fmt.Printf("No %ss found.\n", by)
+				return nil
+			}
+			for _, r := range rollups {
+				var estimates []string
+				if r.EstimatedPoints != 0 {
+					estimates = append(estimates, fmt.Sprintf("%g points", r.EstimatedPoints))
+				}
+				if r.EstimatedHours != 0 {
+					estimates = append(estimates, fmt.Sprintf("%gh", r.EstimatedHours))
+				}
+				if len(estimates) == 0 {
+					estimates = append(estimates, "nothing")
+				}
+				fmt.Printf("%s: %s estimated on %d/%d tickets, %s logged (%s)\n", r.Name, strings.Join(estimates, " + "),
+					r.EstimatedTickets, r.Tickets, formatMinutes(r.LoggedMinutes), r.ID)
+			}
+			return nil
+		},
+	}
+}
+
+// formatMinutes renders minutes as 1h30m, 2h or 45m.
+func formatMinutes(minutes int) string {
+	h, m := minutes/60, minutes%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}
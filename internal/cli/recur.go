@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// schedulerActor is who recurring tickets are attributed to.
+var schedulerActor = models.Actor{Name: "scheduler", Source: models.SourceScheduler}
+
+func recurCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recur",
+		Short: "Manage recurring tickets created on a schedule",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recurring tickets and when they next fire",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			recurrences, err := store.ListRecurrences(listProject)
+			if err != nil {
+				return err
+			}
+			if len(recurrences) == 0 {
+				fmt.Println("No recurring tickets found.")
+				return nil
+			}
+			for _, r := range recurrences {
+				next := "next " + r.NextRunAt.Local().Format("2006-01-02 15:04")
+				if r.Paused {
+					next = "paused"
+				}
+				fmt.Printf("%q %s, %s (%s)\n", r.Ticket.Title, r.Rule, next, r.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "filter by project ID")
+
+	var req models.CreateRecurrenceRequest
+	var createDescription, createPriority, createTeam, createAssignee string
+	var createLabels []string
+	createCmd := &cobra.Command{
+		Use:   "create [title]",
+		Short: "Create a ticket on a schedule",
+		Long: "Create a ticket titled [title] every time --rule fires. Rules are cron expressions\n" +
+			"(minute hour day-of-month month day-of-week, e.g. \"0 9 * * MON\") or @hourly, @daily,\n" +
+			"@weekly, @monthly or @yearly, in local time. {date} in the title becomes the date.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Ticket = models.CreateTicketRequest{
+				Title:       args[0],
+				Description: createDescription,
+				Priority:    createPriority,
+				Labels:      createLabels,
+			}
+			if createTeam != "" {
+				req.Ticket.TeamID = &createTeam
+			}
+			if createAssignee != "" {
+				assigneeID, err := resolveMember(store, createAssignee)
+				if err != nil {
+					return err
+				}
+				req.Ticket.AssigneeID = &assigneeID
+			}
+			r, err := store.CreateRecurrence(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created recurring ticket, next on %s (%s)\n", r.NextRunAt.Local().Format("2006-01-02 15:04"), r.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.ProjectID, "project", "", "project ID (required)")
+	createCmd.MarkFlagRequired("project")
+	createCmd.Flags().StringVar(&req.Rule, "rule", "", "schedule, e.g. \"0 9 * * MON\" or @monthly (required)")
+	createCmd.MarkFlagRequired("rule")
+	createCmd.Flags().StringVar(&createDescription, "description", "", "ticket description")
+	createCmd.Flags().StringVar(&createPriority, "priority", "", "ticket priority (urgent|high|medium|low)")
+	createCmd.Flags().StringVar(&createTeam, "team", "", "team ID")
+	createCmd.Flags().StringVar(&createAssignee, "assignee", "", "assignee ID, handle or \"me\"")
+	createCmd.Flags().StringArrayVar(&createLabels, "label", nil, "label ID, repeat for several")
+
+	setPaused := func(paused bool) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			r, err := store.UpdateRecurrence(args[0], models.UpdateRecurrenceRequest{Paused: &paused})
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				return fmt.Errorf("recurrence not found")
+			}
+			if paused {
+				fmt.Println("Recurrence paused.")
+			} else {
+				fmt.Printf("Recurrence resumed, next on %s\n", r.NextRunAt.Local().Format("2006-01-02 15:04"))
+			}
+			return nil
+		}
+	}
+	pauseCmd := &cobra.Command{
+		Use:   "pause [id]",
+		Short: "Stop creating tickets until resumed",
+		Args:  cobra.ExactArgs(1),
+		RunE:  setPaused(true),
+	}
+	resumeCmd := &cobra.Command{
+		Use:   "resume [id]",
+		Short: "Resume a paused recurrence from now on",
+		Args:  cobra.ExactArgs(1),
+		RunE:  setPaused(false),
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a recurrence; tickets it created are kept",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteRecurrence(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Recurrence deleted.")
+			return nil
+		},
+	}
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Create the tickets of every recurrence that is due, for use from cron",
+		Long: "Create the tickets of every recurrence that is due. taskboard start does this every\n" +
+			"minute; run this from cron when the server is not running. Running it more than once,\n" +
+			"or alongside the server, never creates an occurrence twice.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := openDB()
+			if err != nil {
+				return err
+			}
+			store := db.NewStore(database).WithActor(schedulerActor)
+			runs, err := store.RunRecurrences(time.Now())
+			for _, run := range runs {
+				fmt.Printf("Created %s for %s\n", run.TicketKey, run.Occurrence.Format("2006-01-02 15:04"))
+			}
+			return err
+		},
+	}
+
+	cmd.AddCommand(listCmd, createCmd, pauseCmd, resumeCmd, deleteCmd, runCmd)
+	return cmd
+}
+
+// runScheduler creates due recurring tickets every minute, for as long as
+// the server runs.
+func runScheduler(store *db.Store) {
+	store = store.WithActor(schedulerActor)
+	for {
+		runs, err := store.RunRecurrences(time.Now())
+		for _, run := range runs {
+			fmt.Printf("Created recurring ticket %s\n", run.TicketKey)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "recurring tickets: %v\n", err)
+		}
+		time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	}
+}
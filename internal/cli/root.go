@@ -10,10 +10,12 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tcarac/taskboard/internal/db"
 	"github.com/tcarac/taskboard/internal/mcp"
+	"github.com/tcarac/taskboard/internal/models"
 	"github.com/tcarac/taskboard/internal/server"
 )
 
@@ -42,12 +44,17 @@ func NewRootCmd(webFS fs.FS) *cobra.Command {
 				return fmt.Errorf("opening database: %w", err)
 			}
 			store := db.NewStore(database)
+			go runScheduler(store)
+			if trashRetentionDays > 0 {
+				go runTrashPurge(store, time.Duration(trashRetentionDays)*24*time.Hour)
+			}
 			srv := server.New(store, webFS)
 			return srv.ListenAndServe(port)
 		},
 	}
 	startCmd.Flags().IntVarP(&port, "port", "p", 3010, "port to listen on")
 	startCmd.Flags().BoolVar(&foreground, "foreground", false, "run in foreground instead of as a daemon")
+	startCmd.Flags().IntVar(&trashRetentionDays, "trash-retention-days", 30, "purge deleted items after this many days, 0 to keep them")
 
 	stopCmd := &cobra.Command{
 		Use:   "stop",
@@ -130,7 +137,17 @@ func NewRootCmd(webFS fs.FS) *cobra.Command {
 	root.AddCommand(startCmd, stopCmd, mcpCmd, clearCmd)
 	root.AddCommand(projectCommands())
 	root.AddCommand(teamCommands())
+	root.AddCommand(memberCommands())
 	root.AddCommand(ticketCommands())
+	root.AddCommand(sprintCommands())
+	root.AddCommand(milestoneCommands())
+	root.AddCommand(recurCommands())
+	root.AddCommand(templateCommands())
+	root.AddCommand(fieldCommands())
+	root.AddCommand(viewCommands())
+	root.AddCommand(trashCommands())
+	root.AddCommand(inboxCommand())
+	root.AddCommand(undoCommand(), redoCommand())
 
 	return root
 }
@@ -153,7 +170,7 @@ func openStore() (*db.Store, error) {
 	if err != nil {
 		return nil, err
 	}
-	return db.NewStore(database), nil
+	return db.NewStore(database).WithActor(models.Actor{Name: os.Getenv("USER"), Source: models.SourceCLI}), nil
 }
 
 func daemonize(port int) error {
@@ -175,7 +192,8 @@ func daemonize(port int) error {
 		return fmt.Errorf("finding executable: %w", err)
 	}
 
-	daemonArgs := []string{"start", "--port", strconv.Itoa(port), "--foreground"}
+	daemonArgs := []string{"start", "--port", strconv.Itoa(port), "--foreground",
+		"--trash-retention-days", strconv.Itoa(trashRetentionDays)}
 	if dbPath != "" {
 		daemonArgs = append([]string{"--db", dbPath}, daemonArgs...)
 	}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func sprintCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sprint",
+		Short: "Manage sprints (time boxes tickets are scheduled into)",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List sprints",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			sprints, err := store.ListSprints(listProject)
+			if err != nil {
+				return err
+			}
+			if len(sprints) == 0 {
+				fmt.Println("No sprints found.")
+				return nil
+			}
+			for _, sp := range sprints {
+				fmt.Printf("%s %s..%s [%s] %d/%d done (%s)\n", sp.Name, sp.StartDate.Format("2006-01-02"),
+					sp.EndDate.Format("2006-01-02"), sp.Status, sp.DoneCount, sp.TicketCount, sp.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "only this project's sprints and cross-project ones")
+
+	var req models.CreateSprintRequest
+	var createProject string
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a new sprint",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Name = args[0]
+			if createProject != "" {
+				req.ProjectID = &createProject
+			}
+			sp, err := store.CreateSprint(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created sprint %s (%s)\n", sp.Name, sp.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.StartDate, "start", "", "start date (YYYY-MM-DD, required)")
+	createCmd.MarkFlagRequired("start")
+	createCmd.Flags().StringVar(&req.EndDate, "end", "", "end date (YYYY-MM-DD, required)")
+	createCmd.MarkFlagRequired("end")
+	createCmd.Flags().StringVar(&req.Goal, "goal", "", "what the sprint should achieve")
+	createCmd.Flags().StringVar(&createProject, "project", "", "project ID (default: a cross-project sprint)")
+
+	var boardProject string
+	boardCmd := &cobra.Command{
+		Use:   "board [id]",
+		Short: "Show a sprint's board, by default the active sprint's",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			sp, err := resolveSprint(store, args, boardProject)
+			if err != nil {
+				return err
+			}
+			board, err := store.GetSprintBoard(sp.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %s..%s [%s]\n", sp.Name, sp.StartDate.Format("2006-01-02"), sp.EndDate.Format("2006-01-02"), sp.Status)
+			if sp.Goal != "" {
+				fmt.Printf("Goal: %s\n", sp.Goal)
+			}
+			for _, col := range board.Columns {
+				fmt.Printf("\n%s (%d)\n", col.Name, len(col.Tickets))
+				for _, t := range col.Tickets {
+					fmt.Printf("  [%s] %s (%s, %s)\n", t.DisplayKey(), t.Title, t.Priority, t.ID)
+				}
+			}
+			return nil
+		},
+	}
+	boardCmd.Flags().StringVar(&boardProject, "project", "", "pick the active sprint of this project ID")
+
+	var closeNext string
+	closeCmd := &cobra.Command{
+		Use:   "close [id]",
+		Short: "Close a sprint, rolling unfinished tickets into the next one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			result, err := store.CloseSprint(args[0], models.CloseSprintRequest{NextSprintID: closeNext})
+			if err != nil {
+				return err
+			}
+			if result == nil {
+				return fmt.Errorf("sprint not found")
+			}
+			fmt.Printf("Closed sprint %s\n", result.Sprint.Name)
+			switch {
+			case len(result.Moved) == 0:
+				fmt.Println("Every ticket was done.")
+			case result.NextSprintID == "":
+				fmt.Printf("Moved %d unfinished tickets back to the backlog.\n", len(result.Moved))
+			default:
+				fmt.Printf("Moved %d unfinished tickets to sprint %s.\n", len(result.Moved), result.NextSprintID)
+			}
+			return nil
+		},
+	}
+	closeCmd.Flags().StringVar(&closeNext, "next", "", "sprint ID to roll unfinished tickets into (default: the next open sprint)")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a sprint; its tickets go back to the backlog",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteSprint(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Sprint deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, createCmd, boardCmd, closeCmd, deleteCmd)
+	return cmd
+}
+
+// resolveSprint returns the sprint named by the only argument, or the active
+// sprint of projectID when there is none.
+func resolveSprint(store *db.Store, args []string, projectID string) (*models.Sprint, error) {
+	if len(args) == 1 {
+		sp, err := store.GetSprint(args[0])
+		if err == nil && sp == nil {
+			err = fmt.Errorf("sprint not found")
+		}
+		return sp, err
+	}
+	sp, err := store.CurrentSprint(projectID)
+	if err == nil && sp == nil {
+		err = fmt.Errorf("no active sprint")
+	}
+	return sp, err
+}
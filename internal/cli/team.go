@@ -61,7 +61,7 @@ func teamCommands() *cobra.Command {
 
 	deleteCmd := &cobra.Command{
 		Use:   "delete [id]",
-		Short: "Delete a team",
+		Short: "Move a team to the trash",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, err := openStore()
@@ -71,11 +71,11 @@ func teamCommands() *cobra.Command {
 			if err := store.DeleteTeam(args[0]); err != nil {
 				return err
 			}
-			fmt.Println("Team deleted.")
+			fmt.Println("Team moved to the trash.")
 			return nil
 		},
 	}
 
-	cmd.AddCommand(listCmd, createCmd, deleteCmd)
+	cmd.AddCommand(listCmd, createCmd, deleteCmd, timeRollupCmd(models.RollupByTeam))
 	return cmd
 }
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func templateCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage ticket templates (prefilled tickets such as bug reports)",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List ticket templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			templates, err := store.ListTemplates(listProject)
+			if err != nil {
+				return err
+			}
+			if len(templates) == 0 {
+				fmt.Println("No templates found.")
+				return nil
+			}
+			for _, t := range templates {
+				fmt.Printf("%s: %s (%s)\n", t.Name, templateSummary(t), t.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "filter by project ID")
+
+	showCmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a ticket template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := store.GetTemplate(args[0])
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("template not found")
+			}
+			fmt.Printf("%s: %s (%s)\n", t.Name, templateSummary(*t), t.ID)
+			if t.Description != "" {
+				fmt.Println()
+				fmt.Println(t.Description)
+			}
+			if len(t.Subtasks) > 0 {
+				fmt.Println()
+				for _, title := range t.Subtasks {
+					fmt.Printf("[ ] %s\n", title)
+				}
+			}
+			return nil
+		},
+	}
+
+	var req models.CreateTemplateRequest
+	var createTeam string
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a ticket template",
+		Long: "Create a ticket template, used with taskboard ticket create --template [name]. In the\n" +
+			"--title pattern {title} becomes the ticket's --title and {date} today's date.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Name = args[0]
+			if createTeam != "" {
+				req.TeamID = &createTeam
+			}
+			t, err := store.CreateTemplate(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created template %s (%s)\n", t.Name, t.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.ProjectID, "project", "", "project ID (required)")
+	createCmd.MarkFlagRequired("project")
+	createCmd.Flags().StringVar(&req.Title, "title", "", "title pattern, e.g. \"Bug: {title}\"")
+	createCmd.Flags().StringVar(&req.Description, "description", "", "description skeleton")
+	createCmd.Flags().StringVar(&req.Priority, "priority", "", "default priority (urgent|high|medium|low)")
+	createCmd.Flags().StringVar(&createTeam, "team", "", "default team ID")
+	createCmd.Flags().StringArrayVar(&req.Labels, "label", nil, "label ID, repeat for several")
+	createCmd.Flags().StringArrayVar(&req.Subtasks, "subtask", nil, "subtask title, repeat for several")
+
+	var updateName, updateTitle, updateDescription, updatePriority, updateTeam string
+	var updateLabels, updateSubtasks []string
+	updateCmd := &cobra.Command{
+		Use:   "update [id]",
+		Short: "Update a ticket template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			var req models.UpdateTemplateRequest
+			if cmd.Flags().Changed("name") {
+				req.Name = &updateName
+			}
+			if cmd.Flags().Changed("title") {
+				req.Title = &updateTitle
+			}
+			if cmd.Flags().Changed("description") {
+				req.Description = &updateDescription
+			}
+			if cmd.Flags().Changed("priority") {
+				req.Priority = &updatePriority
+			}
+			if cmd.Flags().Changed("team") {
+				req.TeamID = &updateTeam
+			}
+			if cmd.Flags().Changed("label") {
+				req.Labels = append([]string{}, updateLabels...)
+			}
+			if cmd.Flags().Changed("subtask") {
+				req.Subtasks = append([]string{}, updateSubtasks...)
+			}
+			t, err := store.UpdateTemplate(args[0], req)
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("template not found")
+			}
+			fmt.Printf("Updated template %s\n", t.Name)
+			return nil
+		},
+	}
+	updateCmd.Flags().StringVar(&updateName, "name", "", "new name")
+	updateCmd.Flags().StringVar(&updateTitle, "title", "", "title pattern")
+	updateCmd.Flags().StringVar(&updateDescription, "description", "", "description skeleton")
+	updateCmd.Flags().StringVar(&updatePriority, "priority", "", "default priority, or \"\" to clear")
+	updateCmd.Flags().StringVar(&updateTeam, "team", "", "default team ID, or \"\" to clear")
+	updateCmd.Flags().StringArrayVar(&updateLabels, "label", nil, "label ID, repeat for several; replaces the current labels")
+	updateCmd.Flags().StringArrayVar(&updateSubtasks, "subtask", nil, "subtask title, repeat for several; replaces the current subtasks")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a ticket template; tickets created from it are kept",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteTemplate(args[0]); err != nil {
+				return err
+			}
+			fmt.Println("Template deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, showCmd, createCmd, updateCmd, deleteCmd)
+	return cmd
+}
+
+// templateSummary describes what a template fills in, such as
+// `"Bug: {title}"; high; labels bug, ui; 3-item checklist`.
+func templateSummary(t models.TicketTemplate) string {
+	var parts []string
+	if t.Title != "" {
+		parts = append(parts, fmt.Sprintf("%q", t.Title))
+	}
+	if t.Priority != "" {
+		parts = append(parts, t.Priority)
+	}
+	if len(t.Labels) > 0 {
+		names := make([]string, len(t.Labels))
+		for i, l := range t.Labels {
+			names[i] = l.Name
+		}
+		parts = append(parts, "labels "+strings.Join(names, ", "))
+	}
+	if len(t.Subtasks) > 0 {
+		parts = append(parts, fmt.Sprintf("%d-item checklist", len(t.Subtasks)))
+	}
+	if len(parts) == 0 {
+		return "empty"
+	}
+	return strings.Join(parts, "; ")
+}
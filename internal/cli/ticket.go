@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
 	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/query"
 )
 
 func ticketCommands() *cobra.Command {
@@ -13,7 +18,9 @@ func ticketCommands() *cobra.Command {
 		Short: "Manage tickets",
 	}
 
-	var projectID, status, priority string
+	var projectID, status, priority, assignee, sprint, milestone, expr, sortKey, order, cursor string
+	var limit int
+	var listFields []string
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List tickets",
@@ -22,30 +29,107 @@ func ticketCommands() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			tickets, err := store.ListTickets(models.TicketFilter{
-				ProjectID: projectID,
-				Status:    status,
-				Priority:  priority,
-			})
+			filter := models.TicketFilter{
+				ProjectID:   projectID,
+				AssigneeID:  assignee,
+				SprintID:    sprint,
+				MilestoneID: milestone,
+				Status:      status,
+				Priority:    priority,
+				Expr:        expr,
+				Sort:        sortKey,
+				Order:       order,
+				Limit:       limit,
+				Cursor:      cursor,
+			}
+			if assignee != "" && assignee != "none" {
+				if filter.AssigneeID, err = resolveMember(store, assignee); err != nil {
+					return err
+				}
+			}
+			fields, err := parseFieldFlags(listFields)
 			if err != nil {
 				return err
 			}
-			if len(tickets) == 0 {
+			for key, value := range fields {
+				if filter.Fields == nil {
+					filter.Fields = map[string]string{}
+				}
+				filter.Fields[key] = value.(string)
+			}
+			page, err := store.ListTicketPage(filter)
+			var qerr *query.Error
+			if errors.As(err, &qerr) {
+				return fmt.Errorf("%w\n\n%s", err, qerr.Pointer(expr))
+			}
+			if err != nil {
+				return err
+			}
+			if len(page.Tickets) == 0 {
 				fmt.Println("No tickets found.")
 				return nil
 			}
-			for _, t := range tickets {
+			for _, t := range page.Tickets {
 				key := t.DisplayKey()
 				fmt.Printf("[%s] %s - %s (%s, %s)\n", key, t.Title, t.Status, t.Priority, t.ID)
 			}
+			if page.NextCursor != "" {
+				fmt.Printf("\nShowing %d of %d. Next page: --cursor %s\n", len(page.Tickets), page.Total, page.NextCursor)
+			}
 			return nil
 		},
 	}
 	listCmd.Flags().StringVar(&projectID, "project", "", "filter by project ID")
-	listCmd.Flags().StringVar(&status, "status", "", "filter by status (todo|in_progress|done)")
+	listCmd.Flags().StringVar(&status, "status", "", "filter by workflow status (e.g. todo)")
 	listCmd.Flags().StringVar(&priority, "priority", "", "filter by priority (urgent|high|medium|low)")
+	listCmd.Flags().StringVar(&assignee, "assignee", "", "filter by assignee ID, handle, \"me\" or \"none\"")
+	listCmd.Flags().StringVar(&sprint, "sprint", "", "filter by sprint ID, or \"none\" for the backlog")
+	listCmd.Flags().StringVar(&milestone, "milestone", "", "filter by milestone ID, or \"none\"")
+	listCmd.Flags().StringVar(&expr, "query", "", "filter by a query, e.g. 'status:!done priority>=high label:bug is:blocked'")
+	listCmd.Flags().StringVar(&sortKey, "sort", "", "sort by position|created|updated|due|priority|number")
+	listCmd.Flags().StringVar(&order, "order", "", "sort direction (asc|desc, default depends on --sort)")
+	listCmd.Flags().IntVar(&limit, "limit", 0, "maximum tickets to show (default all)")
+	listCmd.Flags().StringVar(&cursor, "cursor", "", "continue from the cursor printed by a previous page")
+	listCmd.Flags().StringArrayVar(&listFields, "field", nil, "filter by a custom field as key=value, or key=none for unset; repeat for several")
+
+	var searchProject string
+	var searchLimit int
+	searchCmd := &cobra.Command{
+		Use:   "search [text]",
+		Short: "Full-text search tickets, subtasks and comments",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			tickets, err := store.ListTickets(models.TicketFilter{
+				ProjectID: searchProject,
+				Query:     args[0],
+				Limit:     searchLimit,
+			})
+			if err != nil {
+				return err
+			}
+			if len(tickets) == 0 {
+				fmt.Println("No tickets found.")
+				return nil
+			}
+			for _, t := range tickets {
+				fmt.Printf("[%s] %s - %s (%s)\n", t.DisplayKey(), t.Title, t.Status, t.ID)
+				if t.Snippet != "" {
+					fmt.Printf("    %s\n", t.Snippet)
+				}
+			}
+			return nil
+		},
+	}
+	searchCmd.Flags().StringVar(&searchProject, "project", "", "limit to a project ID")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "maximum number of results")
 
-	var createProject, createPriority, createDue, createTeam string
+	var createProject, createPriority, createDue, createTeam, createAssignee, createSprint, createMilestone, createTemplate string
+	var createEstimate float64
+	var createFields []string
 	createCmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new ticket",
@@ -59,6 +143,7 @@ func ticketCommands() *cobra.Command {
 				ProjectID: createProject,
 				Title:     title,
 				Priority:  createPriority,
+				Template:  createTemplate,
 			}
 			if createDue != "" {
 				req.DueDate = &createDue
@@ -66,33 +151,312 @@ func ticketCommands() *cobra.Command {
 			if createTeam != "" {
 				req.TeamID = &createTeam
 			}
+			if createSprint != "" {
+				req.SprintID = &createSprint
+			}
+			if createMilestone != "" {
+				req.MilestoneID = &createMilestone
+			}
+			if createEstimate != 0 {
+				req.Estimate = &createEstimate
+			}
+			if createAssignee != "" {
+				assigneeID, err := resolveMember(store, createAssignee)
+				if err != nil {
+					return err
+				}
+				req.AssigneeID = &assigneeID
+			}
+			if req.Fields, err = parseFieldFlags(createFields); err != nil {
+				return err
+			}
 			t, err := store.CreateTicket(req)
 			if err != nil {
 				return err
 			}
 			fmt.Printf("Created ticket %s: %s (%s)\n", t.DisplayKey(), t.Title, t.ID)
+			for _, w := range t.Warnings {
+				fmt.Printf("Warning: %s\n", w)
+			}
 			return nil
 		},
 	}
 	createCmd.Flags().StringVar(&createProject, "project", "", "project ID (required)")
 	createCmd.MarkFlagRequired("project")
-	createCmd.Flags().String("title", "", "ticket title (required)")
-	createCmd.MarkFlagRequired("title")
-	createCmd.Flags().StringVar(&createPriority, "priority", "medium", "priority (urgent|high|medium|low)")
+	createCmd.Flags().String("title", "", "ticket title (required unless the template sets one)")
+	createCmd.Flags().StringVar(&createTemplate, "template", "", "name or ID of a project ticket template to start from")
+	createCmd.Flags().StringVar(&createPriority, "priority", "", "priority (urgent|high|medium|low, default medium)")
 	createCmd.Flags().StringVar(&createDue, "due", "", "due date (YYYY-MM-DD)")
 	createCmd.Flags().StringVar(&createTeam, "team", "", "team ID")
+	createCmd.Flags().StringVar(&createAssignee, "assignee", "", "assignee ID, handle or \"me\"")
+	createCmd.Flags().StringVar(&createSprint, "sprint", "", "sprint ID")
+	createCmd.Flags().StringVar(&createMilestone, "milestone", "", "milestone ID")
+	createCmd.Flags().Float64Var(&createEstimate, "estimate", 0, "estimate in the project's unit (points or hours)")
+	createCmd.Flags().StringArrayVar(&createFields, "field", nil, "custom field value as key=value, repeat for several")
 
-	var moveStatus string
-	moveCmd := &cobra.Command{
-		Use:   "move [id]",
-		Short: "Move ticket to different status",
+	var setFields []string
+	fieldsCmd := &cobra.Command{
+		Use:   "fields [id]",
+		Short: "Show a ticket's custom field values, or set them with --field",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, err := openStore()
 			if err != nil {
 				return err
 			}
-			t, err := store.MoveTicket(args[0], models.MoveTicketRequest{Status: moveStatus})
+			values, err := parseFieldFlags(setFields)
+			if err != nil {
+				return err
+			}
+			var t *models.Ticket
+			if values != nil {
+				t, err = store.UpdateTicket(args[0], models.UpdateTicketRequest{Fields: values})
+			} else {
+				t, err = store.GetTicket(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fields, err := store.ListCustomFields(t.ProjectID)
+			if err != nil {
+				return err
+			}
+			if len(fields) == 0 {
+				fmt.Println("The ticket's project has no custom fields.")
+				return nil
+			}
+			for _, f := range fields {
+				value := db.FieldText(t.Fields[f.Key])
+				if value == "" {
+					value = "-"
+				}
+				fmt.Printf("%s: %s\n", f.Name, value)
+			}
+			return nil
+		},
+	}
+	fieldsCmd.Flags().StringArrayVar(&setFields, "field", nil, "set a value as key=value, or key= to clear it; repeat for several")
+
+	estimateCmd := &cobra.Command{
+		Use:   "estimate [id] [estimate]",
+		Short: "Set a ticket's estimate in its project's unit, or 0 to clear it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			estimate, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return fmt.Errorf("estimate must be a number, got %q", args[1])
+			}
+			t, err := store.UpdateTicket(args[0], models.UpdateTicketRequest{Estimate: &estimate})
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("Estimated %s at %s\n", t.DisplayKey(), args[1])
+			return nil
+		},
+	}
+
+	var logDate, logAuthor string
+	logCmd := &cobra.Command{
+		Use:   "log [id] [duration] [note]",
+		Short: "Log time spent on a ticket, e.g. 2h, 45m or 1h30m",
+		Args:  cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req := models.LogTimeRequest{Duration: args[1], Date: logDate, Author: logAuthor}
+			if len(args) == 3 {
+				req.Note = args[2]
+			}
+			e, err := store.LogTime(args[0], req)
+			if err != nil {
+				return err
+			}
+			if e == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("Logged %s on %s (%s)\n", formatMinutes(e.Minutes), e.Date.Format("2006-01-02"), e.ID)
+			return nil
+		},
+	}
+	logCmd.Flags().StringVar(&logDate, "date", "", "day the work was done (YYYY-MM-DD, default today)")
+	logCmd.Flags().StringVar(&logAuthor, "author", "", "who did the work (default: $USER)")
+
+	timeCmd := &cobra.Command{
+		Use:   "time [id]",
+		Short: "List the time logged on a ticket against its estimate",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := store.GetTicket(args[0])
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			entries, err := store.ListTimeEntries(t.ID)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				note := ""
+				if e.Note != "" {
+					note = ": " + e.Note
+				}
+				fmt.Printf("%s %6s %s%s (%s)\n", e.Date.Format("2006-01-02"), formatMinutes(e.Minutes), e.Author, note, e.ID)
+			}
+			estimate := "no estimate"
+			if t.Estimate != nil {
+				p, err := store.GetProject(t.ProjectID)
+				if err != nil {
+					return err
+				}
+				estimate = fmt.Sprintf("estimate %g %s", *t.Estimate, p.EstimateUnit)
+			}
+			fmt.Printf("%s: %s logged, %s\n", t.DisplayKey(), formatMinutes(t.LoggedMinutes), estimate)
+			return nil
+		},
+	}
+
+	scheduleCmd := &cobra.Command{
+		Use:   "sprint [id] [sprint-id]",
+		Short: "Schedule a ticket into a sprint, or \"none\" to move it back to the backlog",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			sprintID := args[1]
+			if sprintID == "none" {
+				sprintID = ""
+			}
+			t, err := store.UpdateTicket(args[0], models.UpdateTicketRequest{SprintID: &sprintID})
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			if sprintID == "" {
+				fmt.Printf("Moved %s to the backlog\n", t.DisplayKey())
+			} else {
+				fmt.Printf("Scheduled %s into sprint %s\n", t.DisplayKey(), sprintID)
+			}
+			return nil
+		},
+	}
+
+	milestoneCmd := &cobra.Command{
+		Use:   "milestone [id] [milestone-id]",
+		Short: "Put a ticket in a milestone, or \"none\" to take it out",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			milestoneID := args[1]
+			if milestoneID == "none" {
+				milestoneID = ""
+			}
+			t, err := store.UpdateTicket(args[0], models.UpdateTicketRequest{MilestoneID: &milestoneID})
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			if milestoneID == "" {
+				fmt.Printf("Removed %s from its milestone\n", t.DisplayKey())
+			} else {
+				fmt.Printf("Added %s to milestone %s\n", t.DisplayKey(), milestoneID)
+			}
+			return nil
+		},
+	}
+
+	assignCmd := &cobra.Command{
+		Use:   "assign [id] [member]",
+		Short: "Assign a ticket to a member (ID, handle or \"me\"), or \"none\" to unassign",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			assigneeID := ""
+			if args[1] != "none" {
+				if assigneeID, err = resolveMember(store, args[1]); err != nil {
+					return err
+				}
+			}
+			t, err := store.UpdateTicket(args[0], models.UpdateTicketRequest{AssigneeID: &assigneeID})
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			if assigneeID == "" {
+				fmt.Printf("Unassigned %s\n", t.DisplayKey())
+			} else {
+				fmt.Printf("Assigned %s to %s\n", t.DisplayKey(), args[1])
+			}
+			return nil
+		},
+	}
+
+	var moveStatus, moveBefore, moveAfter string
+	moveCmd := &cobra.Command{
+		Use:   "move [id-or-key]",
+		Short: "Move ticket to a different status, or reorder it in its column",
+		Long: "Move a ticket to the bottom of the --status column, or place it right --after or\n" +
+			"--before another ticket (ID or key such as AUTH-3), in that ticket's column.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if moveStatus == "" && moveBefore == "" && moveAfter == "" {
+				return fmt.Errorf("--status, --after or --before is required")
+			}
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			req := models.MoveTicketRequest{Status: moveStatus}
+			if moveAfter != "" {
+				after, err := resolveTicket(store, moveAfter)
+				if err != nil {
+					return err
+				}
+				req.After = after.ID
+			}
+			if moveBefore != "" {
+				before, err := resolveTicket(store, moveBefore)
+				if err != nil {
+					return err
+				}
+				req.Before = before.ID
+			}
+			t, err = store.MoveTicket(t.ID, req)
 			if err != nil {
 				return err
 			}
@@ -100,15 +464,46 @@ func ticketCommands() *cobra.Command {
 				return fmt.Errorf("ticket not found")
 			}
 			fmt.Printf("Moved %s to %s\n", t.DisplayKey(), t.Status)
+			for _, w := range t.Warnings {
+				fmt.Printf("Warning: %s\n", w)
+			}
 			return nil
 		},
 	}
-	moveCmd.Flags().StringVar(&moveStatus, "status", "", "target status (required)")
-	moveCmd.MarkFlagRequired("status")
+	moveCmd.Flags().StringVar(&moveStatus, "status", "", "target status (default: the status of the --after or --before ticket)")
+	moveCmd.Flags().StringVar(&moveAfter, "after", "", "ticket ID or key to place this one right after")
+	moveCmd.Flags().StringVar(&moveBefore, "before", "", "ticket ID or key to place this one right before")
+
+	var cloneReq models.CloneTicketRequest
+	cloneCmd := &cobra.Command{
+		Use:   "clone [id]",
+		Short: "Copy a ticket as new work, with its labels, blockers and unchecked subtasks",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := store.CloneTicket(args[0], cloneReq)
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			fmt.Printf("Created ticket %s: %s (%s)\n", t.DisplayKey(), t.Title, t.ID)
+			for _, w := range t.Warnings {
+				fmt.Printf("Warning: %s\n", w)
+			}
+			return nil
+		},
+	}
+	cloneCmd.Flags().StringVar(&cloneReq.ProjectID, "project", "", "project ID for the copy (default: the same project)")
+	cloneCmd.Flags().StringVar(&cloneReq.Title, "title", "", "title for the copy (default: the same title)")
 
 	deleteCmd := &cobra.Command{
 		Use:   "delete [id]",
-		Short: "Delete a ticket",
+		Short: "Move a ticket to the trash",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, err := openStore()
@@ -118,11 +513,145 @@ func ticketCommands() *cobra.Command {
 			if err := store.DeleteTicket(args[0]); err != nil {
 				return err
 			}
-			fmt.Println("Ticket deleted.")
+			fmt.Println("Ticket moved to the trash.")
+			return nil
+		},
+	}
+
+	linkCmd := &cobra.Command{
+		Use:   "link [id-or-key] [type] [other-id-or-key]",
+		Short: "Link two tickets (blocks, blocked_by, relates_to, duplicates, caused_by, follows_up, ...)",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			other, err := resolveTicket(store, args[2])
+			if err != nil {
+				return err
+			}
+			t, err = store.AddRelation(t.ID, models.CreateRelationRequest{Type: args[1], TicketID: other.ID})
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			printRelations(t)
+			return nil
+		},
+	}
+
+	unlinkCmd := &cobra.Command{
+		Use:   "unlink [id-or-key] [type] [other-id-or-key]",
+		Short: "Remove a link between two tickets",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			t, err := resolveTicket(store, args[0])
+			if err != nil {
+				return err
+			}
+			other, err := resolveTicket(store, args[2])
+			if err != nil {
+				return err
+			}
+			t, err = store.RemoveRelation(t.ID, args[1], other.ID)
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("ticket not found")
+			}
+			printRelations(t)
 			return nil
 		},
 	}
 
-	cmd.AddCommand(listCmd, createCmd, moveCmd, deleteCmd)
+	var commentAuthor string
+	commentCmd := &cobra.Command{
+		Use:   "comment [id] [body]",
+		Short: "Add a comment to a ticket, or list its comments when no body is given",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				author := commentAuthor
+				if author == "" {
+					author = os.Getenv("USER")
+				}
+				c, err := store.AddComment(args[0], models.CreateCommentRequest{Author: author, Body: args[1]})
+				if err != nil {
+					return err
+				}
+				if c == nil {
+					return fmt.Errorf("ticket not found")
+				}
+				fmt.Printf("Added comment (%s)\n", c.ID)
+				return nil
+			}
+
+			comments, err := store.ListComments(args[0])
+			if err != nil {
+				return err
+			}
+			if len(comments) == 0 {
+				fmt.Println("No comments found.")
+				return nil
+			}
+			for _, c := range comments {
+				edited := ""
+				if c.EditedAt != nil {
+					edited = " (edited)"
+				}
+				fmt.Printf("%s %s%s:\n%s\n\n", c.CreatedAt.Format("2006-01-02 15:04"), c.Author, edited, c.Body)
+			}
+			return nil
+		},
+	}
+	commentCmd.Flags().StringVar(&commentAuthor, "author", "", "comment author (default: $USER)")
+
+	cmd.AddCommand(listCmd, searchCmd, createCmd, fieldsCmd, assignCmd, scheduleCmd, milestoneCmd, estimateCmd, logCmd, timeCmd, moveCmd, cloneCmd, deleteCmd, commentCmd, linkCmd, unlinkCmd)
+	cmd.AddCommand(attachmentCommands()...)
+	cmd.AddCommand(watchCommands()...)
 	return cmd
 }
+
+// resolveTicket finds a ticket by ID or by key, such as AUTH-12.
+func resolveTicket(store *db.Store, ref string) (*models.Ticket, error) {
+	t, err := store.GetTicket(ref)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		t, err = store.GetTicketByKey(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t == nil {
+		return nil, fmt.Errorf("ticket %q not found", ref)
+	}
+	return t, nil
+}
+
+func printRelations(t *models.Ticket) {
+	if len(t.Relations) == 0 {
+		fmt.Printf("%s has no linked tickets.\n", t.DisplayKey())
+		return
+	}
+	for _, rel := range t.Relations {
+		fmt.Printf("%s %s [%s] %s (%s)\n", t.DisplayKey(), rel.Type, rel.TicketKey, rel.Title, rel.Status)
+	}
+}
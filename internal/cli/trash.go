@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+)
+
+// trashRetentionDays is how long taskboard start keeps deleted items before
+// purging them; 0 keeps them until the trash is emptied.
+var trashRetentionDays int
+
+func trashCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or purge deleted projects, tickets, teams and labels",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List deleted items, most recent first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			items, err := store.ListTrash()
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				fmt.Println("The trash is empty.")
+				return nil
+			}
+			for _, item := range items {
+				name := item.Name
+				if item.Tickets == 1 {
+					name += " with 1 ticket"
+				} else if item.Tickets > 1 {
+					name += fmt.Sprintf(" with %d tickets", item.Tickets)
+				}
+				fmt.Printf("%s %s, deleted %s (%s)\n", item.Type, name, item.DeletedAt.Local().Format("2006-01-02 15:04"), item.ID)
+			}
+			return nil
+		},
+	}
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [id]",
+		Short: "Restore a deleted item; a project comes back with its tickets",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			item, err := store.RestoreFromTrash(args[0])
+			if err != nil {
+				return err
+			}
+			if item == nil {
+				return fmt.Errorf("%s is not in the trash", args[0])
+			}
+			fmt.Printf("Restored %s %s\n", item.Type, item.Name)
+			return nil
+		},
+	}
+
+	emptyCmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete everything in the trash",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			if !force {
+				fmt.Print("This will permanently delete everything in the trash. Continue? [y/N] ")
+				var answer string
+				fmt.Scanln(&answer)
+				if answer != "y" && answer != "Y" {
+					fmt.Println("Aborted.")
+					return nil
+				}
+			}
+
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			purged, err := store.EmptyTrash()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Purged %d items.\n", purged)
+			return nil
+		},
+	}
+	emptyCmd.Flags().BoolP("force", "f", false, "skip confirmation prompt")
+
+	cmd.AddCommand(listCmd, restoreCmd, emptyCmd)
+	return cmd
+}
+
+// runTrashPurge purges items that have been in the trash longer than the
+// retention period every hour, for as long as the server runs.
+func runTrashPurge(store *db.Store, retention time.Duration) {
+	for {
+		purged, err := store.PurgeTrash(time.Now().Add(-retention))
+		if purged > 0 {
+			fmt.Printf("Purged %d items from the trash\n", purged)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "trash: %v\n", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
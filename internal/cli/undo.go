@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func undoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo",
+		Short: "Undo your latest change to a ticket, project or subtask",
+		Long: "Undo your latest change to a ticket, project or subtask made from the CLI. Run it again\n" +
+			"to go further back. It refuses if someone has changed the same fields since.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			c, err := store.Undo()
+			if err != nil {
+				return err
+			}
+			if c == nil {
+				fmt.Println("Nothing to undo.")
+				return nil
+			}
+			fmt.Printf("Undid %s\n", c.Summary)
+			return nil
+		},
+	}
+}
+
+func redoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo",
+		Short: "Redo the change you undid last",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			c, err := store.Redo()
+			if err != nil {
+				return err
+			}
+			if c == nil {
+				fmt.Println("Nothing to redo.")
+				return nil
+			}
+			fmt.Printf("Redid %s\n", c.Summary)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tcarac/taskboard/internal/db"
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func viewCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Manage and run saved views (named ticket queries)",
+	}
+
+	var listProject string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved views",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			views, err := store.ListViews(listProject)
+			if err != nil {
+				return err
+			}
+			if len(views) == 0 {
+				fmt.Println("No views found.")
+				return nil
+			}
+			for _, v := range views {
+				fmt.Printf("%s: %s (%s)\n", v.Name, v.Query, v.ID)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&listProject, "project", "", "only global views and those of this project ID")
+
+	var showLimit int
+	var showCursor string
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Run a saved view and list its tickets",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			v, err := resolveView(store, args[0])
+			if err != nil {
+				return err
+			}
+			result, err := store.RunView(v, showCursor, showLimit)
+			if err != nil {
+				return err
+			}
+			if len(result.Tickets) == 0 {
+				fmt.Println("No tickets found.")
+				return nil
+			}
+			printTicket := func(t models.Ticket) {
+				fmt.Printf("[%s] %s - %s (%s, %s)\n", t.DisplayKey(), t.Title, t.Status, t.Priority, t.ID)
+			}
+			if len(result.Groups) == 0 {
+				for _, t := range result.Tickets {
+					printTicket(t)
+				}
+			} else {
+				byID := make(map[string]models.Ticket, len(result.Tickets))
+				for _, t := range result.Tickets {
+					byID[t.ID] = t
+				}
+				for i, g := range result.Groups {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("%s (%d)\n", g.Key, len(g.TicketIDs))
+					for _, id := range g.TicketIDs {
+						fmt.Print("  ")
+						printTicket(byID[id])
+					}
+				}
+			}
+			if result.NextCursor != "" {
+				fmt.Printf("\nShowing %d of %d. Next page: --cursor %s\n", len(result.Tickets), result.Total, result.NextCursor)
+			}
+			return nil
+		},
+	}
+	showCmd.Flags().IntVar(&showLimit, "limit", 0, "maximum tickets to show (default all)")
+	showCmd.Flags().StringVar(&showCursor, "cursor", "", "continue from the cursor printed by a previous page")
+
+	var req models.CreateViewRequest
+	var createProject string
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Save a ticket query as a named view",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			req.Name = args[0]
+			if createProject != "" {
+				req.ProjectID = &createProject
+			}
+			v, err := store.CreateView(req)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created view %s (%s)\n", v.Name, v.ID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.Query, "query", "", "ticket query, e.g. 'assignee:me status:!done'")
+	createCmd.Flags().StringVar(&createProject, "project", "", "limit the view to a project ID")
+	createCmd.Flags().StringVar(&req.Sort, "sort", "", "sort by position|created|updated|due|priority|number")
+	createCmd.Flags().StringVar(&req.Order, "order", "", "sort direction (asc|desc, default depends on --sort)")
+	createCmd.Flags().StringVar(&req.GroupBy, "group", "", "group by "+strings.Join(models.ViewGroupings(), "|"))
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Delete a saved view",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			v, err := resolveView(store, args[0])
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteView(v.ID); err != nil {
+				return err
+			}
+			fmt.Println("View deleted.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, showCmd, createCmd, deleteCmd)
+	return cmd
+}
+
+// resolveView accepts a view ID or name.
+func resolveView(store *db.Store, ref string) (*models.SavedView, error) {
+	v, err := store.GetView(ref)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v, err = store.GetViewByName(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("view %q not found", ref)
+	}
+	return v, nil
+}
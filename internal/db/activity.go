@@ -0,0 +1,158 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 500
+)
+
+type fieldChange struct {
+	field    string
+	oldValue string
+	newValue string
+}
+
+// ticketChanges lists the stored fields that differ between two versions of a
+// ticket. Position is left out on purpose: reordering within a column is not
+// interesting history.
+func ticketChanges(before, after *models.Ticket) []fieldChange {
+	var changes []fieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, fieldChange{field, oldValue, newValue})
+		}
+	}
+	add("title", before.Title, after.Title)
+	add("description", before.Description, after.Description)
+	add("status", before.Status, after.Status)
+	add("priority", before.Priority, after.Priority)
+	add("teamId", stringValue(before.TeamID), stringValue(after.TeamID))
+	add("assigneeId", stringValue(before.AssigneeID), stringValue(after.AssigneeID))
+	add("sprintId", stringValue(before.SprintID), stringValue(after.SprintID))
+	add("milestoneId", stringValue(before.MilestoneID), stringValue(after.MilestoneID))
+	add("dueDate", dateValue(before.DueDate), dateValue(after.DueDate))
+	add("estimate", floatValue(before.Estimate), floatValue(after.Estimate))
+	return changes
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func dateValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func floatValue(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// idsValue renders a set of IDs in a stable order for event old/new values.
+func idsValue(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func labelIDs(labels []models.Label) []string {
+	ids := make([]string, len(labels))
+	for i, l := range labels {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+// recordTicketEvent appends to the activity log as the store's actor. With no
+// field changes a single field-less event is written, which is what creates
+// and deletes use. Watchers are notified of the event as well.
+func (s *Store) recordTicketEvent(e querier, t *models.Ticket, action string, changes []fieldChange) error {
+	if len(changes) == 0 {
+		if action == models.EventUpdated || action == models.EventMoved {
+			return nil
+		}
+		changes = []fieldChange{{}}
+	}
+
+	now := time.Now()
+	for _, c := range changes {
+		_, err := e.Exec(
+			`INSERT INTO ticket_events (id, ticket_id, project_id, ticket_key, action, field, old_value, new_value, actor, source, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			newID(), t.ID, t.ProjectID, t.DisplayKey(), action, c.field, c.oldValue, c.newValue,
+			s.actor.Name, s.actor.Source, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return s.notify(e, t, action, changes)
+}
+
+const eventColumns = "id, ticket_id, project_id, ticket_key, action, field, old_value, new_value, actor, source, created_at"
+
+// ListActivity returns activity log entries, newest first.
+func (s *Store) ListActivity(filter models.ActivityFilter) ([]models.TicketEvent, error) {
+	query := "SELECT " + eventColumns + " FROM ticket_events WHERE 1=1"
+	args := []any{}
+
+	if filter.ProjectID != "" {
+		query += " AND project_id = ?"
+		args = append(args, filter.ProjectID)
+	}
+	if filter.TicketID != "" {
+		query += " AND ticket_id = ?"
+		args = append(args, filter.TicketID)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+	query += " ORDER BY created_at DESC, rowid DESC LIMIT ?"
+	args = append(args, limit)
+	return s.queryEvents(query, args...)
+}
+
+// GetTicketHistory returns every recorded change to a ticket, oldest first.
+func (s *Store) GetTicketHistory(ticketID string) ([]models.TicketEvent, error) {
+	return s.queryEvents("SELECT "+eventColumns+" FROM ticket_events WHERE ticket_id = ? ORDER BY created_at, rowid", ticketID)
+}
+
+func (s *Store) queryEvents(query string, args ...any) ([]models.TicketEvent, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TicketEvent
+	for rows.Next() {
+		var ev models.TicketEvent
+		if err := rows.Scan(&ev.ID, &ev.TicketID, &ev.ProjectID, &ev.TicketKey, &ev.Action, &ev.Field,
+			&ev.OldValue, &ev.NewValue, &ev.Actor, &ev.Source, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
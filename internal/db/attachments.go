@@ -0,0 +1,226 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// MaxAttachmentSize is the largest file that can be attached to a ticket.
+const MaxAttachmentSize = 25 << 20
+
+// ErrAttachmentTooLarge is returned when a file is over MaxAttachmentSize.
+var ErrAttachmentTooLarge = fmt.Errorf("attachment is larger than the %d MB limit", MaxAttachmentSize>>20)
+
+// ErrBinaryAttachment is returned when reading a file that is not text as
+// text.
+var ErrBinaryAttachment = errors.New("attachment is not a text file")
+
+const attachmentColumns = "id, ticket_id, filename, content_type, size, sha256, author, created_at"
+
+func scanAttachment(row interface{ Scan(...any) error }) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.TicketID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.Author, &a.CreatedAt)
+	return a, err
+}
+
+// ListAttachments returns the files attached to a ticket, oldest first.
+func (s *Store) ListAttachments(ticketID string) ([]models.Attachment, error) {
+	rows, err := s.db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE ticket_id = ? ORDER BY created_at, id", ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (s *Store) GetAttachment(id string) (*models.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AddAttachment stores the file read from content and attaches it to a
+// ticket. It returns nil if the ticket does not exist, and
+// ErrAttachmentTooLarge without storing anything for a file over
+// MaxAttachmentSize.
+func (s *Store) AddAttachment(ticketID string, req models.CreateAttachmentRequest, content io.Reader) (*models.Attachment, error) {
+	t, err := s.GetTicket(ticketID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	a := models.Attachment{
+		ID:          newID(),
+		TicketID:    t.ID,
+		Filename:    filepath.Base(strings.ReplaceAll(strings.TrimSpace(req.Filename), `\`, "/")),
+		ContentType: strings.TrimSpace(req.ContentType),
+		Author:      req.Author,
+		CreatedAt:   time.Now(),
+	}
+	v := &ValidationError{}
+	if a.Filename == "." || a.Filename == "/" {
+		v.add("filename", "is required")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if a.Author == "" {
+		a.Author = s.actor.Name
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading attachment: %w", err)
+	}
+	if len(data) > MaxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	sum := sha256.Sum256(data)
+	a.SHA256 = hex.EncodeToString(sum[:])
+	a.Size = int64(len(data))
+	if a.ContentType == "" || a.ContentType == "application/octet-stream" {
+		a.ContentType = detectContentType(a.Filename, data)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO attachment_blobs (sha256, size, data) VALUES (?, ?, ?)", a.SHA256, a.Size, data); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("storing attachment: %w", err)
+	}
+	_, err = tx.Exec("INSERT INTO attachments ("+attachmentColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		a.ID, a.TicketID, a.Filename, a.ContentType, a.Size, a.SHA256, a.Author, a.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("inserting attachment: %w", err)
+	}
+	if err := s.recordTicketEvent(tx, t, models.EventUpdated, []fieldChange{{field: "attachments", newValue: a.Filename}}); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("recording activity: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ReadAttachment returns an attachment and its content, or nil if it does
+// not exist.
+func (s *Store) ReadAttachment(id string) (*models.Attachment, []byte, error) {
+	a, err := s.GetAttachment(id)
+	if err != nil || a == nil {
+		return nil, nil, err
+	}
+	var data []byte
+	if err := s.db.QueryRow("SELECT data FROM attachment_blobs WHERE sha256 = ?", a.SHA256).Scan(&data); err != nil {
+		return nil, nil, fmt.Errorf("reading attachment %s: %w", a.Filename, err)
+	}
+	return a, data, nil
+}
+
+// ReadTextAttachment returns an attachment's content as text, or
+// ErrBinaryAttachment if it is not a text file.
+func (s *Store) ReadTextAttachment(id string) (*models.Attachment, string, error) {
+	a, data, err := s.ReadAttachment(id)
+	if err != nil || a == nil {
+		return nil, "", err
+	}
+	if !isText(a.ContentType, data) {
+		return nil, "", fmt.Errorf("%s (%s): %w", a.Filename, a.ContentType, ErrBinaryAttachment)
+	}
+	return a, string(data), nil
+}
+
+// DeleteAttachment removes an attachment, and its content when no other
+// attachment has the same file.
+func (s *Store) DeleteAttachment(id string) error {
+	a, err := s.GetAttachment(id)
+	if err != nil || a == nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := deleteUnusedBlobs(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if t, _, err := ticketRef(tx, a.TicketID); err == nil {
+		if err := s.recordTicketEvent(tx, t, models.EventUpdated, []fieldChange{{field: "attachments", oldValue: a.Filename}}); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording activity: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteUnusedBlobs removes file contents that no attachment refers to any
+// more.
+func deleteUnusedBlobs(e execer) error {
+	if _, err := e.Exec("DELETE FROM attachment_blobs WHERE sha256 NOT IN (SELECT sha256 FROM attachments)"); err != nil {
+		return fmt.Errorf("deleting unused attachment content: %w", err)
+	}
+	return nil
+}
+
+// detectContentType picks a MIME type from the filename's extension, falling
+// back to sniffing the content.
+func detectContentType(filename string, data []byte) string {
+	if ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
+
+// isText reports whether an attachment can be read as text: a text or
+// structured-text content type, or valid UTF-8 without NUL bytes.
+func isText(contentType string, data []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/json", mediaType == "application/xml",
+		mediaType == "application/yaml", mediaType == "application/x-yaml",
+		mediaType == "application/javascript", mediaType == "application/x-sh":
+		return utf8.Valid(data)
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"), mediaType == "application/pdf", mediaType == "application/zip":
+		return false
+	}
+	return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestAttachments(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login crashes"})
+	other := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Logout crashes"})
+	attach := func(ticketID, filename, content string) *models.Attachment {
+		t.Helper()
+		a, err := s.AddAttachment(ticketID, models.CreateAttachmentRequest{Filename: filename}, strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	blobs := func() int { return count(t, s, "SELECT COUNT(*) FROM attachment_blobs") }
+
+	// The same content is stored once however often it is attached.
+	log := attach(ticket.ID, `C:\logs\crash.log`, "panic: nil map\n")
+	copied := attach(other.ID, "crash.log", "panic: nil map\n")
+	if log.Filename != "crash.log" || !strings.HasPrefix(log.ContentType, "text/") || log.SHA256 != copied.SHA256 {
+		t.Errorf("got %s (%s, %s), want crash.log as text sharing its content", log.Filename, log.ContentType, log.SHA256)
+	}
+	if n := blobs(); n != 1 {
+		t.Errorf("%d stored contents, want 1", n)
+	}
+	if _, text, err := s.ReadTextAttachment(copied.ID); err != nil || text != "panic: nil map\n" {
+		t.Errorf("reading the copy: %q, %v", text, err)
+	}
+
+	image := attach(ticket.ID, "screen.png", "\x89PNG\r\n\x1a\n\x00")
+	if _, _, err := s.ReadTextAttachment(image.ID); !errors.Is(err, ErrBinaryAttachment) {
+		t.Errorf("reading an image as text: got %v, want ErrBinaryAttachment", err)
+	}
+
+	// Content goes once nothing refers to it.
+	for _, a := range []*models.Attachment{log, image} {
+		if err := s.DeleteAttachment(a.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := blobs(); n != 1 {
+		t.Errorf("%d stored contents after deleting the first log, want the copy's", n)
+	}
+	if err := s.DeleteAttachment(copied.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := blobs(); n != 0 {
+		t.Errorf("%d stored contents after deleting every attachment, want 0", n)
+	}
+
+	big := bytes.NewReader(make([]byte, MaxAttachmentSize+1))
+	if _, err := s.AddAttachment(ticket.ID, models.CreateAttachmentRequest{Filename: "core.dump"}, big); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("oversized file: got %v, want ErrAttachmentTooLarge", err)
+	}
+	if n := blobs(); n != 0 {
+		t.Errorf("an oversized file left %d stored contents", n)
+	}
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ticketIDsParam binds a set of ticket IDs as a single JSON array, so that
+// loading details for thousands of tickets stays within SQLite's bound
+// parameter limit. Queries use it as IN (SELECT value FROM json_each(?)).
+func ticketIDsParam(tickets []models.Ticket) string {
+	ids := make([]string, len(tickets))
+	for i, t := range tickets {
+		ids[i] = t.ID
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// loadTicketDetails fills in the labels, subtasks, blocker links, logged
+// time and custom field values of every ticket with one query per relation,
+// however many tickets there are.
+func (s *Store) loadTicketDetails(tickets []models.Ticket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	index := make(map[string]*models.Ticket, len(tickets))
+	for i := range tickets {
+		index[tickets[i].ID] = &tickets[i]
+	}
+	ids := ticketIDsParam(tickets)
+
+	err := s.eachRow(
+		`SELECT tl.ticket_id, l.id, l.name, l.color FROM ticket_labels tl JOIN labels l ON l.id = tl.label_id
+		WHERE tl.ticket_id IN (SELECT value FROM json_each(?)) AND l.deleted_at IS NULL ORDER BY l.name`, ids,
+		func(rows *sql.Rows) error {
+			var ticketID string
+			var l models.Label
+			if err := rows.Scan(&ticketID, &l.ID, &l.Name, &l.Color); err != nil {
+				return err
+			}
+			t := index[ticketID]
+			t.Labels = append(t.Labels, l)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.eachRow(
+		`SELECT id, ticket_id, title, completed, position FROM subtasks
+		WHERE ticket_id IN (SELECT value FROM json_each(?)) ORDER BY position`, ids,
+		func(rows *sql.Rows) error {
+			var st models.Subtask
+			if err := rows.Scan(&st.ID, &st.TicketID, &st.Title, &st.Completed, &st.Position); err != nil {
+				return err
+			}
+			t := index[st.TicketID]
+			t.Subtasks = append(t.Subtasks, st)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.eachRow(
+		`SELECT r.ticket_id, r.related_id, `+openBlockerCondition+`
+		FROM ticket_relations r JOIN tickets b ON b.id = r.related_id
+		WHERE r.type = 'blocked_by' AND r.ticket_id IN (SELECT value FROM json_each(?)) AND b.deleted_at IS NULL`, ids,
+		func(rows *sql.Rows) error {
+			var ticketID, blockerID string
+			var open bool
+			if err := rows.Scan(&ticketID, &blockerID, &open); err != nil {
+				return err
+			}
+			t := index[ticketID]
+			t.BlockedBy = append(t.BlockedBy, blockerID)
+			t.IsBlocked = t.IsBlocked || open
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.eachRow(
+		`SELECT ticket_id, SUM(minutes) FROM time_entries
+		WHERE ticket_id IN (SELECT value FROM json_each(?)) GROUP BY ticket_id`, ids,
+		func(rows *sql.Rows) error {
+			var ticketID string
+			var minutes int
+			if err := rows.Scan(&ticketID, &minutes); err != nil {
+				return err
+			}
+			index[ticketID].LoggedMinutes = minutes
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.eachRow(
+		`SELECT fv.ticket_id, f.key, f.type, fv.value FROM ticket_field_values fv JOIN custom_fields f ON f.id = fv.field_id
+		WHERE fv.ticket_id IN (SELECT value FROM json_each(?))`, ids,
+		func(rows *sql.Rows) error {
+			var ticketID, key, fieldType, data string
+			if err := rows.Scan(&ticketID, &key, &fieldType, &data); err != nil {
+				return err
+			}
+			value, err := decodeFieldValue(fieldType, data)
+			if err != nil {
+				return fmt.Errorf("decoding field %s of ticket %s: %w", key, ticketID, err)
+			}
+			t := index[ticketID]
+			if t.Fields == nil {
+				t.Fields = map[string]any{}
+			}
+			t.Fields[key] = value
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return s.eachRow(
+		`SELECT r.related_id, r.ticket_id FROM ticket_relations r JOIN tickets b ON b.id = r.ticket_id
+		WHERE r.type = 'blocked_by' AND r.related_id IN (SELECT value FROM json_each(?)) AND b.deleted_at IS NULL`, ids,
+		func(rows *sql.Rows) error {
+			var ticketID, blockedID string
+			if err := rows.Scan(&ticketID, &blockedID); err != nil {
+				return err
+			}
+			t := index[ticketID]
+			t.Blocks = append(t.Blocks, blockedID)
+			return nil
+		})
+}
+
+// eachRow runs query and calls fn for every row.
+func (s *Store) eachRow(query string, arg any, fn func(*sql.Rows) error) error {
+	rows, err := s.db.Query(query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
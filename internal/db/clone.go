@@ -0,0 +1,251 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// CloneProject copies a project's settings, workflow, WIP limits, milestones,
+// ticket templates and custom fields into a new project named req.Name with prefix
+// req.Prefix. With req.Tickets the tickets come along too, renumbered from 1
+// in their original order, with their labels, subtasks, milestones, custom
+// field values and the links between them remapped to the copies. Links to tickets of other projects,
+// sprints, comments, attachments, logged time, history and recurrences are not copied.
+func (s *Store) CloneProject(id string, req models.CloneProjectRequest) (*models.Project, error) {
+	src, err := s.GetProject(id)
+	if err != nil || src == nil {
+		return nil, err
+	}
+
+	p := *src
+	p.ID = newID()
+	p.Name = strings.TrimSpace(req.Name)
+	p.Prefix = strings.TrimSpace(req.Prefix)
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	v := &ValidationError{}
+	if p.Name == "" {
+		v.add("name", "is required")
+	}
+	if p.Prefix == "" {
+		v.add("prefix", "is required")
+	} else {
+		var other string
+		err := s.db.QueryRow("SELECT name FROM projects WHERE prefix = ?", p.Prefix).Scan(&other)
+		if err == nil {
+			v.add("prefix", "%s is already used by project %s", p.Prefix, other)
+		} else if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.projectStatuses(id)
+	if err != nil {
+		return nil, err
+	}
+	milestones, err := s.ListMilestones(id)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.ListTemplates(id)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := s.ListCustomFields(id)
+	if err != nil {
+		return nil, err
+	}
+	var tickets []models.Ticket
+	var relations []storedRelation
+	if req.Tickets {
+		tickets, err = s.ListTickets(models.TicketFilter{ProjectID: id, Sort: models.SortNumber, Order: "asc"})
+		if err != nil {
+			return nil, err
+		}
+		if relations, err = s.projectRelations(id); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	if err := s.cloneProject(tx, &p, statuses, milestones, templates, fields, tickets, relations); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO wip_limits (project_id, status, team_id, max_tickets, policy)
+		SELECT ?, status, team_id, max_tickets, policy FROM wip_limits WHERE project_id = ?`, p.ID, id); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("copying WIP limits: %w", err)
+	}
+	summary := fmt.Sprintf("create project %s from %s", p.Name, src.Name)
+	if err := s.recordChange(tx, models.ChangeProject, p.ID, summary, nil, newProjectState(&p)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetProject(p.ID)
+}
+
+func (s *Store) cloneProject(tx *sql.Tx, p *models.Project, statuses []models.WorkflowStatus, milestones []models.Milestone,
+	templates []models.TicketTemplate, fields []models.CustomField, tickets []models.Ticket, relations []storedRelation) error {
+	if err := insertProject(tx, p); err != nil {
+		return fmt.Errorf("inserting project: %w", err)
+	}
+	if err := insertWorkflowStatuses(tx, p.ID, statuses); err != nil {
+		return err
+	}
+
+	milestoneIDs := make(map[string]string, len(milestones))
+	for _, m := range milestones {
+		milestoneIDs[m.ID] = newID()
+		_, err := tx.Exec(`INSERT INTO milestones (id, project_id, name, description, target_date, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			milestoneIDs[m.ID], p.ID, m.Name, m.Description, m.TargetDate, p.CreatedAt, p.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("copying milestone %s: %w", m.Name, err)
+		}
+	}
+
+	for _, t := range templates {
+		labels := labelIDs(t.Labels)
+		t.ID = newID()
+		t.ProjectID = p.ID
+		t.CreatedAt, t.UpdatedAt = p.CreatedAt, p.CreatedAt
+		if err := insertTemplate(tx, &t); err != nil {
+			return err
+		}
+		if err := addTemplateLabels(tx, t.ID, labels); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		options, _ := json.Marshal(f.Options)
+		_, err := tx.Exec("INSERT INTO custom_fields ("+customFieldColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			newID(), p.ID, f.Key, f.Name, f.Type, string(options), p.CreatedAt, p.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("copying field %s: %w", f.Key, err)
+		}
+	}
+
+	ticketIDs := make(map[string]string, len(tickets))
+	for i, t := range tickets {
+		ticketIDs[t.ID] = newID()
+		t.ID = ticketIDs[t.ID]
+		t.ProjectID = p.ID
+		t.ProjectPrefix = p.Prefix
+		t.Number = i + 1
+		t.SprintID = nil
+		if t.MilestoneID != nil {
+			milestoneID := milestoneIDs[*t.MilestoneID]
+			t.MilestoneID = &milestoneID
+		}
+		t.CreatedAt, t.UpdatedAt = p.CreatedAt, p.CreatedAt
+		if err := insertTicket(tx, &t); err != nil {
+			return fmt.Errorf("copying ticket %d: %w", t.Number, err)
+		}
+		if err := addTicketLabels(tx, t.ID, labelIDs(t.Labels)); err != nil {
+			return err
+		}
+		if err := insertSubtasks(tx, t.ID, t.Subtasks); err != nil {
+			return err
+		}
+		if err := writeFieldValues(tx, t.ID, p.ID, t.Fields); err != nil {
+			return err
+		}
+		if err := s.recordTicketEvent(tx, &t, models.EventCreated, nil); err != nil {
+			return fmt.Errorf("recording activity: %w", err)
+		}
+	}
+
+	for _, r := range relations {
+		if _, err := tx.Exec("INSERT INTO ticket_relations (ticket_id, related_id, type) VALUES (?, ?, ?)",
+			ticketIDs[r.ticketID], ticketIDs[r.relatedID], r.relType); err != nil {
+			return fmt.Errorf("copying link: %w", err)
+		}
+	}
+	return nil
+}
+
+// storedRelation is a ticket_relations row, stored from its forward side.
+type storedRelation struct {
+	ticketID, relatedID, relType string
+}
+
+// projectRelations returns the stored links between tickets of projectID.
+func (s *Store) projectRelations(projectID string) ([]storedRelation, error) {
+	rows, err := s.db.Query(`SELECT r.ticket_id, r.related_id, r.type FROM ticket_relations r
+		JOIN tickets a ON a.id = r.ticket_id JOIN tickets b ON b.id = r.related_id
+		WHERE a.project_id = ? AND b.project_id = ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL`, projectID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []storedRelation
+	for rows.Next() {
+		var r storedRelation
+		if err := rows.Scan(&r.ticketID, &r.relatedID, &r.relType); err != nil {
+			return nil, err
+		}
+		relations = append(relations, r)
+	}
+	return relations, rows.Err()
+}
+
+// CloneTicket copies a ticket as new work: the copy starts in the first
+// status of its project's workflow with every subtask unchecked. It keeps
+// the description, priority, team, assignee, due date, estimate, labels and
+// blockers, and the milestone and custom field values unless it moves to
+// another project. Comments, attachments,
+// logged time, history, other links and the sprint are not copied.
+func (s *Store) CloneTicket(id string, req models.CloneTicketRequest) (*models.Ticket, error) {
+	src, err := s.GetTicket(id)
+	if err != nil || src == nil {
+		return nil, err
+	}
+
+	create := models.CreateTicketRequest{
+		ProjectID:   src.ProjectID,
+		TeamID:      src.TeamID,
+		AssigneeID:  src.AssigneeID,
+		MilestoneID: src.MilestoneID,
+		Title:       src.Title,
+		Description: src.Description,
+		Priority:    src.Priority,
+		Estimate:    src.Estimate,
+		Labels:      labelIDs(src.Labels),
+		BlockedBy:   src.BlockedBy,
+		Fields:      src.Fields,
+	}
+	if req.ProjectID != "" && req.ProjectID != src.ProjectID {
+		create.ProjectID = req.ProjectID
+		create.MilestoneID = nil
+		create.Fields = nil
+	}
+	if strings.TrimSpace(req.Title) != "" {
+		create.Title = req.Title
+	}
+	if src.DueDate != nil {
+		due := src.DueDate.Format("2006-01-02")
+		create.DueDate = &due
+	}
+	subtasks := make([]models.Subtask, len(src.Subtasks))
+	for i, st := range src.Subtasks {
+		subtasks[i] = models.Subtask{Title: st.Title}
+	}
+	return s.createTicket(create, subtasks)
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestCloneProject(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	m, err := s.CreateMilestone(models.CreateMilestoneRequest{ProjectID: p.ID, Name: "v1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateTemplate(models.CreateTemplateRequest{ProjectID: p.ID, Name: "Bug", Title: "Bug: {title}"}); err != nil {
+		t.Fatal(err)
+	}
+	other := mustProject(t, s, models.CreateProjectRequest{Name: "Web", Prefix: "WEB"})
+	outside := mustTicket(t, s, models.CreateTicketRequest{ProjectID: other.ID, Title: "Outside"})
+
+	// AUTH-2 was deleted, so AUTH-3 is copied as CORE-2.
+	first := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "First", MilestoneID: &m.ID})
+	if err := s.DeleteTicket(mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Deleted"}).ID); err != nil {
+		t.Fatal(err)
+	}
+	mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Third", BlockedBy: []string{first.ID, outside.ID}})
+
+	clone, err := s.CloneProject(p.ID, models.CloneProjectRequest{Name: "Core", Prefix: "CORE", Tickets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := s.ListTickets(models.TicketFilter{ProjectID: clone.ID, Sort: models.SortNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 || tickets[0].DisplayKey() != "CORE-1" || tickets[1].DisplayKey() != "CORE-2" || tickets[1].Title != "Third" {
+		t.Fatalf("clone holds %v, want CORE-1 and CORE-2 (Third)", displayKeys(tickets))
+	}
+	if got := tickets[1].BlockedBy; len(got) != 1 || got[0] != tickets[0].ID {
+		t.Errorf("CORE-2 is blocked by %v, want only CORE-1", got)
+	}
+	if tickets[0].MilestoneID == nil || *tickets[0].MilestoneID == m.ID {
+		t.Errorf("CORE-1 milestone = %v, want the cloned milestone", tickets[0].MilestoneID)
+	}
+	milestones, err := s.ListMilestones(clone.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(milestones) != 1 || milestones[0].ID != *tickets[0].MilestoneID || milestones[0].TicketCount != 1 {
+		t.Errorf("clone milestones = %+v, want v1.0 holding CORE-1", milestones)
+	}
+	templates, err := s.ListTemplates(clone.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || templates[0].Name != "Bug" {
+		t.Errorf("clone templates = %+v, want Bug", templates)
+	}
+
+	// The source is untouched, and a taken prefix is refused.
+	if got := mustGetTicket(t, s, first.ID).ProjectID; got != p.ID {
+		t.Errorf("AUTH-1 moved to project %s", got)
+	}
+	_, err = s.CloneProject(p.ID, models.CloneProjectRequest{Name: "Again", Prefix: "WEB"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Fields[0].Field != "prefix" {
+		t.Errorf("taken prefix: got %v, want a validation error on prefix", err)
+	}
+}
+
+func TestCloneTicket(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	other := mustProject(t, s, models.CreateProjectRequest{Name: "Web", Prefix: "WEB"})
+	m, err := s.CreateMilestone(models.CreateMilestoneRequest{ProjectID: p.ID, Name: "v1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Rotate keys", Priority: "high", MilestoneID: &m.ID})
+	st, err := s.AddSubtask(src.ID, models.CreateSubtaskRequest{Title: "Staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ToggleSubtask(st.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MoveTicket(src.ID, models.MoveTicketRequest{Status: "in_progress"}); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := s.CloneTicket(src.ID, models.CloneTicketRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied = mustGetTicket(t, s, copied.ID)
+	if copied.Status != "todo" || copied.Priority != "high" || copied.MilestoneID == nil || *copied.MilestoneID != m.ID {
+		t.Errorf("copy: status %s, priority %s, milestone %v; want todo, high and v1.0", copied.Status, copied.Priority, copied.MilestoneID)
+	}
+	if len(copied.Subtasks) != 1 || copied.Subtasks[0].Completed {
+		t.Errorf("copy subtasks = %+v, want one unchecked", copied.Subtasks)
+	}
+
+	moved, err := s.CloneTicket(src.ID, models.CloneTicketRequest{ProjectID: other.ID, Title: "Rotate web keys"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.DisplayKey() != "WEB-1" || moved.Title != "Rotate web keys" || moved.MilestoneID != nil {
+		t.Errorf("copy to WEB: %s %q milestone %v, want WEB-1 with the new title and no milestone",
+			moved.DisplayKey(), moved.Title, moved.MilestoneID)
+	}
+}
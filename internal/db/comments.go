@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func (s *Store) ListComments(ticketID string) ([]models.Comment, error) {
+	rows, err := s.db.Query(
+		"SELECT id, ticket_id, author, body, created_at, edited_at FROM comments WHERE ticket_id = ? ORDER BY created_at, id",
+		ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var c models.Comment
+		if err := rows.Scan(&c.ID, &c.TicketID, &c.Author, &c.Body, &c.CreatedAt, &c.EditedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (s *Store) GetComment(id string) (*models.Comment, error) {
+	var c models.Comment
+	err := s.db.QueryRow("SELECT id, ticket_id, author, body, created_at, edited_at FROM comments WHERE id = ?", id).
+		Scan(&c.ID, &c.TicketID, &c.Author, &c.Body, &c.CreatedAt, &c.EditedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &c, err
+}
+
+// AddComment appends a comment to a ticket, makes its author (by default the
+// store's actor) a watcher and notifies the other watchers. It returns nil if
+// the ticket does not exist.
+func (s *Store) AddComment(ticketID string, req models.CreateCommentRequest) (*models.Comment, error) {
+	t, err := s.GetTicket(ticketID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	c := models.Comment{
+		ID:        newID(),
+		TicketID:  ticketID,
+		Author:    req.Author,
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+	}
+	if c.Author == "" {
+		c.Author = s.actor.Name
+	}
+	if c.Author == "" {
+		c.Author = "anonymous"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	_, err = tx.Exec("INSERT INTO comments (id, ticket_id, author, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		c.ID, c.TicketID, c.Author, c.Body, c.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := notifyComment(tx, t, &c); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *Store) UpdateComment(id string, req models.UpdateCommentRequest) (*models.Comment, error) {
+	c, err := s.GetComment(id)
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	if req.Body != nil && *req.Body != c.Body {
+		now := time.Now()
+		c.Body = *req.Body
+		c.EditedAt = &now
+	}
+
+	_, err = s.db.Exec("UPDATE comments SET body=?, edited_at=? WHERE id=?", c.Body, c.EditedAt, c.ID)
+	return c, err
+}
+
+func (s *Store) DeleteComment(id string) error {
+	_, err := s.db.Exec("DELETE FROM comments WHERE id = ?", id)
+	return err
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+var (
+	ErrDependencyCycle = errors.New("dependency cycle")
+	ErrTicketBlocked   = errors.New("ticket is blocked")
+	ErrInvalidProject  = errors.New("invalid project")
+)
+
+// openBlockerCondition matches a blocker ticket b whose status is not in a
+// done category of its own project's workflow.
+const openBlockerCondition = `NOT EXISTS (
+		SELECT 1 FROM workflow_statuses w
+		WHERE w.project_id = b.project_id AND w.status = b.status AND w.category = 'done')`
+
+func validateBlockedMovePolicy(policy string) error {
+	switch policy {
+	case models.BlockedMoveAllow, models.BlockedMoveWarn, models.BlockedMoveBlock:
+		return nil
+	}
+	return fmt.Errorf("%w: blockedMovePolicy must be allow, warn or block, got %q", ErrInvalidProject, policy)
+}
+
+func validateEstimateUnit(unit string) error {
+	switch unit {
+	case models.EstimatePoints, models.EstimateHours:
+		return nil
+	}
+	return fmt.Errorf("%w: estimateUnit must be points or hours, got %q", ErrInvalidProject, unit)
+}
+
+// checkBlockers verifies that every blocker exists and that making ticketID
+// wait on it would not close a loop in the blocked-by graph.
+func (s *Store) checkBlockers(ticketID string, blockerIDs []string) error {
+	for _, blockerID := range blockerIDs {
+		if blockerID == ticketID {
+			return fmt.Errorf("%w: a ticket cannot block itself", ErrDependencyCycle)
+		}
+		var exists int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM tickets WHERE id = ? AND deleted_at IS NULL", blockerID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return fmt.Errorf("%w: blocker %s not found", ErrInvalidRelation, blockerID)
+		}
+		cycle, err := s.blockerChain(blockerID, ticketID)
+		if err != nil {
+			return err
+		}
+		if cycle != nil {
+			return fmt.Errorf("%w: %s already depends on %s (%s)",
+				ErrDependencyCycle, s.ticketKey(blockerID), s.ticketKey(ticketID), strings.Join(cycle, " → "))
+		}
+	}
+	return nil
+}
+
+// blockerChain walks blocked-by links from start and returns the ticket keys
+// on the path to target, or nil if target is not reachable.
+func (s *Store) blockerChain(start, target string) ([]string, error) {
+	rows, err := s.db.Query(
+		`WITH RECURSIVE chain(id, path) AS (
+			SELECT related_id, ? || ',' || related_id FROM ticket_relations WHERE ticket_id = ? AND type = 'blocked_by'
+			UNION
+			SELECT r.related_id, c.path || ',' || r.related_id FROM ticket_relations r JOIN chain c ON r.ticket_id = c.id
+			WHERE r.type = 'blocked_by' AND instr(c.path, r.related_id) = 0
+		)
+		SELECT path FROM chain WHERE id = ? LIMIT 1`,
+		start, start, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var path string
+	if err := rows.Scan(&path); err != nil {
+		return nil, err
+	}
+	ids := strings.Split(path, ",")
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.ticketKey(id)
+	}
+	return keys, nil
+}
+
+// getOpenBlockers returns the IDs of a ticket's blockers that are not done.
+func (s *Store) getOpenBlockers(ticketID string) ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT b.id FROM ticket_relations r JOIN tickets b ON b.id = r.related_id
+		WHERE r.ticket_id = ? AND r.type = 'blocked_by' AND b.deleted_at IS NULL AND `+openBlockerCondition,
+		ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// checkBlockedMove applies the project's blocked-move policy to a ticket
+// moving into status. Moving into a backlog status is always allowed. It
+// returns a warning under the warn policy and ErrTicketBlocked under block.
+func (s *Store) checkBlockedMove(t *models.Ticket, status string) (string, error) {
+	statuses, err := s.projectStatuses(t.ProjectID)
+	if err != nil {
+		return "", err
+	}
+	for _, st := range statuses {
+		if st.Status == status && st.Category == models.StatusCategoryBacklog {
+			return "", nil
+		}
+	}
+
+	var policy string
+	if err := s.db.QueryRow("SELECT COALESCE(blocked_move_policy, 'allow') FROM projects WHERE id = ?", t.ProjectID).Scan(&policy); err != nil {
+		return "", err
+	}
+	if policy == models.BlockedMoveAllow {
+		return "", nil
+	}
+
+	open, err := s.getOpenBlockers(t.ID)
+	if err != nil || len(open) == 0 {
+		return "", err
+	}
+	keys := make([]string, len(open))
+	for i, id := range open {
+		keys[i] = s.ticketKey(id)
+	}
+	msg := fmt.Sprintf("%s is blocked by open tickets: %s", t.DisplayKey(), strings.Join(keys, ", "))
+	if policy == models.BlockedMoveBlock {
+		return "", fmt.Errorf("%w: %s", ErrTicketBlocked, msg)
+	}
+	return msg, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestDependencyCycles(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	tickets := make([]*models.Ticket, 5)
+	for i := range tickets {
+		tickets[i] = mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: fmt.Sprintf("Ticket %d", i+1)})
+	}
+	a, b, c, d, e := tickets[0], tickets[1], tickets[2], tickets[3], tickets[4]
+	link := func(from *models.Ticket, relType string, to *models.Ticket) error {
+		_, err := s.AddRelation(from.ID, models.CreateRelationRequest{Type: relType, TicketID: to.ID})
+		return err
+	}
+	setBlockers := func(t *models.Ticket, blockers ...*models.Ticket) error {
+		ids := make([]string, len(blockers))
+		for i, b := range blockers {
+			ids[i] = b.ID
+		}
+		_, err := s.UpdateTicket(t.ID, models.UpdateTicketRequest{BlockedBy: ids})
+		return err
+	}
+
+	// AUTH-3 waits on AUTH-2, which waits on AUTH-1. AUTH-4 waits on both
+	// AUTH-2 and AUTH-3, which is not a loop.
+	for _, l := range []struct {
+		from    *models.Ticket
+		relType string
+		to      *models.Ticket
+	}{
+		{b, models.RelationBlockedBy, a},
+		{c, models.RelationBlockedBy, b},
+		{d, models.RelationBlockedBy, b},
+		{c, models.RelationBlocks, d},
+	} {
+		if err := link(l.from, l.relType, l.to); err != nil {
+			t.Fatalf("%s %s %s: %v", l.from.DisplayKey(), l.relType, l.to.DisplayKey(), err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"two tickets", link(a, models.RelationBlockedBy, b), "AUTH-2 already depends on AUTH-1 (AUTH-2 → AUTH-1)"},
+		{"chain", link(a, models.RelationBlockedBy, c), "AUTH-3 already depends on AUTH-1 (AUTH-3 → AUTH-2 → AUTH-1)"},
+		{"inverse type", link(d, models.RelationBlocks, a), "AUTH-4 already depends on AUTH-1"},
+		{"update", setBlockers(a, e, d), "AUTH-4 already depends on AUTH-1"},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrDependencyCycle) || !strings.Contains(tt.err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want a dependency cycle error containing %q", tt.name, tt.err, tt.want)
+		}
+	}
+
+	if err := setBlockers(a, a); err == nil || !strings.Contains(err.Error(), "cannot block itself") {
+		t.Errorf("self: got %v, want an error saying a ticket cannot block itself", err)
+	}
+
+	// Rejected links leave nothing behind.
+	if got := mustGetTicket(t, s, a.ID).BlockedBy; len(got) != 0 {
+		t.Errorf("AUTH-1 is blocked by %v after rejected links, want nothing", got)
+	}
+	got, want := mustGetTicket(t, s, d.ID).BlockedBy, []string{b.ID, c.ID}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("AUTH-4 is blocked by %v, want %v", got, want)
+	}
+}
+
+func TestBlockedMovePolicy(t *testing.T) {
+	s := newTestStore(t)
+	for _, policy := range []string{models.BlockedMoveAllow, models.BlockedMoveWarn, models.BlockedMoveBlock} {
+		p := mustProject(t, s, models.CreateProjectRequest{Name: policy, Prefix: strings.ToUpper(policy), BlockedMovePolicy: policy})
+		blocker := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocker"})
+		blocked := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocked", BlockedBy: []string{blocker.ID}})
+
+		moved, err := s.MoveTicket(blocked.ID, models.MoveTicketRequest{Status: "in_progress"})
+		switch policy {
+		case models.BlockedMoveAllow:
+			if err != nil || len(moved.Warnings) != 0 {
+				t.Errorf("allow: got %v, %v, want the move without warnings", moved, err)
+			}
+		case models.BlockedMoveWarn:
+			if err != nil || len(moved.Warnings) != 1 || !strings.Contains(moved.Warnings[0], blocker.DisplayKey()) {
+				t.Errorf("warn: got %v, %v, want the move with a warning naming %s", moved, err, blocker.DisplayKey())
+			}
+		case models.BlockedMoveBlock:
+			if !errors.Is(err, ErrTicketBlocked) {
+				t.Errorf("block: got %v, want ErrTicketBlocked", err)
+			}
+			if got := mustGetTicket(t, s, blocked.ID).Status; got != "todo" {
+				t.Errorf("block: ticket moved to %s", got)
+			}
+		}
+
+		// Once the blocker is done nothing stands in the way.
+		if _, err := s.MoveTicket(blocker.ID, models.MoveTicketRequest{Status: "done"}); err != nil {
+			t.Fatal(err)
+		}
+		moved, err = s.MoveTicket(blocked.ID, models.MoveTicketRequest{Status: "done"})
+		if err != nil || len(moved.Warnings) != 0 {
+			t.Errorf("%s after the blocker is done: got %v, %v, want the move without warnings", policy, moved, err)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// fieldKeyPattern is what a custom field key looks like, so that it can be
+// written as a ticket query field.
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+const customFieldColumns = "id, project_id, key, name, type, options, created_at, updated_at"
+
+func scanCustomField(row interface{ Scan(...any) error }) (*models.CustomField, error) {
+	var f models.CustomField
+	var options string
+	err := row.Scan(&f.ID, &f.ProjectID, &f.Key, &f.Name, &f.Type, &options, &f.CreatedAt, &f.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(options), &f.Options); err != nil {
+		return nil, fmt.Errorf("decoding field %s options: %w", f.ID, err)
+	}
+	return &f, nil
+}
+
+// ListCustomFields returns custom fields in the order they were added: all
+// of them, or only those of projectID when it is set.
+func (s *Store) ListCustomFields(projectID string) ([]models.CustomField, error) {
+	if projectID == "" {
+		return s.customFields("")
+	}
+	return s.customFields(" AND project_id = ?", projectID)
+}
+
+// customFields returns the custom fields of live projects matching where.
+func (s *Store) customFields(where string, args ...any) ([]models.CustomField, error) {
+	rows, err := s.db.Query("SELECT "+customFieldColumns+" FROM custom_fields WHERE "+inLiveProject("project_id")+where+
+		" ORDER BY rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []models.CustomField
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, *f)
+	}
+	return fields, rows.Err()
+}
+
+func (s *Store) GetCustomField(id string) (*models.CustomField, error) {
+	f, err := scanCustomField(s.db.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return f, err
+}
+
+func (s *Store) CreateCustomField(req models.CreateCustomFieldRequest) (*models.CustomField, error) {
+	v := &ValidationError{}
+	f := models.CustomField{
+		ID:        newID(),
+		ProjectID: req.ProjectID,
+		Key:       strings.TrimSpace(req.Key),
+		Name:      strings.TrimSpace(req.Name),
+		Type:      req.Type,
+		Options:   trimOptions(req.Options),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if f.Name == "" {
+		f.Name = f.Key
+	}
+	if f.ProjectID == "" {
+		v.add("projectId", "is required")
+	} else if err := s.checkExists(v, "projectId", "projects", "project", f.ProjectID); err != nil {
+		return nil, err
+	}
+	if f.Key == "" {
+		v.add("key", "is required")
+	} else if !fieldKeyPattern.MatchString(f.Key) {
+		v.add("key", "%q must start with a lowercase letter and contain only lowercase letters, digits and underscores", f.Key)
+	} else if slices.Contains(ticketQueryFields, f.Key) {
+		v.add("key", "%q is a built-in ticket query field", f.Key)
+	} else {
+		var other string
+		err := s.db.QueryRow("SELECT id FROM custom_fields WHERE project_id = ? AND key = ?", f.ProjectID, f.Key).Scan(&other)
+		if err == nil {
+			v.add("key", "the project already has a field %q", f.Key)
+		} else if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+	if !slices.Contains(models.CustomFieldTypes(), f.Type) {
+		v.add("type", "must be one of %s, got %q", strings.Join(models.CustomFieldTypes(), ", "), f.Type)
+	}
+	if err := validateCustomField(v, &f); err != nil {
+		return nil, err
+	}
+
+	options, _ := json.Marshal(f.Options)
+	_, err := s.db.Exec("INSERT INTO custom_fields ("+customFieldColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		f.ID, f.ProjectID, f.Key, f.Name, f.Type, string(options), f.CreatedAt, f.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting field: %w", err)
+	}
+	return s.GetCustomField(f.ID)
+}
+
+// UpdateCustomField renames a custom field or replaces its options. The key
+// and type of a field cannot change, and an option cannot be removed while a
+// ticket has it set.
+func (s *Store) UpdateCustomField(id string, req models.UpdateCustomFieldRequest) (*models.CustomField, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	f, err := s.inTx(tx).GetCustomField(id)
+	if err != nil || f == nil {
+		tx.Rollback()
+		return nil, err
+	}
+	removed := f.Options
+	if req.Name != nil {
+		f.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Options != nil {
+		f.Options = trimOptions(req.Options)
+	}
+	v := &ValidationError{}
+	for _, option := range removed {
+		if slices.Contains(f.Options, option) {
+			continue
+		}
+		// Tickets in the trash count too, as restoring them brings the
+		// value back.
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM ticket_field_values fv
+			WHERE fv.field_id = ? AND EXISTS (SELECT 1 FROM json_each(fv.value) WHERE value = ?)`, id, option).Scan(&n); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		switch {
+		case n == 1:
+			v.add("options", "%q cannot be removed while a ticket uses it", option)
+		case n > 1:
+			v.add("options", "%q cannot be removed while %d tickets use it", option, n)
+		}
+	}
+	if err := validateCustomField(v, f); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	options, _ := json.Marshal(f.Options)
+	_, err = tx.Exec("UPDATE custom_fields SET name=?, options=?, updated_at=? WHERE id=?",
+		f.Name, string(options), time.Now(), id)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("updating field: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetCustomField(id)
+}
+
+// DeleteCustomField removes a custom field along with every ticket's value
+// for it.
+func (s *Store) DeleteCustomField(id string) error {
+	_, err := s.db.Exec("DELETE FROM custom_fields WHERE id = ?", id)
+	return err
+}
+
+// validateCustomField adds the name and option checks of f to v and returns
+// the collected errors. Select fields need at least one option and other
+// fields take none.
+func validateCustomField(v *ValidationError, f *models.CustomField) error {
+	if f.Name == "" {
+		v.add("name", "must not be empty")
+	}
+	selectable := f.Type == models.FieldSelect || f.Type == models.FieldMultiSelect
+	switch {
+	case selectable && len(f.Options) == 0:
+		v.add("options", "are required for %s fields", f.Type)
+	case !selectable && len(f.Options) > 0:
+		v.add("options", "only apply to select and multi_select fields")
+	}
+	for i, option := range f.Options {
+		if option == "" {
+			v.add("options", "must not be empty")
+		} else if slices.IndexFunc(f.Options[:i], func(o string) bool { return strings.EqualFold(o, option) }) >= 0 {
+			v.add("options", "%q is listed twice", option)
+		}
+	}
+	return v.err()
+}
+
+func trimOptions(options []string) []string {
+	if options == nil {
+		return nil
+	}
+	trimmed := make([]string, len(options))
+	for i, option := range options {
+		trimmed[i] = strings.TrimSpace(option)
+	}
+	return trimmed
+}
+
+// fieldValue checks a value given for f and returns it as stored: a trimmed
+// string, a float64 for number fields or the chosen options of a
+// multi_select field, spelled as defined. Numbers may be given as strings
+// and multi_select options as a comma-separated string. An empty value
+// returns nil, which clears the field.
+func fieldValue(f models.CustomField, value any) (any, error) {
+	switch f.Type {
+	case models.FieldNumber:
+		switch n := value.(type) {
+		case nil:
+			return nil, nil
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case string:
+			if strings.TrimSpace(n) == "" {
+				return nil, nil
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", n)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("must be a number")
+
+	case models.FieldMultiSelect:
+		var items []string
+		switch list := value.(type) {
+		case nil:
+		case string:
+			items = strings.Split(list, ",")
+		case []string:
+			items = list
+		case []any:
+			for _, item := range list {
+				text, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("must be a list of options")
+				}
+				items = append(items, text)
+			}
+		default:
+			return nil, fmt.Errorf("must be a list of options")
+		}
+		var chosen []string
+		for _, item := range items {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			option, err := fieldOption(f, item)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(chosen, option) {
+				chosen = append(chosen, option)
+			}
+		}
+		if len(chosen) == 0 {
+			return nil, nil
+		}
+		return chosen, nil
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	switch f.Type {
+	case models.FieldDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return nil, fmt.Errorf("%q is not a date in YYYY-MM-DD form", text)
+		}
+	case models.FieldSelect:
+		return fieldOption(f, text)
+	case models.FieldURL:
+		u, err := url.Parse(text)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q is not an http or https URL", text)
+		}
+	}
+	return text, nil
+}
+
+// fieldOption returns the option of f matching text, ignoring case.
+func fieldOption(f models.CustomField, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	for _, option := range f.Options {
+		if strings.EqualFold(option, text) {
+			return option, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not one of %s", text, strings.Join(f.Options, ", "))
+}
+
+// setFieldValues checks values against the custom fields of t's project and
+// applies them to t.Fields, leaving the fields values does not name. Unknown
+// keys and invalid values are recorded in v.
+func (s *Store) setFieldValues(v *ValidationError, t *models.Ticket, values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+	fields, err := s.customFields(" AND project_id = ?", t.ProjectID)
+	if err != nil {
+		return err
+	}
+	updated := maps.Clone(t.Fields)
+	if updated == nil {
+		updated = map[string]any{}
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		i := slices.IndexFunc(fields, func(f models.CustomField) bool { return f.Key == key })
+		if i < 0 {
+			v.add("fields."+key, "the project has no field %q", key)
+			continue
+		}
+		value, err := fieldValue(fields[i], values[key])
+		if err != nil {
+			v.add("fields."+key, "%v", err)
+			continue
+		}
+		if value == nil {
+			delete(updated, key)
+		} else {
+			updated[key] = value
+		}
+	}
+	t.Fields = updated
+	if len(updated) == 0 {
+		t.Fields = nil
+	}
+	return nil
+}
+
+// writeFieldValues replaces the stored custom field values of a ticket of
+// projectID. Keys the project has no field for are skipped.
+func writeFieldValues(e execer, ticketID, projectID string, values map[string]any) error {
+	if _, err := e.Exec("DELETE FROM ticket_field_values WHERE ticket_id = ?", ticketID); err != nil {
+		return fmt.Errorf("clearing field values: %w", err)
+	}
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("encoding field %s: %w", key, err)
+		}
+		_, err = e.Exec(`INSERT INTO ticket_field_values (ticket_id, field_id, value)
+			SELECT ?, id, ? FROM custom_fields WHERE project_id = ? AND key = ?`, ticketID, string(data), projectID, key)
+		if err != nil {
+			return fmt.Errorf("setting field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// decodeFieldValue reads a stored value of a field of the given type.
+func decodeFieldValue(fieldType, data string) (any, error) {
+	var value any
+	var err error
+	switch fieldType {
+	case models.FieldMultiSelect:
+		var options []string
+		err = json.Unmarshal([]byte(data), &options)
+		value = options
+	case models.FieldNumber:
+		var n float64
+		err = json.Unmarshal([]byte(data), &n)
+		value = n
+	default:
+		var text string
+		err = json.Unmarshal([]byte(data), &text)
+		value = text
+	}
+	return value, err
+}
+
+// fieldChanges lists the custom field values that differ between two
+// versions of a ticket, as "fields.<key>" changes for the activity log.
+func fieldChanges(before, after map[string]any) []fieldChange {
+	keys := slices.Sorted(maps.Keys(before))
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []fieldChange
+	for _, key := range keys {
+		if oldValue, newValue := FieldText(before[key]), FieldText(after[key]); oldValue != newValue {
+			changes = append(changes, fieldChange{"fields." + key, oldValue, newValue})
+		}
+	}
+	return changes
+}
+
+// FieldText renders a custom field value as text, joining the options of a
+// multi_select field with commas.
+func FieldText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestCreateCustomFieldValidation(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	if _, err := s.CreateCustomField(models.CreateCustomFieldRequest{ProjectID: p.ID, Key: "env", Type: models.FieldText}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		req   models.CreateCustomFieldRequest
+		field string
+	}{
+		{"taken key", models.CreateCustomFieldRequest{Key: "env", Type: models.FieldText}, "key"},
+		{"bad key", models.CreateCustomFieldRequest{Key: "Story Points", Type: models.FieldNumber}, "key"},
+		{"built-in key", models.CreateCustomFieldRequest{Key: "status", Type: models.FieldText}, "key"},
+		{"unknown type", models.CreateCustomFieldRequest{Key: "points", Type: "integer"}, "type"},
+		{"select without options", models.CreateCustomFieldRequest{Key: "size", Type: models.FieldSelect}, "options"},
+		{"options on text", models.CreateCustomFieldRequest{Key: "size", Type: models.FieldText, Options: []string{"s"}}, "options"},
+		{"repeated option", models.CreateCustomFieldRequest{Key: "size", Type: models.FieldSelect, Options: []string{"s", "S"}}, "options"},
+	}
+	for _, tt := range tests {
+		tt.req.ProjectID = p.ID
+		_, err := s.CreateCustomField(tt.req)
+		if got := fieldNames(t, err); !slices.Contains(got, tt.field) {
+			t.Errorf("%s: errors on %v, want %s", tt.name, got, tt.field)
+		}
+	}
+}
+
+func TestTicketFieldValues(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	for _, req := range []models.CreateCustomFieldRequest{
+		{ProjectID: p.ID, Key: "points", Type: models.FieldNumber},
+		{ProjectID: p.ID, Key: "env", Type: models.FieldSelect, Options: []string{"prod", "staging"}},
+		{ProjectID: p.ID, Key: "browsers", Type: models.FieldMultiSelect, Options: []string{"Chrome", "Firefox"}},
+		{ProjectID: p.ID, Key: "found", Type: models.FieldDate},
+		{ProjectID: p.ID, Key: "report", Type: models.FieldURL},
+	} {
+		if _, err := s.CreateCustomField(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login", Fields: map[string]any{
+		"points": "3", "env": "PROD", "browsers": "firefox, chrome,firefox", "found": "2026-03-01",
+	}})
+	want := map[string]any{"points": 3.0, "env": "prod", "browsers": []string{"Firefox", "Chrome"}, "found": "2026-03-01"}
+	if got := mustGetTicket(t, s, ticket.ID).Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+
+	// Values left out are kept, and empty ones clear the field.
+	if _, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Fields: map[string]any{"points": nil, "env": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]any{"browsers": []string{"Firefox", "Chrome"}, "found": "2026-03-01"}
+	if got := mustGetTicket(t, s, ticket.ID).Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields after clearing = %v, want %v", got, want)
+	}
+
+	_, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Fields: map[string]any{
+		"points": "many", "env": "dev", "browsers": []any{"Safari"}, "found": "March", "report": "ftp://x", "colour": "red",
+	}})
+	wantFields := []string{"fields.browsers", "fields.colour", "fields.env", "fields.found", "fields.points", "fields.report"}
+	if got := fieldNames(t, err); !slices.Equal(got, wantFields) {
+		t.Errorf("errors on %v, want %v", got, wantFields)
+	}
+}
+
+func TestUpdateCustomFieldOptions(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	env, err := s.CreateCustomField(models.CreateCustomFieldRequest{ProjectID: p.ID, Key: "env", Type: models.FieldSelect, Options: []string{"prod", "staging", "dev"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	browsers, err := s.CreateCustomField(models.CreateCustomFieldRequest{ProjectID: p.ID, Key: "browsers", Type: models.FieldMultiSelect, Options: []string{"chrome", "firefox"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login", Fields: map[string]any{"env": "prod", "browsers": []string{"firefox"}}})
+
+	// Options no ticket uses can go; ones in use cannot.
+	if _, err := s.UpdateCustomField(env.ID, models.UpdateCustomFieldRequest{Options: []string{"prod", "staging"}}); err != nil {
+		t.Errorf("removing an unused option: %v", err)
+	}
+	for _, tt := range []struct {
+		field   *models.CustomField
+		options []string
+	}{
+		{env, []string{"staging"}},
+		{browsers, []string{"chrome"}},
+	} {
+		_, err := s.UpdateCustomField(tt.field.ID, models.UpdateCustomFieldRequest{Options: tt.options})
+		if got := fieldNames(t, err); !slices.Equal(got, []string{"options"}) {
+			t.Errorf("removing an option of %s in use: errors on %v, want options", tt.field.Key, got)
+		}
+	}
+	if f, err := s.GetCustomField(env.ID); err != nil || !slices.Equal(f.Options, []string{"prod", "staging"}) {
+		t.Errorf("env options after a refused update: %v, %v", f, err)
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ListMembers returns all members, or only those on teamID when it is set.
+func (s *Store) ListMembers(teamID string) ([]models.Member, error) {
+	query := "SELECT m.id, m.name, m.handle, m.avatar_color, m.created_at FROM members m"
+	args := []any{}
+	if teamID != "" {
+		query += " JOIN team_members tm ON tm.member_id = m.id WHERE tm.team_id = ?"
+		args = append(args, teamID)
+	}
+	query += " ORDER BY m.name"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.Member
+	for rows.Next() {
+		var m models.Member
+		if err := rows.Scan(&m.ID, &m.Name, &m.Handle, &m.AvatarColor, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range members {
+		if members[i].TeamIDs, err = s.getMemberTeamIDs(members[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+func (s *Store) GetMember(id string) (*models.Member, error) {
+	return s.getMemberWhere("id", id)
+}
+
+func (s *Store) GetMemberByHandle(handle string) (*models.Member, error) {
+	return s.getMemberWhere("handle", handle)
+}
+
+func (s *Store) getMemberWhere(column, value string) (*models.Member, error) {
+	var m models.Member
+	err := s.db.QueryRow("SELECT id, name, handle, avatar_color, created_at FROM members WHERE "+column+" = ?", value).
+		Scan(&m.ID, &m.Name, &m.Handle, &m.AvatarColor, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.TeamIDs, err = s.getMemberTeamIDs(m.ID)
+	return &m, err
+}
+
+func (s *Store) CreateMember(req models.CreateMemberRequest) (*models.Member, error) {
+	m := models.Member{
+		ID:          newID(),
+		Name:        req.Name,
+		Handle:      req.Handle,
+		AvatarColor: req.AvatarColor,
+		CreatedAt:   time.Now(),
+	}
+	if m.AvatarColor == "" {
+		m.AvatarColor = "#10B981"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	_, err = tx.Exec("INSERT INTO members (id, name, handle, avatar_color, created_at) VALUES (?, ?, ?, ?, ?)",
+		m.ID, m.Name, m.Handle, m.AvatarColor, m.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := setMemberTeams(tx, m.ID, req.TeamIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetMember(m.ID)
+}
+
+func (s *Store) UpdateMember(id string, req models.UpdateMemberRequest) (*models.Member, error) {
+	m, err := s.GetMember(id)
+	if err != nil || m == nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		m.Name = *req.Name
+	}
+	if req.Handle != nil {
+		m.Handle = *req.Handle
+	}
+	if req.AvatarColor != nil {
+		m.AvatarColor = *req.AvatarColor
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	_, err = tx.Exec("UPDATE members SET name=?, handle=?, avatar_color=? WHERE id=?", m.Name, m.Handle, m.AvatarColor, m.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if req.TeamIDs != nil {
+		if _, err := tx.Exec("DELETE FROM team_members WHERE member_id = ?", id); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := setMemberTeams(tx, id, req.TeamIDs); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetMember(id)
+}
+
+func (s *Store) DeleteMember(id string) error {
+	_, err := s.db.Exec("DELETE FROM members WHERE id = ?", id)
+	return err
+}
+
+func setMemberTeams(e execer, memberID string, teamIDs []string) error {
+	for _, teamID := range teamIDs {
+		if _, err := e.Exec("INSERT OR IGNORE INTO team_members (team_id, member_id) VALUES (?, ?)", teamID, memberID); err != nil {
+			return fmt.Errorf("adding member to team %s: %w", teamID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) getMemberTeamIDs(memberID string) ([]string, error) {
+	rows, err := s.db.Query("SELECT team_id FROM team_members WHERE member_id = ?", memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
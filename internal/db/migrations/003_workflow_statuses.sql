@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS workflow_statuses (
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    status     TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    category   TEXT NOT NULL DEFAULT 'active',
+    position   INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, status)
+);
+
+INSERT OR IGNORE INTO workflow_statuses (project_id, status, name, category, position)
+SELECT id, 'todo', 'Todo', 'backlog', 0 FROM projects;
+INSERT OR IGNORE INTO workflow_statuses (project_id, status, name, category, position)
+SELECT id, 'in_progress', 'In Progress', 'active', 1 FROM projects;
+INSERT OR IGNORE INTO workflow_statuses (project_id, status, name, category, position)
+SELECT id, 'done', 'Done', 'done', 2 FROM projects;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id         TEXT PRIMARY KEY,
+    ticket_id  TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    author     TEXT NOT NULL DEFAULT '',
+    body       TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    edited_at  DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_ticket_id ON comments(ticket_id);
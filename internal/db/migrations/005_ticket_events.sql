@@ -0,0 +1,19 @@
+-- ticket_events is append-only and deliberately has no foreign keys so the
+-- history of deleted tickets and projects is kept.
+CREATE TABLE IF NOT EXISTS ticket_events (
+    id         TEXT PRIMARY KEY,
+    ticket_id  TEXT NOT NULL,
+    project_id TEXT NOT NULL,
+    ticket_key TEXT NOT NULL DEFAULT '',
+    action     TEXT NOT NULL,
+    field      TEXT NOT NULL DEFAULT '',
+    old_value  TEXT NOT NULL DEFAULT '',
+    new_value  TEXT NOT NULL DEFAULT '',
+    actor      TEXT NOT NULL DEFAULT '',
+    source     TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_events_ticket_id ON ticket_events(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_events_project_id ON ticket_events(project_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_events_created_at ON ticket_events(created_at);
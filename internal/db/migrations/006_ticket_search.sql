@@ -0,0 +1,93 @@
+-- The searchable text of each ticket lives in an ordinary table keyed by
+-- ticket_id, which FTS5 indexes as external content. Triggers on the source
+-- tables can then find a ticket's row through the ticket_id index instead of
+-- scanning the FTS table, where ticket_id is UNINDEXED.
+CREATE TABLE IF NOT EXISTS ticket_search_docs (
+    id          INTEGER PRIMARY KEY,
+    ticket_id   TEXT NOT NULL UNIQUE,
+    title       TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    subtasks    TEXT NOT NULL DEFAULT '',
+    comments    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS ticket_search USING fts5(
+    ticket_id UNINDEXED,
+    title,
+    description,
+    subtasks,
+    comments,
+    content = 'ticket_search_docs',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+-- Keep the index in step with the documents table.
+CREATE TRIGGER IF NOT EXISTS ticket_search_docs_insert AFTER INSERT ON ticket_search_docs BEGIN
+    INSERT INTO ticket_search (rowid, ticket_id, title, description, subtasks, comments)
+    VALUES (NEW.id, NEW.ticket_id, NEW.title, NEW.description, NEW.subtasks, NEW.comments);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ticket_search_docs_update AFTER UPDATE ON ticket_search_docs BEGIN
+    INSERT INTO ticket_search (ticket_search, rowid, ticket_id, title, description, subtasks, comments)
+    VALUES ('delete', OLD.id, OLD.ticket_id, OLD.title, OLD.description, OLD.subtasks, OLD.comments);
+    INSERT INTO ticket_search (rowid, ticket_id, title, description, subtasks, comments)
+    VALUES (NEW.id, NEW.ticket_id, NEW.title, NEW.description, NEW.subtasks, NEW.comments);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ticket_search_docs_delete AFTER DELETE ON ticket_search_docs BEGIN
+    INSERT INTO ticket_search (ticket_search, rowid, ticket_id, title, description, subtasks, comments)
+    VALUES ('delete', OLD.id, OLD.ticket_id, OLD.title, OLD.description, OLD.subtasks, OLD.comments);
+END;
+
+-- Every source trigger rebuilds the affected column for one ticket, found
+-- through the ticket_id index.
+CREATE TRIGGER IF NOT EXISTS tickets_search_insert AFTER INSERT ON tickets BEGIN
+    INSERT INTO ticket_search_docs (ticket_id, title, description)
+    VALUES (NEW.id, NEW.title, COALESCE(NEW.description, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tickets_search_update AFTER UPDATE OF title, description ON tickets BEGIN
+    UPDATE ticket_search_docs SET title = NEW.title, description = COALESCE(NEW.description, '')
+    WHERE ticket_id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tickets_search_delete AFTER DELETE ON tickets BEGIN
+    DELETE FROM ticket_search_docs WHERE ticket_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS subtasks_search_insert AFTER INSERT ON subtasks BEGIN
+    UPDATE ticket_search_docs SET subtasks = COALESCE((SELECT group_concat(title, ' ') FROM subtasks WHERE ticket_id = NEW.ticket_id), '')
+    WHERE ticket_id = NEW.ticket_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS subtasks_search_update AFTER UPDATE OF title ON subtasks BEGIN
+    UPDATE ticket_search_docs SET subtasks = COALESCE((SELECT group_concat(title, ' ') FROM subtasks WHERE ticket_id = NEW.ticket_id), '')
+    WHERE ticket_id = NEW.ticket_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS subtasks_search_delete AFTER DELETE ON subtasks BEGIN
+    UPDATE ticket_search_docs SET subtasks = COALESCE((SELECT group_concat(title, ' ') FROM subtasks WHERE ticket_id = OLD.ticket_id), '')
+    WHERE ticket_id = OLD.ticket_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_search_insert AFTER INSERT ON comments BEGIN
+    UPDATE ticket_search_docs SET comments = COALESCE((SELECT group_concat(body, ' ') FROM comments WHERE ticket_id = NEW.ticket_id), '')
+    WHERE ticket_id = NEW.ticket_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_search_update AFTER UPDATE OF body ON comments BEGIN
+    UPDATE ticket_search_docs SET comments = COALESCE((SELECT group_concat(body, ' ') FROM comments WHERE ticket_id = NEW.ticket_id), '')
+    WHERE ticket_id = NEW.ticket_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_search_delete AFTER DELETE ON comments BEGIN
+    UPDATE ticket_search_docs SET comments = COALESCE((SELECT group_concat(body, ' ') FROM comments WHERE ticket_id = OLD.ticket_id), '')
+    WHERE ticket_id = OLD.ticket_id;
+END;
+
+INSERT INTO ticket_search_docs (ticket_id, title, description, subtasks, comments)
+SELECT t.id, t.title, COALESCE(t.description, ''),
+    COALESCE((SELECT group_concat(title, ' ') FROM subtasks WHERE ticket_id = t.id), ''),
+    COALESCE((SELECT group_concat(body, ' ') FROM comments WHERE ticket_id = t.id), '')
+FROM tickets t;
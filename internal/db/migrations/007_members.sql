@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS members (
+    id           TEXT PRIMARY KEY,
+    name         TEXT NOT NULL,
+    handle       TEXT NOT NULL UNIQUE,
+    avatar_color TEXT DEFAULT '#10B981',
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id   TEXT REFERENCES teams(id) ON DELETE CASCADE,
+    member_id TEXT REFERENCES members(id) ON DELETE CASCADE,
+    PRIMARY KEY (team_id, member_id)
+);
+
+ALTER TABLE tickets ADD COLUMN assignee_id TEXT REFERENCES members(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tickets_assignee_id ON tickets(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_team_members_member_id ON team_members(member_id);
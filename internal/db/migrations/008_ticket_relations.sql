@@ -0,0 +1,18 @@
+-- ticket_relations replaces ticket_dependencies. Each row is stored from the
+-- forward side (e.g. blocked_by, duplicates); the inverse side (blocks,
+-- duplicated_by) is computed when reading.
+CREATE TABLE IF NOT EXISTS ticket_relations (
+    ticket_id  TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    related_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    type       TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ticket_id, related_id, type)
+);
+
+INSERT OR IGNORE INTO ticket_relations (ticket_id, related_id, type)
+SELECT ticket_id, blocked_by_id, 'blocked_by' FROM ticket_dependencies
+WHERE ticket_id IS NOT NULL AND blocked_by_id IS NOT NULL;
+
+DROP TABLE IF EXISTS ticket_dependencies;
+
+CREATE INDEX IF NOT EXISTS idx_ticket_relations_related_id ON ticket_relations(related_id);
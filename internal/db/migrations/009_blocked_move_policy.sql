@@ -0,0 +1 @@
+ALTER TABLE projects ADD COLUMN blocked_move_policy TEXT DEFAULT 'allow';
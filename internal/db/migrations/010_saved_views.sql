@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS saved_views (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    project_id TEXT REFERENCES projects(id) ON DELETE CASCADE,
+    query      TEXT NOT NULL DEFAULT '',
+    sort       TEXT NOT NULL DEFAULT '',
+    sort_order TEXT NOT NULL DEFAULT '',
+    group_by   TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_views_project_id ON saved_views(project_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS sprints (
+    id         TEXT PRIMARY KEY,
+    project_id TEXT REFERENCES projects(id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    goal       TEXT NOT NULL DEFAULT '',
+    start_date DATETIME NOT NULL,
+    end_date   DATETIME NOT NULL,
+    closed_at  DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tickets ADD COLUMN sprint_id TEXT REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sprints_project_id ON sprints(project_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_sprint_id ON tickets(sprint_id);
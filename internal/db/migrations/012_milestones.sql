@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS milestones (
+    id          TEXT PRIMARY KEY,
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    target_date DATETIME,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tickets ADD COLUMN milestone_id TEXT REFERENCES milestones(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_milestones_project_name ON milestones(project_id, name COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_tickets_milestone_id ON tickets(milestone_id);
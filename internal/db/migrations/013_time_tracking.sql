@@ -0,0 +1,14 @@
+ALTER TABLE projects ADD COLUMN estimate_unit TEXT DEFAULT 'points';
+ALTER TABLE tickets ADD COLUMN estimate REAL;
+
+CREATE TABLE IF NOT EXISTS time_entries (
+    id         TEXT PRIMARY KEY,
+    ticket_id  TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    minutes    INTEGER NOT NULL,
+    note       TEXT NOT NULL DEFAULT '',
+    date       DATETIME NOT NULL,
+    author     TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_ticket_id ON time_entries(ticket_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS recurrences (
+    id          TEXT PRIMARY KEY,
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    rule        TEXT NOT NULL,
+    template    TEXT NOT NULL,
+    paused      INTEGER NOT NULL DEFAULT 0,
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per occurrence that has been claimed, so that a restart or a
+-- second scheduler never creates the same occurrence twice.
+CREATE TABLE IF NOT EXISTS recurrence_runs (
+    recurrence_id TEXT NOT NULL REFERENCES recurrences(id) ON DELETE CASCADE,
+    occurrence    TEXT NOT NULL,
+    ticket_id     TEXT REFERENCES tickets(id) ON DELETE SET NULL,
+    created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (recurrence_id, occurrence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurrences_project_id ON recurrences(project_id);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS ticket_templates (
+    id          TEXT PRIMARY KEY,
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    title       TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    priority    TEXT NOT NULL DEFAULT '',
+    team_id     TEXT REFERENCES teams(id) ON DELETE SET NULL,
+    subtasks    TEXT NOT NULL DEFAULT '[]',
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS template_labels (
+    template_id TEXT REFERENCES ticket_templates(id) ON DELETE CASCADE,
+    label_id    TEXT REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (template_id, label_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_templates_project_name ON ticket_templates(project_id, name COLLATE NOCASE);
@@ -0,0 +1,11 @@
+-- Deleting a project, ticket, team or label moves it to the trash by setting
+-- deleted_at; rows are removed for good when the trash is purged. Tickets
+-- trashed along with their project share the project's deleted_at, which is
+-- how restoring the project finds them.
+ALTER TABLE projects ADD COLUMN deleted_at DATETIME;
+ALTER TABLE tickets ADD COLUMN deleted_at DATETIME;
+ALTER TABLE teams ADD COLUMN deleted_at DATETIME;
+ALTER TABLE labels ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tickets_deleted_at ON tickets(deleted_at);
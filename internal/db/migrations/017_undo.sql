@@ -0,0 +1,19 @@
+-- undo_log keeps the state of a ticket, project or subtask before and after
+-- each change so that the actor who made it can undo and redo it. A NULL
+-- state means the entity did not exist or was in the trash. Entries with
+-- undone_at set form the actor's redo stack.
+CREATE TABLE IF NOT EXISTS undo_log (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor       TEXT NOT NULL DEFAULT '',
+    source      TEXT NOT NULL DEFAULT '',
+    entity      TEXT NOT NULL,
+    entity_id   TEXT NOT NULL,
+    summary     TEXT NOT NULL,
+    before      TEXT,
+    after       TEXT,
+    undone_at   DATETIME,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_undo_log_actor ON undo_log(actor, source);
+CREATE INDEX IF NOT EXISTS idx_undo_log_entity ON undo_log(entity_id);
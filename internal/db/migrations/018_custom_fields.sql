@@ -0,0 +1,24 @@
+-- custom_fields defines the typed fields a project adds to its tickets, and
+-- ticket_field_values holds each ticket's values as JSON: a string, a number
+-- or, for multi_select fields, an array of options.
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id          TEXT PRIMARY KEY,
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key         TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    options     TEXT NOT NULL DEFAULT '[]',
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ticket_field_values (
+    ticket_id   TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    field_id    TEXT NOT NULL REFERENCES custom_fields(id) ON DELETE CASCADE,
+    value       TEXT NOT NULL,
+    PRIMARY KEY (ticket_id, field_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_fields_project_key ON custom_fields(project_id, key);
+CREATE INDEX IF NOT EXISTS idx_custom_fields_key ON custom_fields(key);
+CREATE INDEX IF NOT EXISTS idx_ticket_field_values_field ON ticket_field_values(field_id);
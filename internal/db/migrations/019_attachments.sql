@@ -0,0 +1,22 @@
+-- attachment_blobs holds each distinct file once, keyed by the SHA-256 of its
+-- content, and attachments names the files attached to each ticket. A blob
+-- is deleted when no attachment uses it any more.
+CREATE TABLE IF NOT EXISTS attachment_blobs (
+    sha256      TEXT PRIMARY KEY,
+    size        INTEGER NOT NULL,
+    data        BLOB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id           TEXT PRIMARY KEY,
+    ticket_id    TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    filename     TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size         INTEGER NOT NULL,
+    sha256       TEXT NOT NULL REFERENCES attachment_blobs(sha256),
+    author       TEXT NOT NULL DEFAULT '',
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_ticket_id ON attachments(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_sha256 ON attachments(sha256);
@@ -0,0 +1,24 @@
+-- ticket_watchers lists the members following each ticket, and notifications
+-- is each member's inbox of changes to the tickets they watch.
+CREATE TABLE IF NOT EXISTS ticket_watchers (
+    ticket_id   TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    member_id   TEXT NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ticket_id, member_id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id          TEXT PRIMARY KEY,
+    member_id   TEXT NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    ticket_id   TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    ticket_key  TEXT NOT NULL DEFAULT '',
+    kind        TEXT NOT NULL,
+    message     TEXT NOT NULL,
+    actor       TEXT NOT NULL DEFAULT '',
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    read_at     DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_watchers_member ON ticket_watchers(member_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_member ON notifications(member_id, read_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_ticket ON notifications(ticket_id);
@@ -0,0 +1,10 @@
+-- wip_limits caps the number of a project's tickets in a status, either for
+-- the whole project (team_id '') or for one team's tickets.
+CREATE TABLE IF NOT EXISTS wip_limits (
+    project_id  TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    status      TEXT NOT NULL,
+    team_id     TEXT NOT NULL DEFAULT '',
+    max_tickets INTEGER NOT NULL,
+    policy      TEXT NOT NULL DEFAULT 'warn',
+    PRIMARY KEY (project_id, status, team_id)
+);
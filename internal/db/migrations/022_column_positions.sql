@@ -0,0 +1,8 @@
+-- Tickets used to be placed by ticket number across the whole project, and
+-- kept their position when they changed status. Space each column's tickets
+-- evenly, in their current order.
+UPDATE tickets SET position = (
+    SELECT r.n * 1000 FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY project_id, status ORDER BY position, id) AS n FROM tickets
+    ) r WHERE r.id = tickets.id
+);
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+const milestoneColumns = `m.id, m.project_id, m.name, m.description, m.target_date, m.created_at, m.updated_at,
+	(SELECT COUNT(*) FROM tickets t WHERE t.milestone_id = m.id AND t.deleted_at IS NULL),
+	(SELECT COUNT(*) FROM tickets t WHERE t.milestone_id = m.id AND t.deleted_at IS NULL AND ` + ticketIsDone + `),
+	(SELECT COUNT(*) FROM subtasks st JOIN tickets t ON t.id = st.ticket_id WHERE t.milestone_id = m.id AND t.deleted_at IS NULL),
+	(SELECT COUNT(*) FROM subtasks st JOIN tickets t ON t.id = st.ticket_id WHERE t.milestone_id = m.id AND t.deleted_at IS NULL AND st.completed)`
+
+func scanMilestone(row interface{ Scan(...any) error }) (*models.Milestone, error) {
+	var m models.Milestone
+	err := row.Scan(&m.ID, &m.ProjectID, &m.Name, &m.Description, &m.TargetDate, &m.CreatedAt, &m.UpdatedAt,
+		&m.TicketCount, &m.DoneCount, &m.SubtaskCount, &m.SubtaskDoneCount)
+	if err != nil {
+		return nil, err
+	}
+	if m.TicketCount > 0 {
+		m.Progress = m.DoneCount * 100 / m.TicketCount
+	}
+	m.Overdue = m.TargetDate != nil && m.DoneCount < m.TicketCount &&
+		m.TargetDate.Format("2006-01-02") < time.Now().Format("2006-01-02")
+	return &m, nil
+}
+
+// ListMilestones returns milestones by target date, those without one last:
+// all of them, or only those of projectID when it is set.
+func (s *Store) ListMilestones(projectID string) ([]models.Milestone, error) {
+	q := "SELECT " + milestoneColumns + " FROM milestones m WHERE " + inLiveProject("m.project_id")
+	args := []any{}
+	if projectID != "" {
+		q += " AND m.project_id = ?"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY m.target_date IS NULL, m.target_date, m.created_at"
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []models.Milestone
+	for rows.Next() {
+		m, err := scanMilestone(rows)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, *m)
+	}
+	return milestones, rows.Err()
+}
+
+func (s *Store) GetMilestone(id string) (*models.Milestone, error) {
+	m, err := scanMilestone(s.db.QueryRow("SELECT "+milestoneColumns+" FROM milestones m WHERE m.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+func (s *Store) CreateMilestone(req models.CreateMilestoneRequest) (*models.Milestone, error) {
+	v := &ValidationError{}
+	m := models.Milestone{
+		ID:          newID(),
+		ProjectID:   req.ProjectID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if m.ProjectID == "" {
+		v.add("projectId", "is required")
+	} else if err := s.checkExists(v, "projectId", "projects", "project", m.ProjectID); err != nil {
+		return nil, err
+	}
+	if req.TargetDate != "" {
+		m.TargetDate = parseDate(v, "targetDate", req.TargetDate)
+	}
+	if err := s.validateMilestone(v, &m); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(`INSERT INTO milestones (id, project_id, name, description, target_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		m.ID, m.ProjectID, m.Name, m.Description, m.TargetDate, m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting milestone: %w", err)
+	}
+	return s.GetMilestone(m.ID)
+}
+
+func (s *Store) UpdateMilestone(id string, req models.UpdateMilestoneRequest) (*models.Milestone, error) {
+	m, err := s.GetMilestone(id)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	v := &ValidationError{}
+
+	if req.Name != nil {
+		m.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		m.Description = *req.Description
+	}
+	if req.TargetDate != nil {
+		m.TargetDate = nil
+		if *req.TargetDate != "" {
+			m.TargetDate = parseDate(v, "targetDate", *req.TargetDate)
+		}
+	}
+	if err := s.validateMilestone(v, m); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec("UPDATE milestones SET name=?, description=?, target_date=?, updated_at=? WHERE id=?",
+		m.Name, m.Description, m.TargetDate, time.Now(), m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("updating milestone: %w", err)
+	}
+	return s.GetMilestone(id)
+}
+
+// validateMilestone adds the name checks to v and returns the collected
+// errors. Names are unique within a project, ignoring case.
+func (s *Store) validateMilestone(v *ValidationError, m *models.Milestone) error {
+	if m.Name == "" {
+		v.add("name", "must not be empty")
+	} else {
+		var other string
+		err := s.db.QueryRow("SELECT id FROM milestones WHERE project_id = ? AND name = ? COLLATE NOCASE AND id != ?",
+			m.ProjectID, m.Name, m.ID).Scan(&other)
+		if err == nil {
+			v.add("name", "the project already has a milestone named %q", m.Name)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	return v.err()
+}
+
+// DeleteMilestone removes a milestone. Its tickets stay, without one.
+func (s *Store) DeleteMilestone(id string) error {
+	_, err := s.db.Exec("DELETE FROM milestones WHERE id = ?", id)
+	return err
+}
+
+// checkMilestone records a field error unless a ticket of projectID can be
+// put in milestoneID.
+func (s *Store) checkMilestone(v *ValidationError, field, milestoneID, projectID string) error {
+	var owner string
+	err := s.db.QueryRow("SELECT project_id FROM milestones WHERE id = ?", milestoneID).Scan(&owner)
+	switch {
+	case err == sql.ErrNoRows:
+		v.add(field, "milestone %s not found", milestoneID)
+	case err != nil:
+		return err
+	case owner != projectID:
+		v.add(field, "milestone %s belongs to another project", milestoneID)
+	}
+	return nil
+}
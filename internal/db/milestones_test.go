@@ -0,0 +1,67 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestMilestoneProgress(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	m, err := s.CreateMilestone(models.CreateMilestoneRequest{ProjectID: p.ID, Name: "v1.0", TargetDate: yesterday})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Overdue || m.Progress != 0 {
+		t.Errorf("empty milestone: overdue %v, progress %d; want neither", m.Overdue, m.Progress)
+	}
+
+	tickets := make([]*models.Ticket, 4)
+	for i := range tickets {
+		tickets[i] = mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket", MilestoneID: &m.ID})
+	}
+	if _, err := s.MoveTicket(tickets[0].ID, models.MoveTicketRequest{Status: "done"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = s.GetMilestone(m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TicketCount != 4 || m.DoneCount != 1 || m.Progress != 25 || !m.Overdue {
+		t.Errorf("got %d of %d done, progress %d, overdue %v; want 1 of 4, 25, overdue",
+			m.DoneCount, m.TicketCount, m.Progress, m.Overdue)
+	}
+
+	for _, ticket := range tickets[1:] {
+		if _, err := s.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: "done"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m, err = s.GetMilestone(m.ID); err != nil {
+		t.Fatal(err)
+	}
+	if m.Progress != 100 || m.Overdue {
+		t.Errorf("all done: progress %d, overdue %v; want 100 and not overdue", m.Progress, m.Overdue)
+	}
+}
+
+func TestMilestoneOfAnotherProject(t *testing.T) {
+	s := newTestStore(t)
+	auth := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	web := mustProject(t, s, models.CreateProjectRequest{Name: "Web", Prefix: "WEB"})
+	m, err := s.CreateMilestone(models.CreateMilestoneRequest{ProjectID: auth.ID, Name: "v1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.CreateTicket(models.CreateTicketRequest{ProjectID: web.ID, Title: "Ticket", MilestoneID: &m.ID})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Fields[0].Field != "milestoneId" {
+		t.Errorf("got %v, want a validation error on milestoneId", err)
+	}
+}
@@ -0,0 +1,430 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 500
+)
+
+// querier is an execer that can also read, such as *sql.Tx.
+type querier interface {
+	execer
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// actorMember matches the member whose handle is the bound actor name, which
+// is how changes made from the CLI ($USER) or the web UI are tied to people.
+const actorMember = "SELECT id FROM members WHERE handle = ?"
+
+// ListWatchers returns the members watching a ticket.
+func (s *Store) ListWatchers(ticketID string) ([]models.Member, error) {
+	rows, err := s.db.Query(`SELECT m.id, m.name, m.handle, m.avatar_color, m.created_at
+		FROM ticket_watchers w JOIN members m ON m.id = w.member_id
+		WHERE w.ticket_id = ? ORDER BY w.created_at, m.name`, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.Member
+	for rows.Next() {
+		var m models.Member
+		if err := rows.Scan(&m.ID, &m.Name, &m.Handle, &m.AvatarColor, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range members {
+		if members[i].TeamIDs, err = s.getMemberTeamIDs(members[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+func (s *Store) watcherIDs(ticketID string) ([]string, error) {
+	rows, err := s.db.Query("SELECT member_id FROM ticket_watchers WHERE ticket_id = ? ORDER BY created_at, member_id", ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// WatchTicket adds a member to a ticket's watchers and returns the ticket,
+// or nil if the ticket does not exist. Watching twice is not an error.
+func (s *Store) WatchTicket(ticketID string, req models.WatchRequest) (*models.Ticket, error) {
+	t, err := s.GetTicket(ticketID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	v := &ValidationError{}
+	if req.MemberID == "" {
+		v.add("memberId", "is required")
+	} else if err := s.checkExists(v, "memberId", "members", "member", req.MemberID); err != nil {
+		return nil, err
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if err := watch(s.db, t.ID, req.MemberID); err != nil {
+		return nil, err
+	}
+	return s.GetTicket(t.ID)
+}
+
+// UnwatchTicket removes a member from a ticket's watchers and returns the
+// ticket, or nil if the ticket does not exist.
+func (s *Store) UnwatchTicket(ticketID, memberID string) (*models.Ticket, error) {
+	t, err := s.GetTicket(ticketID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	if _, err := s.db.Exec("DELETE FROM ticket_watchers WHERE ticket_id = ? AND member_id = ?", t.ID, memberID); err != nil {
+		return nil, err
+	}
+	return s.GetTicket(t.ID)
+}
+
+func watch(e execer, ticketID, memberID string) error {
+	_, err := e.Exec("INSERT OR IGNORE INTO ticket_watchers (ticket_id, member_id, created_at) VALUES (?, ?, ?)",
+		ticketID, memberID, time.Now())
+	if err != nil {
+		return fmt.Errorf("adding watcher: %w", err)
+	}
+	return nil
+}
+
+// watchAsActor makes the member behind name, if there is one, watch a
+// ticket.
+func watchAsActor(e execer, ticketID, name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := e.Exec("INSERT OR IGNORE INTO ticket_watchers (ticket_id, member_id, created_at) SELECT ?, id, ? FROM members WHERE handle = ?",
+		ticketID, time.Now(), name)
+	if err != nil {
+		return fmt.Errorf("adding watcher: %w", err)
+	}
+	return nil
+}
+
+// notify keeps watchers up to date with a ticket event: the creator and the
+// assignee start watching, and watchers hear about status changes and about
+// tickets that are no longer blocked because their blocker is done or in the
+// trash, or was unlinked.
+func (s *Store) notify(e querier, t *models.Ticket, action string, changes []fieldChange) error {
+	if action == models.EventDeleted {
+		return s.notifyTrashed(e, "?", t.ID)
+	}
+	if action == models.EventCreated {
+		if err := watchAsActor(e, t.ID, s.actor.Name); err != nil {
+			return err
+		}
+		if t.AssigneeID != nil {
+			if err := watch(e, t.ID, *t.AssigneeID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range changes {
+		switch c.field {
+		case "assigneeId":
+			if c.newValue != "" {
+				if err := watch(e, t.ID, c.newValue); err != nil {
+					return err
+				}
+			}
+		case "status":
+			message := fmt.Sprintf("%s moved from %s to %s", t.DisplayKey(), c.oldValue, c.newValue)
+			if err := notifyWatchers(e, s.actor.Name, t.ID, t.DisplayKey(), models.NotifyStatus, message); err != nil {
+				return err
+			}
+			if err := s.notifyUnblocked(e, t, c.oldValue); err != nil {
+				return err
+			}
+		case "blockedBy":
+			var removed []string
+			kept := strings.Split(c.newValue, ",")
+			for _, id := range strings.Split(c.oldValue, ",") {
+				if id != "" && !slices.Contains(kept, id) {
+					removed = append(removed, id)
+				}
+			}
+			if err := s.notifyUnlinked(e, t.ID, removed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notifyUnblocked tells the watchers of the tickets t was blocking that they
+// are free to start, when t has just moved into a done status from one that
+// is not done and it was their last open blocker.
+func (s *Store) notifyUnblocked(e querier, t *models.Ticket, oldStatus string) error {
+	var wasDone bool
+	if err := e.QueryRow("SELECT EXISTS (SELECT 1 FROM workflow_statuses WHERE project_id = ? AND status = ? AND category = 'done')",
+		t.ProjectID, oldStatus).Scan(&wasDone); err != nil {
+		return err
+	}
+	if wasDone {
+		return nil
+	}
+	return s.notifyBlockerGone(e, t.ID, t.DisplayKey()+" is done")
+}
+
+// notifyTrashed does what notifyUnblocked does for the open tickets that
+// have just been moved to the trash. trashed is a query, with args, for
+// their IDs.
+func (s *Store) notifyTrashed(e querier, trashed string, args ...any) error {
+	rows, err := e.Query(`SELECT b.id, COALESCE(p.prefix || '-', '') || b.number
+		FROM tickets b LEFT JOIN projects p ON p.id = b.project_id
+		WHERE b.id IN (`+trashed+`) AND `+openBlockerCondition, args...)
+	if err != nil {
+		return err
+	}
+	blockers, err := scanTicketKeys(rows)
+	if err != nil {
+		return err
+	}
+	for _, b := range blockers {
+		if err := s.notifyBlockerGone(e, b.id, b.key+" was moved to the trash"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyUnlinked tells the watchers of the ticket with ID id that it is free
+// to start, when its blocked_by links to the tickets removed have just been
+// deleted, one of those was open, and no open blocker is left.
+func (s *Store) notifyUnlinked(e querier, id string, removed []string) error {
+	if len(removed) == 0 {
+		return nil
+	}
+	rows, err := e.Query(`SELECT t.id, COALESCE(p.prefix || '-', '') || t.number
+		FROM tickets t LEFT JOIN projects p ON p.id = t.project_id
+		WHERE t.id = ? AND t.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM ticket_relations o JOIN tickets b ON b.id = o.related_id
+			WHERE o.ticket_id = t.id AND o.type = 'blocked_by' AND b.deleted_at IS NULL AND `+openBlockerCondition+`)`, id)
+	if err != nil {
+		return err
+	}
+	tickets, err := scanTicketKeys(rows)
+	if err != nil || len(tickets) == 0 {
+		return err
+	}
+
+	ids, _ := json.Marshal(removed)
+	rows, err = e.Query(`SELECT b.id, COALESCE(p.prefix || '-', '') || b.number
+		FROM tickets b LEFT JOIN projects p ON p.id = b.project_id
+		WHERE b.id IN (SELECT value FROM json_each(?)) AND b.deleted_at IS NULL AND `+openBlockerCondition+`
+		ORDER BY b.project_id, b.number`, string(ids))
+	if err != nil {
+		return err
+	}
+	blockers, err := scanTicketKeys(rows)
+	if err != nil || len(blockers) == 0 {
+		return err
+	}
+	keys := make([]string, len(blockers))
+	for i, b := range blockers {
+		keys[i] = b.key
+	}
+	t := tickets[0]
+	message := fmt.Sprintf("%s is no longer blocked: unlinked from %s", t.key, strings.Join(keys, ", "))
+	return notifyWatchers(e, s.actor.Name, t.id, t.key, models.NotifyUnblocked, message)
+}
+
+// notifyBlockerGone tells the watchers of the live tickets blocked by the
+// ticket with ID blockerID, which no longer counts as open, that they are
+// free to start when they have no other open blocker. reason says what
+// happened to the blocker.
+func (s *Store) notifyBlockerGone(e querier, blockerID, reason string) error {
+	rows, err := e.Query(`SELECT r.ticket_id, COALESCE(p.prefix || '-', '') || t.number
+		FROM ticket_relations r JOIN tickets t ON t.id = r.ticket_id LEFT JOIN projects p ON p.id = t.project_id
+		WHERE r.related_id = ? AND r.type = 'blocked_by' AND t.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM ticket_relations o JOIN tickets b ON b.id = o.related_id
+			WHERE o.ticket_id = r.ticket_id AND o.type = 'blocked_by' AND b.deleted_at IS NULL AND `+openBlockerCondition+`)`,
+		blockerID)
+	if err != nil {
+		return err
+	}
+	tickets, err := scanTicketKeys(rows)
+	if err != nil {
+		return err
+	}
+	for _, u := range tickets {
+		message := fmt.Sprintf("%s is no longer blocked: %s", u.key, reason)
+		if err := notifyWatchers(e, s.actor.Name, u.id, u.key, models.NotifyUnblocked, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type keyedTicket struct{ id, key string }
+
+// scanTicketKeys reads and closes rows of ticket IDs and display keys.
+func scanTicketKeys(rows *sql.Rows) ([]keyedTicket, error) {
+	defer rows.Close()
+	var tickets []keyedTicket
+	for rows.Next() {
+		var t keyedTicket
+		if err := rows.Scan(&t.id, &t.key); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
+
+// notifyComment makes a comment's author watch the ticket and tells the other
+// watchers about the comment.
+func notifyComment(e querier, t *models.Ticket, c *models.Comment) error {
+	if err := watchAsActor(e, t.ID, c.Author); err != nil {
+		return err
+	}
+	body := strings.Join(strings.Fields(c.Body), " ")
+	if len([]rune(body)) > 80 {
+		body = string([]rune(body)[:79]) + "…"
+	}
+	message := fmt.Sprintf("%s commented on %s: %s", c.Author, t.DisplayKey(), body)
+	return notifyWatchers(e, c.Author, t.ID, t.DisplayKey(), models.NotifyComment, message)
+}
+
+// notifyWatchers adds a notification to the inbox of every watcher of a
+// ticket except the member behind actor, who made the change.
+func notifyWatchers(e querier, actor, ticketID, ticketKey, kind, message string) error {
+	rows, err := e.Query("SELECT member_id FROM ticket_watchers WHERE ticket_id = ? AND member_id NOT IN ("+actorMember+")",
+		ticketID, actor)
+	if err != nil {
+		return err
+	}
+	var members []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		members = append(members, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, memberID := range members {
+		_, err := e.Exec(`INSERT INTO notifications (id, member_id, ticket_id, ticket_key, kind, message, actor, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			newID(), memberID, ticketID, ticketKey, kind, message, actor, now)
+		if err != nil {
+			return fmt.Errorf("adding notification: %w", err)
+		}
+	}
+	return nil
+}
+
+const notificationColumns = "n.id, n.member_id, n.ticket_id, n.ticket_key, n.kind, n.message, n.actor, n.created_at, n.read_at"
+
+// ListNotifications returns a member's notifications about tickets that are
+// not in the trash, newest first.
+func (s *Store) ListNotifications(filter models.NotificationFilter) ([]models.Notification, error) {
+	query := "SELECT " + notificationColumns + ` FROM notifications n JOIN tickets t ON t.id = n.ticket_id
+		WHERE n.member_id = ? AND t.deleted_at IS NULL`
+	args := []any{filter.MemberID}
+	if filter.Unread {
+		query += " AND n.read_at IS NULL"
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	query += " ORDER BY n.rowid DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.MemberID, &n.TicketID, &n.TicketKey, &n.Kind, &n.Message, &n.Actor, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// UnreadNotificationCount returns how many unread notifications a member
+// has, for badges in the UI.
+func (s *Store) UnreadNotificationCount(memberID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications n JOIN tickets t ON t.id = n.ticket_id
+		WHERE n.member_id = ? AND n.read_at IS NULL AND t.deleted_at IS NULL`, memberID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationRead marks a notification of memberID as read and returns
+// it, or nil if the member has no such notification.
+func (s *Store) MarkNotificationRead(memberID, id string) (*models.Notification, error) {
+	if _, err := s.db.Exec("UPDATE notifications SET read_at = ? WHERE id = ? AND member_id = ? AND read_at IS NULL",
+		time.Now(), id, memberID); err != nil {
+		return nil, err
+	}
+	var n models.Notification
+	err := s.db.QueryRow("SELECT "+notificationColumns+" FROM notifications n WHERE n.id = ? AND n.member_id = ?", id, memberID).
+		Scan(&n.ID, &n.MemberID, &n.TicketID, &n.TicketKey, &n.Kind, &n.Message, &n.Actor, &n.CreatedAt, &n.ReadAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a member as
+// read and returns how many there were.
+func (s *Store) MarkAllNotificationsRead(memberID string) (int, error) {
+	res, err := s.db.Exec("UPDATE notifications SET read_at = ? WHERE member_id = ? AND read_at IS NULL", time.Now(), memberID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
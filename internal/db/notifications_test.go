@@ -0,0 +1,157 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// mustMember creates a member, failing the test on error.
+func mustMember(t *testing.T, s *Store, name string) *models.Member {
+	t.Helper()
+	m, err := s.CreateMember(models.CreateMemberRequest{Name: name, Handle: name})
+	if err != nil {
+		t.Fatalf("creating member %q: %v", name, err)
+	}
+	return m
+}
+
+// inbox returns the kinds of a member's notifications, newest first.
+func inbox(t *testing.T, s *Store, memberID string) []string {
+	t.Helper()
+	notifications, err := s.ListNotifications(models.NotificationFilter{MemberID: memberID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make([]string, len(notifications))
+	for i, n := range notifications {
+		kinds[i] = n.Kind + " " + n.TicketKey
+	}
+	return kinds
+}
+
+func TestNotifyUnblocked(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	mustMember(t, s, "alice")
+	carol := mustMember(t, s, "carol")
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	first := mustTicket(t, alice, models.CreateTicketRequest{ProjectID: p.ID, Title: "First blocker"})
+	second := mustTicket(t, alice, models.CreateTicketRequest{ProjectID: p.ID, Title: "Second blocker"})
+	blocked := mustTicket(t, alice, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocked",
+		BlockedBy: []string{first.ID, second.ID}})
+	if _, err := s.WatchTicket(blocked.ID, models.WatchRequest{MemberID: carol.ID}); err != nil {
+		t.Fatal(err)
+	}
+	move := func(ticket *models.Ticket, status string) {
+		t.Helper()
+		if _, err := alice.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	move(first, "done")
+	if got := inbox(t, s, carol.ID); len(got) != 0 {
+		t.Errorf("after the first blocker is done carol has %v, want nothing", got)
+	}
+	move(second, "in_progress")
+	move(second, "done")
+	if got := inbox(t, s, carol.ID); len(got) != 1 || got[0] != "unblocked AUTH-3" {
+		t.Errorf("after the last blocker is done carol has %v, want [unblocked AUTH-3]", got)
+	}
+
+	// Closing a reopened blocker is news again.
+	move(first, "todo")
+	move(first, "done")
+	if got := inbox(t, s, carol.ID); len(got) != 2 {
+		t.Errorf("after closing a reopened blocker carol has %v, want a second unblocked", got)
+	}
+}
+
+func TestCommentNotifications(t *testing.T) {
+	s := newTestStore(t)
+	alice := mustMember(t, s, "alice")
+	bob := mustMember(t, s, "bob")
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	ticket := mustTicket(t, s.WithActor(models.Actor{Name: "alice", Source: models.SourceWeb}),
+		models.CreateTicketRequest{ProjectID: p.ID, Title: "Login"})
+
+	// Commenting makes bob a watcher and tells alice, who created the
+	// ticket; nobody hears about their own comment.
+	if _, err := s.AddComment(ticket.ID, models.CreateCommentRequest{Author: "bob", Body: "Can't reproduce"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddComment(ticket.ID, models.CreateCommentRequest{Author: "alice", Body: "Try Safari"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := inbox(t, s, alice.ID); len(got) != 1 || got[0] != "comment AUTH-1" {
+		t.Errorf("alice has %v, want bob's comment", got)
+	}
+	if got := inbox(t, s, bob.ID); len(got) != 1 || got[0] != "comment AUTH-1" {
+		t.Errorf("bob has %v, want alice's comment", got)
+	}
+	watchers, err := s.ListWatchers(ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watchers) != 2 || watchers[0].Handle != "alice" || watchers[1].Handle != "bob" {
+		t.Errorf("watchers = %v, want alice then bob", watchers)
+	}
+
+	notifications, err := s.ListNotifications(models.NotificationFilter{MemberID: bob.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only bob can mark the notification read.
+	if n, err := s.MarkNotificationRead(alice.ID, notifications[0].ID); err != nil || n != nil {
+		t.Errorf("alice marking bob's notification read: got %v, %v, want nil", n, err)
+	}
+	if n, err := s.UnreadNotificationCount(bob.ID); err != nil || n != 1 {
+		t.Errorf("bob's unread count after alice's attempt = %d, %v, want 1", n, err)
+	}
+	if _, err := s.MarkNotificationRead(bob.ID, notifications[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.UnreadNotificationCount(bob.ID); err != nil || n != 0 {
+		t.Errorf("bob's unread count = %d, %v, want 0", n, err)
+	}
+	if n, err := s.UnreadNotificationCount(alice.ID); err != nil || n != 1 {
+		t.Errorf("alice's unread count = %d, %v, want 1", n, err)
+	}
+}
+
+func TestNotifyBlockerGone(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	carol := mustMember(t, s, "carol")
+	auth := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	infra := mustProject(t, s, models.CreateProjectRequest{Name: "Infra", Prefix: "INFRA"})
+	trashed := mustTicket(t, s, models.CreateTicketRequest{ProjectID: auth.ID, Title: "Trashed blocker"})
+	unlinked := mustTicket(t, s, models.CreateTicketRequest{ProjectID: auth.ID, Title: "Unlinked blocker"})
+	elsewhere := mustTicket(t, s, models.CreateTicketRequest{ProjectID: infra.ID, Title: "Blocker in another project"})
+	blocked := make([]*models.Ticket, 3)
+	for i, blocker := range []*models.Ticket{trashed, unlinked, elsewhere} {
+		blocked[i] = mustTicket(t, s, models.CreateTicketRequest{ProjectID: auth.ID, Title: "Blocked",
+			BlockedBy: []string{blocker.ID}})
+		if _, err := s.WatchTicket(blocked[i].ID, models.WatchRequest{MemberID: carol.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := alice.DeleteTicket(trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.RemoveRelation(blocked[1].ID, models.RelationBlockedBy, unlinked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.DeleteProject(infra.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got := inbox(t, s, carol.ID)
+	want := []string{"unblocked " + blocked[2].DisplayKey(), "unblocked " + blocked[1].DisplayKey(), "unblocked " + blocked[0].DisplayKey()}
+	if !slices.Equal(got, want) {
+		t.Errorf("carol has %v, want %v", got, want)
+	}
+}
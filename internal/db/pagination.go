@@ -0,0 +1,125 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ticketSort is a resolved sort key: the SQL expression rows are ordered by
+// and the direction. Ties are broken by ticket ID in the same direction, so
+// the pair (expr, id) identifies a position for keyset pagination.
+type ticketSort struct {
+	key   string
+	expr  string
+	order string
+}
+
+// ticketSortOrders gives each sort key its natural direction: board order,
+// newest first, soonest due, most urgent, lowest number, best match.
+var ticketSortOrders = map[string]string{
+	models.SortPosition:  "asc",
+	models.SortCreated:   "desc",
+	models.SortUpdated:   "desc",
+	models.SortDueDate:   "asc",
+	models.SortPriority:  "desc",
+	models.SortNumber:    "asc",
+	models.SortRelevance: "asc",
+}
+
+// resolveTicketSort validates the sort and order of filter and fills in the
+// defaults. Dates are cast to text so the driver hands the stored value back
+// for the cursor instead of parsing it.
+func resolveTicketSort(filter models.TicketFilter) (ticketSort, error) {
+	v := &ValidationError{}
+	sort := ticketSort{key: filter.Sort, order: strings.ToLower(filter.Order)}
+	if sort.key == "" {
+		sort.key = models.SortPosition
+		if filter.Query != "" {
+			sort.key = models.SortRelevance
+		}
+	}
+	if _, ok := ticketSortOrders[sort.key]; !ok {
+		v.add("sort", "must be one of %s, got %q", strings.Join(models.TicketSorts(), ", "), sort.key)
+		return sort, v
+	}
+	if sort.key == models.SortRelevance && filter.Query == "" {
+		v.add("sort", "relevance needs a search query")
+	}
+	if sort.order == "" {
+		sort.order = ticketSortOrders[sort.key]
+	} else if !slices.Contains([]string{"asc", "desc"}, sort.order) {
+		v.add("order", "must be asc or desc, got %q", filter.Order)
+	}
+	if err := v.err(); err != nil {
+		return sort, err
+	}
+
+	switch sort.key {
+	case models.SortPosition:
+		sort.expr = "t.position"
+	case models.SortCreated:
+		sort.expr = "CAST(t.created_at AS TEXT)"
+	case models.SortUpdated:
+		sort.expr = "CAST(t.updated_at AS TEXT)"
+	case models.SortDueDate:
+		// Tickets without a due date come last in either direction.
+		if sort.order == "asc" {
+			sort.expr = "COALESCE(CAST(t.due_date AS TEXT), '9999')"
+		} else {
+			sort.expr = "COALESCE(CAST(t.due_date AS TEXT), '')"
+		}
+	case models.SortPriority:
+		sort.expr = priorityRankExpr
+	case models.SortNumber:
+		sort.expr = "t.number"
+	case models.SortRelevance:
+		sort.expr = searchRankExpr
+	}
+	return sort, nil
+}
+
+// after returns the condition for rows that come after a cursor. It takes
+// the cursor's key twice and then its ticket ID.
+func (s ticketSort) after() string {
+	op := ">"
+	if s.order == "desc" {
+		op = "<"
+	}
+	return "(" + s.expr + " " + op + " ? OR (" + s.expr + " = ? AND t.id " + op + " ?))"
+}
+
+// ticketCursor is the opaque position handed to clients. It remembers the
+// sort it was issued for so it cannot be replayed against another order.
+type ticketCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Key   any    `json:"k"`
+	ID    string `json:"id"`
+}
+
+func encodeTicketCursor(sort ticketSort, key any, id string) string {
+	data, _ := json.Marshal(ticketCursor{Sort: sort.key, Order: sort.order, Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTicketCursor(cursor string, sort ticketSort) (key any, id string, err error) {
+	v := &ValidationError{}
+	var c ticketCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.ID == "" {
+		v.add("cursor", "is not a cursor returned by a ticket listing")
+		return nil, "", v
+	}
+	if c.Sort != sort.key || c.Order != sort.order {
+		v.add("cursor", "was issued for sort %s %s, not %s %s", c.Sort, c.Order, sort.key, sort.order)
+		return nil, "", v
+	}
+	return c.Key, c.ID, nil
+}
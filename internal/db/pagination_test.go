@@ -0,0 +1,92 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestListTicketPage(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	// Few distinct priorities and due dates, so most sort keys tie and the
+	// ID has to break them.
+	priorities := []string{"high", "low", "high", "medium", "low", "high", "low"}
+	for i, priority := range priorities {
+		req := models.CreateTicketRequest{ProjectID: p.ID, Title: fmt.Sprintf("Ticket %d", i+1), Priority: priority}
+		if i%3 == 0 {
+			due := fmt.Sprintf("2026-0%d-01", 3-i/3)
+			req.DueDate = &due
+		}
+		mustTicket(t, s, req)
+	}
+
+	for _, sort := range []struct{ key, order string }{
+		{models.SortPosition, ""},
+		{models.SortNumber, "desc"},
+		{models.SortPriority, ""},
+		{models.SortPriority, "asc"},
+		{models.SortDueDate, ""},
+		{models.SortDueDate, "desc"},
+		{models.SortCreated, ""},
+	} {
+		filter := models.TicketFilter{ProjectID: p.ID, Sort: sort.key, Order: sort.order}
+		all, err := s.ListTickets(filter)
+		if err != nil {
+			t.Fatalf("%s %s: %v", sort.key, sort.order, err)
+		}
+
+		var paged []models.Ticket
+		filter.Limit = 3
+		for pages := 0; ; pages++ {
+			if pages == len(priorities) {
+				t.Fatalf("%s %s: paging does not end", sort.key, sort.order)
+			}
+			page, err := s.ListTicketPage(filter)
+			if err != nil {
+				t.Fatalf("%s %s: %v", sort.key, sort.order, err)
+			}
+			if page.Total != len(priorities) {
+				t.Errorf("%s %s: total = %d, want %d", sort.key, sort.order, page.Total, len(priorities))
+			}
+			paged = append(paged, page.Tickets...)
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		if got, want := displayKeys(paged), displayKeys(all); !slices.Equal(got, want) {
+			t.Errorf("%s %s: pages hold %v, want %v", sort.key, sort.order, got, want)
+		}
+	}
+}
+
+func TestListTicketPageCursorErrors(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	for i := range 3 {
+		mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: fmt.Sprintf("Ticket %d", i+1)})
+	}
+	page, err := s.ListTicketPage(models.TicketFilter{ProjectID: p.ID, Sort: models.SortNumber, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("first page has no next cursor")
+	}
+
+	for _, filter := range []models.TicketFilter{
+		{ProjectID: p.ID, Sort: models.SortNumber, Order: "desc", Cursor: page.NextCursor},
+		{ProjectID: p.ID, Sort: models.SortCreated, Cursor: page.NextCursor},
+		{ProjectID: p.ID, Sort: models.SortNumber, Cursor: "not-a-cursor"},
+	} {
+		_, err := s.ListTicketPage(filter)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "cursor" {
+			t.Errorf("sort %s %s, cursor %q: got %v, want a cursor validation error", filter.Sort, filter.Order, filter.Cursor, err)
+		}
+	}
+}
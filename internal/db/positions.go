@@ -0,0 +1,163 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// Tickets in a column are spaced positionGap apart. Placing a ticket between
+// two others takes the midpoint, and once neighbours are closer than
+// minPositionGap the whole column is renumbered.
+const (
+	positionGap    = 1000
+	minPositionGap = 1e-3
+)
+
+// moveAnchors loads the tickets req asks t to be placed before and after,
+// filling in req.Status from them when it is empty.
+func (s *Store) moveAnchors(t *models.Ticket, req *models.MoveTicketRequest) (before, after *models.Ticket, err error) {
+	v := &ValidationError{}
+	if req.Position != nil && (req.Before != "" || req.After != "") {
+		v.add("position", "cannot be combined with before or after")
+	}
+	anchor := func(field, id string) (*models.Ticket, error) {
+		if id == "" {
+			return nil, nil
+		}
+		if id == t.ID {
+			v.add(field, "cannot be the ticket being moved")
+			return nil, nil
+		}
+		a, err := s.GetTicket(id)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil || a.ProjectID != t.ProjectID {
+			v.add(field, "ticket %s not found in the project", id)
+			return nil, nil
+		}
+		if req.Status == "" {
+			req.Status = a.Status
+		}
+		if a.Status != req.Status {
+			v.add(field, "%s is in %s, not %s", a.DisplayKey(), a.Status, req.Status)
+		}
+		return a, nil
+	}
+	if after, err = anchor("after", req.After); err != nil {
+		return nil, nil, err
+	}
+	if before, err = anchor("before", req.Before); err != nil {
+		return nil, nil, err
+	}
+	if before != nil && after != nil && !columnLess(after, before) {
+		v.add("before", "%s comes before %s on the board", before.DisplayKey(), after.DisplayKey())
+	}
+	if req.Status == "" && req.Before == "" && req.After == "" {
+		v.add("status", "is required")
+	}
+	return before, after, v.err()
+}
+
+// columnLess reports whether a comes before b in a column, which is ordered
+// by position and then ID.
+func columnLess(a, b *models.Ticket) bool {
+	return a.Position < b.Position || (a.Position == b.Position && a.ID < b.ID)
+}
+
+// movePosition works out t's position in the status column of its project:
+// after after and before before when either is set, or at the bottom. New
+// tickets and tickets changing status without a position go at the bottom.
+// Anchoring between two tickets that are too close renumbers the column.
+func movePosition(e querier, t *models.Ticket, status string, before, after *models.Ticket) (float64, error) {
+	if before == nil && after == nil {
+		var position float64
+		err := e.QueryRow("SELECT COALESCE(MAX(position), 0) + ? FROM tickets WHERE project_id = ? AND status = ? AND id != ? AND deleted_at IS NULL",
+			positionGap, t.ProjectID, status, t.ID).Scan(&position)
+		return position, err
+	}
+
+	lo, hi := after, before
+	var err error
+	if lo == nil {
+		if lo, err = columnNeighbour(e, t, before, "<", "DESC"); err != nil {
+			return 0, err
+		}
+	}
+	if hi == nil {
+		if hi, err = columnNeighbour(e, t, after, ">", "ASC"); err != nil {
+			return 0, err
+		}
+	}
+	switch {
+	case lo == nil:
+		return hi.Position - positionGap, nil
+	case hi == nil:
+		return lo.Position + positionGap, nil
+	case hi.Position-lo.Position >= minPositionGap:
+		return (lo.Position + hi.Position) / 2, nil
+	}
+	return rebalanceColumn(e, t, status, lo.ID)
+}
+
+// columnNeighbour returns the ticket next to anchor in its column, other than
+// t, on the side op and order pick, or nil at the end of the column.
+func columnNeighbour(e querier, t, anchor *models.Ticket, op, order string) (*models.Ticket, error) {
+	rows, err := e.Query(`SELECT id, position FROM tickets
+		WHERE project_id = ? AND status = ? AND id != ? AND deleted_at IS NULL
+		AND (position `+op+` ? OR (position = ? AND id `+op+` ?))
+		ORDER BY position `+order+`, id `+order+` LIMIT 1`,
+		t.ProjectID, anchor.Status, t.ID, anchor.Position, anchor.Position, anchor.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	n := &models.Ticket{}
+	if err := rows.Scan(&n.ID, &n.Position); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// rebalanceColumn spaces the tickets of t's project in status positionGap
+// apart again, keeping their order, and returns the position for t right
+// after the ticket with ID afterID. It leaves writing t's position to the
+// caller.
+func rebalanceColumn(e querier, t *models.Ticket, status, afterID string) (float64, error) {
+	rows, err := e.Query(`SELECT id FROM tickets WHERE project_id = ? AND status = ? AND id != ? AND deleted_at IS NULL
+		ORDER BY position, id`, t.ProjectID, status, t.ID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var position float64
+	next := float64(positionGap)
+	for _, id := range ids {
+		if _, err := e.Exec("UPDATE tickets SET position = ? WHERE id = ?", next, id); err != nil {
+			return 0, fmt.Errorf("renumbering %s column: %w", status, err)
+		}
+		next += positionGap
+		if id == afterID {
+			position = next
+			next += positionGap
+		}
+	}
+	return position, nil
+}
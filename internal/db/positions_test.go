@@ -0,0 +1,87 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestRelativeMoves(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	tickets := make([]*models.Ticket, 4)
+	for i := range tickets {
+		tickets[i] = mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket"})
+	}
+	column := func() []models.Ticket {
+		t.Helper()
+		list, err := s.ListTickets(models.TicketFilter{ProjectID: p.ID, Status: "todo", Sort: models.SortPosition})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	// Slipping AUTH-3 and AUTH-4 in turn right after AUTH-1 halves the gap
+	// each time, well past what a float can tell apart without renumbering.
+	for i := range 60 {
+		moving := tickets[2+i%2]
+		if _, err := s.MoveTicket(moving.ID, models.MoveTicketRequest{After: tickets[0].ID}); err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+	}
+	col := column()
+	if got, want := displayKeys(col), []string{"AUTH-1", "AUTH-4", "AUTH-3", "AUTH-2"}; !slices.Equal(got, want) {
+		t.Fatalf("column is %v, want %v", got, want)
+	}
+	for i := 1; i < len(col); i++ {
+		if gap := col[i].Position - col[i-1].Position; gap < minPositionGap {
+			t.Errorf("%s and %s are %g apart, want at least %g", col[i-1].DisplayKey(), col[i].DisplayKey(), gap, minPositionGap)
+		}
+	}
+
+	// Before and after together must agree with the board.
+	if _, err := s.MoveTicket(tickets[1].ID, models.MoveTicketRequest{Before: tickets[0].ID, After: tickets[3].ID}); err == nil {
+		t.Error("moving between tickets in the wrong order succeeded")
+	}
+	if _, err := s.MoveTicket(tickets[1].ID, models.MoveTicketRequest{Before: tickets[3].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := displayKeys(column()), []string{"AUTH-1", "AUTH-2", "AUTH-4", "AUTH-3"}; !slices.Equal(got, want) {
+		t.Errorf("column is %v, want %v", got, want)
+	}
+}
+
+func TestNewPositionsAppend(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	a := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "A"})
+	b := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "B"})
+	if _, err := s.MoveTicket(b.ID, models.MoveTicketRequest{Before: a.ID}); err != nil {
+		t.Fatal(err)
+	}
+	c := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "C"})
+	column := func(status string) []string {
+		t.Helper()
+		list, err := s.ListTickets(models.TicketFilter{ProjectID: p.ID, Status: status, Sort: models.SortPosition})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return displayKeys(list)
+	}
+	if got, want := column("todo"), []string{"AUTH-2", "AUTH-1", "AUTH-3"}; !slices.Equal(got, want) {
+		t.Errorf("todo is %v, want %v", got, want)
+	}
+
+	// Changing status without a position goes to the end of the new column.
+	status := "in_progress"
+	for _, ticket := range []*models.Ticket{c, a} {
+		if _, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Status: &status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := column("in_progress"), []string{"AUTH-3", "AUTH-1"}; !slices.Equal(got, want) {
+		t.Errorf("in_progress is %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,304 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/schedule"
+)
+
+const recurrenceColumns = "id, project_id, rule, template, paused, next_run_at, last_run_at, created_at, updated_at"
+
+func scanRecurrence(row interface{ Scan(...any) error }) (*models.Recurrence, error) {
+	var r models.Recurrence
+	var template string
+	err := row.Scan(&r.ID, &r.ProjectID, &r.Rule, &template, &r.Paused, &r.NextRunAt, &r.LastRunAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(template), &r.Ticket); err != nil {
+		return nil, fmt.Errorf("decoding recurrence %s template: %w", r.ID, err)
+	}
+	r.Ticket.ProjectID = r.ProjectID
+	return &r, nil
+}
+
+// ListRecurrences returns recurrences in the order they next fire: all of
+// them, or only those of projectID when it is set.
+func (s *Store) ListRecurrences(projectID string) ([]models.Recurrence, error) {
+	q := "SELECT " + recurrenceColumns + " FROM recurrences WHERE " + inLiveProject("project_id")
+	args := []any{}
+	if projectID != "" {
+		q += " AND project_id = ?"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY paused, next_run_at, created_at"
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurrences []models.Recurrence
+	for rows.Next() {
+		r, err := scanRecurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		recurrences = append(recurrences, *r)
+	}
+	return recurrences, rows.Err()
+}
+
+func (s *Store) GetRecurrence(id string) (*models.Recurrence, error) {
+	r, err := scanRecurrence(s.db.QueryRow("SELECT "+recurrenceColumns+" FROM recurrences WHERE id = ? AND "+inLiveProject("project_id"), id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (s *Store) CreateRecurrence(req models.CreateRecurrenceRequest) (*models.Recurrence, error) {
+	now := time.Now()
+	r := &models.Recurrence{
+		ID:        newID(),
+		ProjectID: req.ProjectID,
+		Rule:      strings.TrimSpace(req.Rule),
+		Ticket:    req.Ticket,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.Ticket.ProjectID = r.ProjectID
+	sched, err := s.validateRecurrence(r)
+	if err != nil {
+		return nil, err
+	}
+	r.NextRunAt = sched.Next(now)
+
+	template, _ := json.Marshal(r.Ticket)
+	_, err = s.db.Exec("INSERT INTO recurrences ("+recurrenceColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		r.ID, r.ProjectID, r.Rule, string(template), r.Paused, r.NextRunAt, r.LastRunAt, r.CreatedAt, r.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting recurrence: %w", err)
+	}
+	return r, nil
+}
+
+func (s *Store) UpdateRecurrence(id string, req models.UpdateRecurrenceRequest) (*models.Recurrence, error) {
+	r, err := s.GetRecurrence(id)
+	if err != nil || r == nil {
+		return nil, err
+	}
+
+	reschedule := false
+	if req.Rule != nil && strings.TrimSpace(*req.Rule) != r.Rule {
+		r.Rule = strings.TrimSpace(*req.Rule)
+		reschedule = true
+	}
+	if req.Ticket != nil {
+		r.Ticket = *req.Ticket
+		r.Ticket.ProjectID = r.ProjectID
+	}
+	if req.Paused != nil {
+		reschedule = reschedule || (r.Paused && !*req.Paused)
+		r.Paused = *req.Paused
+	}
+	sched, err := s.validateRecurrence(r)
+	if err != nil {
+		return nil, err
+	}
+	r.UpdatedAt = time.Now()
+	if reschedule {
+		r.NextRunAt = sched.Next(r.UpdatedAt)
+	}
+
+	template, _ := json.Marshal(r.Ticket)
+	_, err = s.db.Exec("UPDATE recurrences SET rule=?, template=?, paused=?, next_run_at=?, updated_at=? WHERE id=?",
+		r.Rule, string(template), r.Paused, r.NextRunAt, r.UpdatedAt, r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("updating recurrence: %w", err)
+	}
+	return r, nil
+}
+
+// DeleteRecurrence stops a recurrence. Tickets it already created are kept.
+func (s *Store) DeleteRecurrence(id string) error {
+	_, err := s.db.Exec("DELETE FROM recurrences WHERE id = ?", id)
+	return err
+}
+
+// validateRecurrence checks the rule and that the ticket template would be
+// accepted by CreateTicket today, reporting template fields as ticket.<field>.
+func (s *Store) validateRecurrence(r *models.Recurrence) (*schedule.Schedule, error) {
+	v := &ValidationError{}
+	sched, err := schedule.Parse(r.Rule)
+	if err != nil {
+		v.add("rule", "%s", err)
+	} else if sched.Next(time.Now()).IsZero() {
+		v.add("rule", "never fires")
+	}
+
+	var prefix string
+	err = s.db.QueryRow("SELECT prefix FROM projects WHERE id = ? AND deleted_at IS NULL", r.ProjectID).Scan(&prefix)
+	if err == sql.ErrNoRows {
+		v.add("projectId", "project %s not found", r.ProjectID)
+		return nil, v
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tv := &ValidationError{}
+	t, _, err := s.expandTemplate(tv, recurrenceTicket(r, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == "" {
+		statuses, err := s.projectStatuses(r.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		t.Status = statuses[0].Status
+	}
+	if t.Priority == "" {
+		t.Priority = models.PriorityMedium
+	}
+	ticket := &models.Ticket{
+		ProjectID:   t.ProjectID,
+		TeamID:      t.TeamID,
+		AssigneeID:  t.AssigneeID,
+		SprintID:    optionalID(t.SprintID),
+		MilestoneID: optionalID(t.MilestoneID),
+		Estimate:    optionalEstimate(t.Estimate),
+		Title:       t.Title,
+		Status:      t.Status,
+		Priority:    t.Priority,
+	}
+	if t.DueDate != nil {
+		parseDueDate(tv, *t.DueDate)
+	}
+	if err := s.validateTicket(tv, nil, ticket, t.Labels, t.BlockedBy); err != nil {
+		return nil, err
+	}
+	if err := s.setFieldValues(tv, ticket, t.Fields); err != nil {
+		return nil, err
+	}
+	for _, f := range tv.Fields {
+		v.add("ticket."+f.Field, "%s", f.Message)
+	}
+	return sched, v.err()
+}
+
+// recurrenceTicket is the ticket request for the occurrence at t.
+func recurrenceTicket(r *models.Recurrence, t time.Time) models.CreateTicketRequest {
+	req := r.Ticket
+	req.ProjectID = r.ProjectID
+	req.Title = strings.ReplaceAll(req.Title, "{date}", t.Format("2006-01-02"))
+	return req
+}
+
+// RunRecurrences creates the tickets of every recurrence that was due by now.
+// A recurrence that missed several occurrences, say while the server was
+// down, gets one ticket for the latest of them.
+//
+// Each occurrence is claimed in recurrence_runs in the transaction that
+// creates its ticket, so running this again, or from two processes at once,
+// never duplicates a ticket, and a failed creation leaves the occurrence
+// unclaimed to be retried on the next run.
+// Errors for individual recurrences are joined and returned alongside the
+// runs that succeeded.
+func (s *Store) RunRecurrences(now time.Time) ([]models.RecurrenceRun, error) {
+	rows, err := s.db.Query("SELECT " + recurrenceColumns + " FROM recurrences WHERE paused = 0 AND " + inLiveProject("project_id"))
+	if err != nil {
+		return nil, err
+	}
+	var due []models.Recurrence
+	for rows.Next() {
+		r, err := scanRecurrence(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !r.NextRunAt.After(now) {
+			due = append(due, *r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	runs := []models.RecurrenceRun{}
+	var errs []error
+	for i := range due {
+		run, err := s.runRecurrence(&due[i], now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurrence %s: %w", due[i].ID, err))
+		} else if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, errors.Join(errs...)
+}
+
+// runRecurrence creates the ticket for the latest occurrence of r up to now
+// and schedules the next one. It returns nil if the occurrence was already
+// claimed.
+func (s *Store) runRecurrence(r *models.Recurrence, now time.Time) (*models.RecurrenceRun, error) {
+	sched, err := schedule.Parse(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+	occurrence := r.NextRunAt.In(now.Location())
+	for next := sched.Next(occurrence); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+		occurrence = next
+	}
+	key := occurrence.UTC().Format(time.RFC3339)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	claim, err := tx.Exec("INSERT OR IGNORE INTO recurrence_runs (recurrence_id, occurrence, created_at) VALUES (?, ?, ?)",
+		r.ID, key, now)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("claiming occurrence: %w", err)
+	}
+	claimed, err := claim.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var run *models.RecurrenceRun
+	if claimed == 1 {
+		t, err := s.inTx(tx).addTicket(tx, recurrenceTicket(r, occurrence), nil)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE recurrence_runs SET ticket_id = ? WHERE recurrence_id = ? AND occurrence = ?",
+			t.ID, r.ID, key); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		run = &models.RecurrenceRun{RecurrenceID: r.ID, Occurrence: occurrence, TicketID: t.ID, TicketKey: t.DisplayKey()}
+	}
+
+	_, err = tx.Exec("UPDATE recurrences SET next_run_at=?, last_run_at=? WHERE id=?", sched.Next(now), occurrence, r.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("scheduling next occurrence: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestRunRecurrences(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Ops", Prefix: "OPS"})
+	r, err := s.CreateRecurrence(models.CreateRecurrenceRequest{ProjectID: p.ID, Rule: "@daily",
+		Ticket: models.CreateTicketRequest{Title: "Rotate logs {date}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is due before the first occurrence.
+	runs, err := s.RunRecurrences(r.NextRunAt.Add(-time.Minute))
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("before the first occurrence: got %v, %v, want no runs", runs, err)
+	}
+
+	// Three days later only the latest missed occurrence gets a ticket.
+	now := r.NextRunAt.AddDate(0, 0, 2).Add(time.Hour)
+	runs, err = s.RunRecurrences(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := r.NextRunAt.AddDate(0, 0, 2)
+	if len(runs) != 1 || !runs[0].Occurrence.Equal(latest) {
+		t.Fatalf("got runs %v, want one for %s", runs, latest)
+	}
+	ticket := mustGetTicket(t, s, runs[0].TicketID)
+	if want := "Rotate logs " + latest.Format("2006-01-02"); ticket.Title != want {
+		t.Errorf("ticket title = %q, want %q", ticket.Title, want)
+	}
+
+	// Running again, even as if the schedule had not advanced, creates
+	// nothing new.
+	if _, err := s.db.Exec("UPDATE recurrences SET next_run_at = ? WHERE id = ?", r.NextRunAt, r.ID); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if runs, err = s.RunRecurrences(now); err != nil || len(runs) != 0 {
+			t.Errorf("second run: got %v, %v, want no runs", runs, err)
+		}
+	}
+	tickets, err := s.ListTickets(models.TicketFilter{ProjectID: p.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 1 {
+		t.Errorf("project has %d tickets, want 1", len(tickets))
+	}
+
+	r, err = s.GetRecurrence(r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.NextRunAt.After(now) {
+		t.Errorf("next run at %s, want after %s", r.NextRunAt, now)
+	}
+}
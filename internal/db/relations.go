@@ -0,0 +1,224 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+var ErrInvalidRelation = errors.New("invalid relation")
+
+// ListRelations returns every link touching a ticket, with links stored on
+// the other ticket reported under their inverse type.
+func (s *Store) ListRelations(ticketID string) ([]models.TicketRelation, error) {
+	rows, err := s.db.Query(
+		`SELECT r.type, 0, t.id, COALESCE(p.prefix, ''), t.number, t.title, t.status
+		FROM ticket_relations r JOIN tickets t ON t.id = r.related_id LEFT JOIN projects p ON p.id = t.project_id
+		WHERE r.ticket_id = ? AND t.deleted_at IS NULL
+		UNION ALL
+		SELECT r.type, 1, t.id, COALESCE(p.prefix, ''), t.number, t.title, t.status
+		FROM ticket_relations r JOIN tickets t ON t.id = r.ticket_id LEFT JOIN projects p ON p.id = t.project_id
+		WHERE r.related_id = ? AND t.deleted_at IS NULL`,
+		ticketID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []models.TicketRelation
+	for rows.Next() {
+		var rel models.TicketRelation
+		var inverse bool
+		var linked models.Ticket
+		if err := rows.Scan(&rel.Type, &inverse, &rel.TicketID, &linked.ProjectPrefix, &linked.Number, &rel.Title, &rel.Status); err != nil {
+			return nil, err
+		}
+		if inverse {
+			rel.Type = models.InverseRelation(rel.Type)
+		}
+		rel.TicketKey = linked.DisplayKey()
+		relations = append(relations, rel)
+	}
+	return relations, rows.Err()
+}
+
+// AddRelation links ticketID to req.TicketID. Inverse types such as "blocks"
+// are stored from the other side. It returns nil if ticketID does not exist.
+func (s *Store) AddRelation(ticketID string, req models.CreateRelationRequest) (*models.Ticket, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	t, err := ts.GetTicket(ticketID)
+	if err != nil || t == nil {
+		tx.Rollback()
+		return nil, err
+	}
+	from, to, stored, err := ts.resolveRelation(ticketID, req.Type, req.TicketID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if stored == models.RelationBlockedBy {
+		if err := ts.checkBlockers(from, []string{to}); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// relates_to is symmetric, so an existing link in either direction counts.
+	if stored == models.RelationRelatesTo {
+		var exists int
+		if err := tx.QueryRow(
+			"SELECT COUNT(*) FROM ticket_relations WHERE type = ? AND ticket_id = ? AND related_id = ?",
+			stored, to, from).Scan(&exists); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if exists > 0 {
+			tx.Rollback()
+			return t, nil
+		}
+	}
+
+	res, err := tx.Exec("INSERT OR IGNORE INTO ticket_relations (ticket_id, related_id, type) VALUES (?, ?, ?)", from, to, stored)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		change := fieldChange{"relations", "", req.Type + " " + ts.ticketKey(req.TicketID)}
+		if err := s.recordTicketEvent(tx, t, models.EventUpdated, []fieldChange{change}); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("recording activity: %w", err)
+		}
+		if stored == models.RelationBlockedBy {
+			summary := "link " + t.DisplayKey() + " " + change.newValue
+			if err := ts.recordBlockerChange(tx, from, to, summary, true); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetTicket(ticketID)
+}
+
+// RemoveRelation deletes a link added with AddRelation. It returns nil if
+// ticketID does not exist.
+func (s *Store) RemoveRelation(ticketID, relType, relatedID string) (*models.Ticket, error) {
+	stored, swap, ok := models.StoredRelation(relType)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidRelation, relType)
+	}
+	from, to := ticketID, relatedID
+	if swap {
+		from, to = to, from
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	t, err := ts.GetTicket(ticketID)
+	if err != nil || t == nil {
+		tx.Rollback()
+		return nil, err
+	}
+	query := "DELETE FROM ticket_relations WHERE type = ? AND ticket_id = ? AND related_id = ?"
+	args := []any{stored, from, to}
+	if stored == models.RelationRelatesTo {
+		query = "DELETE FROM ticket_relations WHERE type = ? AND ((ticket_id = ? AND related_id = ?) OR (ticket_id = ? AND related_id = ?))"
+		args = append(args, to, from)
+	}
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		change := fieldChange{"relations", relType + " " + ts.ticketKey(relatedID), ""}
+		if err := s.recordTicketEvent(tx, t, models.EventUpdated, []fieldChange{change}); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("recording activity: %w", err)
+		}
+		if stored == models.RelationBlockedBy {
+			summary := "unlink " + t.DisplayKey() + " " + change.oldValue
+			if err := ts.recordBlockerChange(tx, from, to, summary, false); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := s.notifyUnlinked(tx, from, []string{to}); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetTicket(ticketID)
+}
+
+// recordBlockerChange records on the undo stack that blocker was linked to
+// ticket id, or unlinked from it when linked is false. It reads the ticket as
+// it is after the change, so run it on a store in the write transaction.
+func (s *Store) recordBlockerChange(e execer, id, blocker, summary string, linked bool) error {
+	t, err := s.GetTicket(id)
+	if err != nil || t == nil {
+		return err
+	}
+	after := newTicketState(t, labelIDs(t.Labels), t.BlockedBy)
+	before := *after
+	if linked {
+		before.BlockedBy = sortedIDs(slices.DeleteFunc(slices.Clone(after.BlockedBy), func(b string) bool { return b == blocker }))
+	} else {
+		before.BlockedBy = sortedIDs(append(after.BlockedBy, blocker))
+	}
+	return s.recordChange(e, models.ChangeTicket, id, summary, &before, after)
+}
+
+// resolveRelation validates a relation request and returns the row to store.
+func (s *Store) resolveRelation(ticketID, relType, relatedID string) (from, to, stored string, err error) {
+	stored, swap, ok := models.StoredRelation(relType)
+	if !ok {
+		return "", "", "", fmt.Errorf("%w: unknown type %q", ErrInvalidRelation, relType)
+	}
+	if relatedID == "" {
+		return "", "", "", fmt.Errorf("%w: ticketId is required", ErrInvalidRelation)
+	}
+	if relatedID == ticketID {
+		return "", "", "", fmt.Errorf("%w: a ticket cannot be linked to itself", ErrInvalidRelation)
+	}
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tickets WHERE id = ? AND deleted_at IS NULL", relatedID).Scan(&exists); err != nil {
+		return "", "", "", err
+	}
+	if exists == 0 {
+		return "", "", "", fmt.Errorf("%w: ticket %s not found", ErrInvalidRelation, relatedID)
+	}
+
+	from, to = ticketID, relatedID
+	if swap {
+		from, to = to, from
+	}
+	return from, to, stored, nil
+}
+
+// ticketKey returns a ticket's display key, falling back to its ID.
+func (s *Store) ticketKey(id string) string {
+	var t models.Ticket
+	err := s.db.QueryRow(
+		"SELECT COALESCE(p.prefix, ''), t.number FROM tickets t LEFT JOIN projects p ON p.id = t.project_id WHERE t.id = ?", id,
+	).Scan(&t.ProjectPrefix, &t.Number)
+	if err != nil {
+		return id
+	}
+	return t.DisplayKey()
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchSnippetExpr highlights matches in whichever indexed column matched
+// best. Markers are markdown bold so they render in the UI and read fine in a
+// terminal.
+const searchSnippetExpr = `snippet(ticket_search, -1, '**', '**', '…', 12)`
+
+// searchRankExpr orders matches by BM25, weighting title over description
+// over subtasks and comments. The first weight is the unindexed ticket_id.
+const searchRankExpr = `bm25(ticket_search, 0, 10.0, 4.0, 2.0, 1.0)`
+
+// ftsQuery turns free text into an FTS5 query that cannot hit a syntax error:
+// every word is quoted and prefix-matched, and all words must match.
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,328 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ErrSprintClosed is returned when closing a sprint that is already closed.
+var ErrSprintClosed = errors.New("sprint is already closed")
+
+const sprintColumns = `s.id, s.project_id, s.name, s.goal, s.start_date, s.end_date, s.closed_at, s.created_at, s.updated_at,
+	(SELECT COUNT(*) FROM tickets t WHERE t.sprint_id = s.id AND t.deleted_at IS NULL),
+	(SELECT COUNT(*) FROM tickets t WHERE t.sprint_id = s.id AND t.deleted_at IS NULL AND ` + ticketIsDone + `)`
+
+// sprintInLiveProject matches cross-project sprints and those of projects that
+// are not in the trash.
+var sprintInLiveProject = "(s.project_id IS NULL OR " + inLiveProject("s.project_id") + ")"
+
+func scanSprint(row interface{ Scan(...any) error }) (*models.Sprint, error) {
+	var sp models.Sprint
+	err := row.Scan(&sp.ID, &sp.ProjectID, &sp.Name, &sp.Goal, &sp.StartDate, &sp.EndDate, &sp.ClosedAt,
+		&sp.CreatedAt, &sp.UpdatedAt, &sp.TicketCount, &sp.DoneCount)
+	if err != nil {
+		return nil, err
+	}
+	sp.Status = sprintStatus(&sp)
+	return &sp, nil
+}
+
+func sprintStatus(sp *models.Sprint) string {
+	switch {
+	case sp.ClosedAt != nil:
+		return models.SprintClosed
+	case sp.StartDate.Format("2006-01-02") > time.Now().Format("2006-01-02"):
+		return models.SprintPlanned
+	default:
+		return models.SprintActive
+	}
+}
+
+// ListSprints returns sprints in start order: all of them, or only those
+// of projectID and the cross-project ones when it is set.
+func (s *Store) ListSprints(projectID string) ([]models.Sprint, error) {
+	q := "SELECT " + sprintColumns + " FROM sprints s WHERE " + sprintInLiveProject
+	args := []any{}
+	if projectID != "" {
+		q += " AND (s.project_id IS NULL OR s.project_id = ?)"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY s.start_date, s.created_at"
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sprints []models.Sprint
+	for rows.Next() {
+		sp, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, *sp)
+	}
+	return sprints, rows.Err()
+}
+
+func (s *Store) GetSprint(id string) (*models.Sprint, error) {
+	sp, err := scanSprint(s.db.QueryRow("SELECT "+sprintColumns+" FROM sprints s WHERE s.id = ? AND "+sprintInLiveProject, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sp, err
+}
+
+// CurrentSprint returns the active sprint for projectID, preferring one of
+// the project's own over a cross-project sprint and the latest started over
+// older ones. With no projectID any active sprint qualifies. It returns nil
+// when nothing is active.
+func (s *Store) CurrentSprint(projectID string) (*models.Sprint, error) {
+	q := "SELECT " + sprintColumns + ` FROM sprints s
+		WHERE s.closed_at IS NULL AND substr(CAST(s.start_date AS TEXT), 1, 10) <= ? AND ` + sprintInLiveProject
+	args := []any{time.Now().Format("2006-01-02")}
+	if projectID != "" {
+		q += " AND (s.project_id IS NULL OR s.project_id = ?)"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY s.project_id IS NULL, s.start_date DESC, s.created_at DESC LIMIT 1"
+
+	sp, err := scanSprint(s.db.QueryRow(q, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sp, err
+}
+
+func (s *Store) CreateSprint(req models.CreateSprintRequest) (*models.Sprint, error) {
+	v := &ValidationError{}
+	sp := models.Sprint{
+		ID:        newID(),
+		ProjectID: optionalID(req.ProjectID),
+		Name:      strings.TrimSpace(req.Name),
+		Goal:      req.Goal,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if sp.ProjectID != nil {
+		if err := s.checkExists(v, "projectId", "projects", "project", *sp.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	if req.StartDate == "" {
+		v.add("startDate", "is required")
+	} else if start := parseDate(v, "startDate", req.StartDate); start != nil {
+		sp.StartDate = *start
+	}
+	if req.EndDate == "" {
+		v.add("endDate", "is required")
+	} else if end := parseDate(v, "endDate", req.EndDate); end != nil {
+		sp.EndDate = *end
+	}
+	validateSprint(v, &sp)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(`INSERT INTO sprints (id, project_id, name, goal, start_date, end_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sp.ID, sp.ProjectID, sp.Name, sp.Goal, sp.StartDate, sp.EndDate, sp.CreatedAt, sp.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting sprint: %w", err)
+	}
+	return s.GetSprint(sp.ID)
+}
+
+func (s *Store) UpdateSprint(id string, req models.UpdateSprintRequest) (*models.Sprint, error) {
+	sp, err := s.GetSprint(id)
+	if err != nil || sp == nil {
+		return nil, err
+	}
+	v := &ValidationError{}
+
+	if req.Name != nil {
+		sp.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Goal != nil {
+		sp.Goal = *req.Goal
+	}
+	if req.StartDate != nil {
+		if start := parseDate(v, "startDate", *req.StartDate); start != nil {
+			sp.StartDate = *start
+		}
+	}
+	if req.EndDate != nil {
+		if end := parseDate(v, "endDate", *req.EndDate); end != nil {
+			sp.EndDate = *end
+		}
+	}
+	validateSprint(v, sp)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec("UPDATE sprints SET name=?, goal=?, start_date=?, end_date=?, updated_at=? WHERE id=?",
+		sp.Name, sp.Goal, sp.StartDate, sp.EndDate, time.Now(), sp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("updating sprint: %w", err)
+	}
+	return s.GetSprint(id)
+}
+
+func validateSprint(v *ValidationError, sp *models.Sprint) {
+	if sp.Name == "" {
+		v.add("name", "must not be empty")
+	}
+	if !sp.StartDate.IsZero() && !sp.EndDate.IsZero() && sp.EndDate.Before(sp.StartDate) {
+		v.add("endDate", "must not be before the start date")
+	}
+}
+
+// DeleteSprint removes a sprint. Its tickets stay, unscheduled.
+func (s *Store) DeleteSprint(id string) error {
+	_, err := s.db.Exec("DELETE FROM sprints WHERE id = ?", id)
+	return err
+}
+
+// GetSprintBoard lays out a sprint's tickets in workflow columns: those of
+// the sprint's project, or of every project for a cross-project sprint. It
+// returns nil if the sprint does not exist.
+func (s *Store) GetSprintBoard(sprintID string) (*models.Board, error) {
+	sp, err := s.GetSprint(sprintID)
+	if err != nil || sp == nil {
+		return nil, err
+	}
+	return s.buildBoard(stringValue(sp.ProjectID), models.TicketFilter{SprintID: sp.ID})
+}
+
+// CloseSprint closes a sprint and moves its unfinished tickets into the next
+// sprint, recording the move in each ticket's history. It returns nil if the
+// sprint does not exist.
+func (s *Store) CloseSprint(id string, req models.CloseSprintRequest) (*models.CloseSprintResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	sp, err := ts.GetSprint(id)
+	if err != nil || sp == nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if sp.ClosedAt != nil {
+		tx.Rollback()
+		return nil, ErrSprintClosed
+	}
+
+	next := req.NextSprintID
+	if next != "" {
+		v := &ValidationError{}
+		if next == sp.ID {
+			v.add("nextSprintId", "must be a different sprint")
+		} else if err := ts.checkNextSprint(v, sp, next); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := v.err(); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	} else {
+		err := tx.QueryRow(`SELECT id FROM sprints
+			WHERE closed_at IS NULL AND id != ? AND project_id IS ? AND start_date >= ?
+			ORDER BY start_date, created_at LIMIT 1`, sp.ID, sp.ProjectID, sp.StartDate).Scan(&next)
+		if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return nil, fmt.Errorf("finding next sprint: %w", err)
+		}
+	}
+
+	open, err := ts.ListTickets(models.TicketFilter{SprintID: sp.ID, Expr: "is:open"})
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("listing unfinished tickets: %w", err)
+	}
+
+	now := time.Now()
+	result := &models.CloseSprintResult{NextSprintID: next, Moved: []string{}}
+	for i := range open {
+		t := &open[i]
+		if _, err := tx.Exec("UPDATE tickets SET sprint_id=?, updated_at=? WHERE id=?", optionalID(&next), now, t.ID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := s.recordTicketEvent(tx, t, models.EventUpdated, []fieldChange{{"sprintId", sp.ID, next}}); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("recording activity: %w", err)
+		}
+		before := newTicketState(t, labelIDs(t.Labels), t.BlockedBy)
+		after := *before
+		after.SprintID = optionalID(&next)
+		if err := s.recordChange(tx, models.ChangeTicket, t.ID, "close sprint "+sp.Name+": move "+t.DisplayKey(), before, &after); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		result.Moved = append(result.Moved, t.ID)
+	}
+	// Closing only an open sprint keeps two concurrent closes from both
+	// moving its tickets.
+	res, err := tx.Exec("UPDATE sprints SET closed_at=?, updated_at=? WHERE id=? AND closed_at IS NULL", now, now, sp.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return nil, ErrSprintClosed
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if result.Sprint, err = s.GetSprint(sp.ID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkNextSprint records a field error unless next can take every ticket of
+// sp: it must be open and cover sp's project, if any, or span projects.
+func (s *Store) checkNextSprint(v *ValidationError, sp *models.Sprint, next string) error {
+	n, err := s.GetSprint(next)
+	if err != nil {
+		return err
+	}
+	switch {
+	case n == nil:
+		v.add("nextSprintId", "sprint %s not found", next)
+	case n.ClosedAt != nil:
+		v.add("nextSprintId", "sprint %s is closed", n.Name)
+	case n.ProjectID != nil && stringValue(sp.ProjectID) != *n.ProjectID:
+		v.add("nextSprintId", "sprint %s belongs to another project", n.Name)
+	}
+	return nil
+}
+
+// checkSprint records a field error unless a ticket of projectID can be
+// scheduled into sprintID.
+func (s *Store) checkSprint(v *ValidationError, field, sprintID, projectID string) error {
+	sp, err := s.GetSprint(sprintID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sp == nil:
+		v.add(field, "sprint %s not found", sprintID)
+	case sp.ClosedAt != nil:
+		v.add(field, "sprint %s is closed", sp.Name)
+	case sp.ProjectID != nil && *sp.ProjectID != projectID:
+		v.add(field, "sprint %s belongs to another project", sp.Name)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestCloseSprint(t *testing.T) {
+	s := newTestStore(t).WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	sprints := make([]*models.Sprint, 2)
+	for i, dates := range [][2]string{{"2026-03-02", "2026-03-13"}, {"2026-03-16", "2026-03-27"}} {
+		sp, err := s.CreateSprint(models.CreateSprintRequest{ProjectID: &p.ID, Name: "Sprint", StartDate: dates[0], EndDate: dates[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sprints[i] = sp
+	}
+	open := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Open", SprintID: &sprints[0].ID})
+	done := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Done", Status: "done", SprintID: &sprints[0].ID})
+
+	// Unfinished work rolls over into the sprint that starts next.
+	result, err := s.CloseSprint(sprints[0].ID, models.CloseSprintRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NextSprintID != sprints[1].ID || !slices.Equal(result.Moved, []string{open.ID}) {
+		t.Errorf("close: moved %v into %s, want [%s] into %s", result.Moved, result.NextSprintID, open.ID, sprints[1].ID)
+	}
+	if got := stringValue(mustGetTicket(t, s, open.ID).SprintID); got != sprints[1].ID {
+		t.Errorf("AUTH-1 is in sprint %q, want the next sprint", got)
+	}
+	if got := stringValue(mustGetTicket(t, s, done.ID).SprintID); got != sprints[0].ID {
+		t.Errorf("AUTH-2 is in sprint %q, want the closed sprint", got)
+	}
+	// The move is on the undo stack, but going back into a closed sprint is
+	// refused.
+	var conflict *ConflictError
+	if _, err := s.Undo(); !errors.As(err, &conflict) {
+		t.Errorf("undoing the roll-over: got %v, want a conflict", err)
+	}
+
+	if _, err := s.CloseSprint(sprints[0].ID, models.CloseSprintRequest{}); !errors.Is(err, ErrSprintClosed) {
+		t.Errorf("closing twice: got %v, want ErrSprintClosed", err)
+	}
+}
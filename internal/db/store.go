@@ -3,29 +3,86 @@ package db
 import (
 	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/oklog/ulid/v2"
 	"github.com/tcarac/taskboard/internal/models"
 )
 
+// conn is what a Store runs its queries on: the database, or the transaction
+// of a write in progress.
+type conn interface {
+	querier
+	Begin() (*sql.Tx, error)
+}
+
 type Store struct {
-	db *sql.DB
+	db    conn
+	actor models.Actor
 }
 
 func NewStore(database *sql.DB) *Store {
 	return &Store{db: database}
 }
 
+// txConn runs a store's queries in a transaction, which cannot be nested.
+type txConn struct{ *sql.Tx }
+
+func (txConn) Begin() (*sql.Tx, error) {
+	return nil, errors.New("already in a transaction")
+}
+
+// inTx returns a copy of the store whose queries run in tx, so that a write
+// is validated against the same state it changes. With _txlock=immediate the
+// transaction holds the write lock from the start, so concurrent writes
+// cannot both pass a check that only one of them should.
+func (s *Store) inTx(tx *sql.Tx) *Store {
+	c := *s
+	c.db = txConn{tx}
+	return &c
+}
+
+// WithActor returns a copy of the store that attributes the changes it makes
+// to actor in the activity log.
+func (s *Store) WithActor(actor models.Actor) *Store {
+	c := *s
+	c.actor = actor
+	return &c
+}
+
 func (s *Store) ClearData() error {
 	tables := []string{
-		"ticket_dependencies",
+		"notifications",
+		"ticket_watchers",
+		"attachments",
+		"attachment_blobs",
+		"undo_log",
+		"wip_limits",
+		"ticket_field_values",
+		"custom_fields",
+		"recurrence_runs",
+		"recurrences",
+		"template_labels",
+		"ticket_templates",
+		"saved_views",
+		"ticket_events",
+		"comments",
+		"time_entries",
+		"ticket_relations",
 		"ticket_labels",
 		"subtasks",
 		"tickets",
+		"sprints",
+		"milestones",
 		"labels",
+		"team_members",
+		"members",
 		"teams",
+		"workflow_statuses",
 		"projects",
 	}
 
@@ -49,10 +106,10 @@ func newID() string {
 }
 
 func (s *Store) ListProjects(status string) ([]models.Project, error) {
-	query := "SELECT id, name, prefix, description, icon, color, status, created_at, updated_at FROM projects"
+	query := "SELECT id, name, prefix, description, icon, color, status, blocked_move_policy, estimate_unit, created_at, updated_at FROM projects WHERE deleted_at IS NULL"
 	args := []any{}
 	if status != "" {
-		query += " WHERE status = ?"
+		query += " AND status = ?"
 		args = append(args, status)
 	}
 	query += " ORDER BY created_at DESC"
@@ -66,7 +123,7 @@ func (s *Store) ListProjects(status string) ([]models.Project, error) {
 	var projects []models.Project
 	for rows.Next() {
 		var p models.Project
-		if err := rows.Scan(&p.ID, &p.Name, &p.Prefix, &p.Description, &p.Icon, &p.Color, &p.Status, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Prefix, &p.Description, &p.Icon, &p.Color, &p.Status, &p.BlockedMovePolicy, &p.EstimateUnit, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		projects = append(projects, p)
@@ -77,8 +134,8 @@ func (s *Store) ListProjects(status string) ([]models.Project, error) {
 func (s *Store) GetProject(id string) (*models.Project, error) {
 	var p models.Project
 	err := s.db.QueryRow(
-		"SELECT id, name, prefix, description, icon, color, status, created_at, updated_at FROM projects WHERE id = ?", id,
-	).Scan(&p.ID, &p.Name, &p.Prefix, &p.Description, &p.Icon, &p.Color, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+		"SELECT id, name, prefix, description, icon, color, status, blocked_move_policy, estimate_unit, created_at, updated_at FROM projects WHERE id = ? AND deleted_at IS NULL", id,
+	).Scan(&p.ID, &p.Name, &p.Prefix, &p.Description, &p.Icon, &p.Color, &p.Status, &p.BlockedMovePolicy, &p.EstimateUnit, &p.CreatedAt, &p.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -87,32 +144,83 @@ func (s *Store) GetProject(id string) (*models.Project, error) {
 
 func (s *Store) CreateProject(req models.CreateProjectRequest) (*models.Project, error) {
 	p := models.Project{
-		ID:          newID(),
-		Name:        req.Name,
-		Prefix:      req.Prefix,
-		Description: req.Description,
-		Icon:        req.Icon,
-		Color:       req.Color,
-		Status:      "active",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:                newID(),
+		Name:              req.Name,
+		Prefix:            req.Prefix,
+		Description:       req.Description,
+		Icon:              req.Icon,
+		Color:             req.Color,
+		Status:            "active",
+		BlockedMovePolicy: req.BlockedMovePolicy,
+		EstimateUnit:      req.EstimateUnit,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
 	}
 	if p.Color == "" {
 		p.Color = "#3B82F6"
 	}
+	if p.BlockedMovePolicy == "" {
+		p.BlockedMovePolicy = models.BlockedMoveAllow
+	}
+	if p.EstimateUnit == "" {
+		p.EstimateUnit = models.EstimatePoints
+	}
+	if err := validateBlockedMovePolicy(p.BlockedMovePolicy); err != nil {
+		return nil, err
+	}
+	if err := validateEstimateUnit(p.EstimateUnit); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	if err := insertProject(tx, &p); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertWorkflowStatuses(tx, p.ID, models.DefaultWorkflowStatuses()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.recordChange(tx, models.ChangeProject, p.ID, "create project "+p.Name, nil, newProjectState(&p)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &p, tx.Commit()
+}
 
-	_, err := s.db.Exec(
-		"INSERT INTO projects (id, name, prefix, description, icon, color, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		p.ID, p.Name, p.Prefix, p.Description, p.Icon, p.Color, p.Status, p.CreatedAt, p.UpdatedAt,
+func insertProject(e execer, p *models.Project) error {
+	_, err := e.Exec(
+		"INSERT INTO projects (id, name, prefix, description, icon, color, status, blocked_move_policy, estimate_unit, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		p.ID, p.Name, p.Prefix, p.Description, p.Icon, p.Color, p.Status, p.BlockedMovePolicy, p.EstimateUnit, p.CreatedAt, p.UpdatedAt,
 	)
-	return &p, err
+	return err
 }
 
 func (s *Store) UpdateProject(id string, req models.UpdateProjectRequest) (*models.Project, error) {
-	p, err := s.GetProject(id)
+	if req.BlockedMovePolicy != nil {
+		if err := validateBlockedMovePolicy(*req.BlockedMovePolicy); err != nil {
+			return nil, err
+		}
+	}
+	if req.EstimateUnit != nil {
+		if err := validateEstimateUnit(*req.EstimateUnit); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	p, err := s.inTx(tx).GetProject(id)
 	if err != nil || p == nil {
+		tx.Rollback()
 		return nil, err
 	}
+	before := newProjectState(p)
 
 	if req.Name != nil {
 		p.Name = *req.Name
@@ -132,22 +240,76 @@ func (s *Store) UpdateProject(id string, req models.UpdateProjectRequest) (*mode
 	if req.Status != nil {
 		p.Status = *req.Status
 	}
+	if req.BlockedMovePolicy != nil {
+		p.BlockedMovePolicy = *req.BlockedMovePolicy
+	}
+	if req.EstimateUnit != nil {
+		p.EstimateUnit = *req.EstimateUnit
+	}
 	p.UpdatedAt = time.Now()
 
-	_, err = s.db.Exec(
-		"UPDATE projects SET name=?, prefix=?, description=?, icon=?, color=?, status=?, updated_at=? WHERE id=?",
-		p.Name, p.Prefix, p.Description, p.Icon, p.Color, p.Status, p.UpdatedAt, p.ID,
+	_, err = tx.Exec(
+		"UPDATE projects SET name=?, prefix=?, description=?, icon=?, color=?, status=?, blocked_move_policy=?, estimate_unit=?, updated_at=? WHERE id=?",
+		p.Name, p.Prefix, p.Description, p.Icon, p.Color, p.Status, p.BlockedMovePolicy, p.EstimateUnit, p.UpdatedAt, p.ID,
 	)
-	return p, err
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.recordChange(tx, models.ChangeProject, p.ID, "update project "+p.Name, before, newProjectState(p)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return p, tx.Commit()
 }
 
+// DeleteProject moves a project and its tickets to the trash.
 func (s *Store) DeleteProject(id string) error {
-	_, err := s.db.Exec("DELETE FROM projects WHERE id = ?", id)
-	return err
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	p, err := s.inTx(tx).GetProject(id)
+	if err != nil || p == nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.trashProject(tx, id, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.recordChange(tx, models.ChangeProject, id, "delete project "+p.Name, newProjectState(p), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// trashProject moves a project and its live tickets to the trash, stamping
+// them with the same time so that restoring the project finds them, and
+// records each ticket's deletion, which tells the watchers of tickets
+// elsewhere that they blocked.
+func (s *Store) trashProject(e querier, id string, now time.Time) error {
+	if _, err := e.Exec("UPDATE projects SET deleted_at = ? WHERE id = ?", now, id); err != nil {
+		return err
+	}
+	if _, err := e.Exec("UPDATE tickets SET deleted_at = ? WHERE project_id = ? AND deleted_at IS NULL", now, id); err != nil {
+		return err
+	}
+	tickets, err := ticketRefs(e, "SELECT id FROM tickets WHERE project_id = ? AND deleted_at = ?", id, now)
+	if err != nil {
+		return err
+	}
+	for _, t := range tickets {
+		if err := s.recordTicketEvent(e, t, models.EventDeleted, nil); err != nil {
+			return fmt.Errorf("recording activity: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *Store) ListTeams() ([]models.Team, error) {
-	rows, err := s.db.Query("SELECT id, name, color, created_at FROM teams ORDER BY created_at DESC")
+	rows, err := s.db.Query("SELECT id, name, color, created_at FROM teams WHERE deleted_at IS NULL ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +328,7 @@ func (s *Store) ListTeams() ([]models.Team, error) {
 
 func (s *Store) GetTeam(id string) (*models.Team, error) {
 	var t models.Team
-	err := s.db.QueryRow("SELECT id, name, color, created_at FROM teams WHERE id = ?", id).
+	err := s.db.QueryRow("SELECT id, name, color, created_at FROM teams WHERE id = ? AND deleted_at IS NULL", id).
 		Scan(&t.ID, &t.Name, &t.Color, &t.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -207,80 +369,215 @@ func (s *Store) UpdateTeam(id string, req models.UpdateTeamRequest) (*models.Tea
 	return t, err
 }
 
+// DeleteTeam moves a team to the trash. Its tickets keep pointing at it, so
+// restoring it brings them back into the team.
 func (s *Store) DeleteTeam(id string) error {
-	_, err := s.db.Exec("DELETE FROM teams WHERE id = ?", id)
+	_, err := s.db.Exec("UPDATE teams SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
 	return err
 }
 
-func (s *Store) nextTicketNumber(projectID string) (int, error) {
+// nextTicketNumber reads the next free number inside tx, so that it is taken
+// by the same transaction that inserts the ticket. Trashed tickets keep
+// their numbers.
+func nextTicketNumber(tx *sql.Tx, projectID string) (int, error) {
 	var num int
-	err := s.db.QueryRow("SELECT COALESCE(MAX(number), 0) + 1 FROM tickets WHERE project_id = ?", projectID).Scan(&num)
+	err := tx.QueryRow("SELECT COALESCE(MAX(number), 0) + 1 FROM tickets WHERE project_id = ?", projectID).Scan(&num)
 	return num, err
 }
 
 func (s *Store) ListTickets(filter models.TicketFilter) ([]models.Ticket, error) {
-	query := `SELECT t.id, t.project_id, t.team_id, t.number, t.title, t.description,
-		t.status, t.priority, t.due_date, t.position, t.created_at, t.updated_at,
-		COALESCE(p.prefix, '') as project_prefix
-		FROM tickets t LEFT JOIN projects p ON t.project_id = p.id WHERE 1=1`
-	args := []any{}
+	tickets, _, err := s.listTickets(filter)
+	return tickets, err
+}
 
-	if filter.ProjectID != "" {
-		query += " AND t.project_id = ?"
-		args = append(args, filter.ProjectID)
+// ListTicketPage returns up to filter.Limit tickets after filter.Cursor, the
+// total number of tickets matching the filter, and the cursor of the next
+// page. With no limit every remaining ticket is returned.
+func (s *Store) ListTicketPage(filter models.TicketFilter) (*models.TicketPage, error) {
+	limit := filter.Limit
+	if limit > 0 {
+		filter.Limit = limit + 1
 	}
-	if filter.TeamID != "" {
-		query += " AND t.team_id = ?"
-		args = append(args, filter.TeamID)
+	tickets, keys, err := s.listTickets(filter)
+	if err != nil {
+		return nil, err
 	}
-	if filter.Status != "" {
-		query += " AND t.status = ?"
-		args = append(args, filter.Status)
+	total, err := s.countTickets(filter)
+	if err != nil {
+		return nil, err
 	}
-	if filter.Priority != "" {
-		query += " AND t.priority = ?"
-		args = append(args, filter.Priority)
+
+	page := &models.TicketPage{Tickets: tickets, Total: total}
+	if limit > 0 && len(tickets) > limit {
+		sort, _ := resolveTicketSort(filter)
+		page.Tickets = tickets[:limit]
+		page.NextCursor = encodeTicketCursor(sort, keys[limit-1], tickets[limit-1].ID)
+	}
+	if page.Tickets == nil {
+		page.Tickets = []models.Ticket{}
+	}
+	return page, nil
+}
+
+// listTickets runs a ticket listing and also returns each row's sort key,
+// from which the next page's cursor is built.
+func (s *Store) listTickets(filter models.TicketFilter) ([]models.Ticket, []any, error) {
+	sort, err := resolveTicketSort(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	from, args, ok, err := s.ticketFilterSQL(filter)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	snippet := "''"
+	if filter.Query != "" {
+		snippet = searchSnippetExpr
+	}
+	query := `SELECT t.id, t.project_id, t.team_id, t.assignee_id, t.sprint_id, t.milestone_id, t.number, t.title, t.description,
+		t.status, t.priority, t.due_date, t.estimate, t.position, t.created_at, t.updated_at,
+		COALESCE(p.prefix, '') as project_prefix, ` + snippet + `, ` + sort.expr + from
+
+	if filter.Cursor != "" {
+		key, id, err := decodeTicketCursor(filter.Cursor, sort)
+		if err != nil {
+			return nil, nil, err
+		}
+		query += " AND " + sort.after()
+		args = append(args, key, key, id)
+	}
+	query += " ORDER BY " + sort.expr + " " + sort.order + ", t.id " + sort.order
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
 	}
-	query += " ORDER BY t.position ASC, t.created_at DESC"
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var tickets []models.Ticket
+	var keys []any
 	for rows.Next() {
 		var t models.Ticket
-		if err := rows.Scan(&t.ID, &t.ProjectID, &t.TeamID, &t.Number, &t.Title, &t.Description,
-			&t.Status, &t.Priority, &t.DueDate, &t.Position, &t.CreatedAt, &t.UpdatedAt,
-			&t.ProjectPrefix); err != nil {
-			return nil, err
+		var key any
+		if err := rows.Scan(&t.ID, &t.ProjectID, &t.TeamID, &t.AssigneeID, &t.SprintID, &t.MilestoneID, &t.Number, &t.Title, &t.Description,
+			&t.Status, &t.Priority, &t.DueDate, &t.Estimate, &t.Position, &t.CreatedAt, &t.UpdatedAt,
+			&t.ProjectPrefix, &t.Snippet, &key); err != nil {
+			return nil, nil, err
 		}
 		tickets = append(tickets, t)
+		keys = append(keys, key)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	for i := range tickets {
-		tickets[i].Labels, _ = s.getTicketLabels(tickets[i].ID)
-		tickets[i].Subtasks, _ = s.getTicketSubtasks(tickets[i].ID)
-		tickets[i].BlockedBy, _ = s.getTicketBlockedBy(tickets[i].ID)
+	if err := s.loadTicketDetails(tickets); err != nil {
+		return nil, nil, fmt.Errorf("loading ticket details: %w", err)
 	}
+	return tickets, keys, nil
+}
 
-	return tickets, nil
+// countTickets returns how many tickets match filter, ignoring its cursor
+// and limit.
+func (s *Store) countTickets(filter models.TicketFilter) (int, error) {
+	from, args, ok, err := s.ticketFilterSQL(filter)
+	if err != nil || !ok {
+		return 0, err
+	}
+	var n int
+	err = s.db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&n)
+	return n, err
+}
+
+// ticketFilterSQL builds the FROM and WHERE clauses shared by listing and
+// counting tickets. It reports false when the search text has no searchable
+// words, in which case nothing can match, and returns a *query.Error when
+// the query expression does not parse or names unknown values.
+func (s *Store) ticketFilterSQL(filter models.TicketFilter) (string, []any, bool, error) {
+	var from string
+	args := []any{}
+	if filter.Query != "" {
+		match := ftsQuery(filter.Query)
+		if match == "" {
+			return "", nil, false, nil
+		}
+		from = `
+		FROM ticket_search ts JOIN tickets t ON t.id = ts.ticket_id
+		LEFT JOIN projects p ON t.project_id = p.id WHERE ticket_search MATCH ? AND t.deleted_at IS NULL`
+		args = append(args, match)
+	} else {
+		from = ` FROM tickets t LEFT JOIN projects p ON t.project_id = p.id WHERE t.deleted_at IS NULL`
+	}
+
+	if filter.ProjectID != "" {
+		from += " AND t.project_id = ?"
+		args = append(args, filter.ProjectID)
+	}
+	if filter.TeamID != "" {
+		from += " AND t.team_id = ?"
+		args = append(args, filter.TeamID)
+	}
+	if filter.AssigneeID == "none" {
+		from += " AND t.assignee_id IS NULL"
+	} else if filter.AssigneeID != "" {
+		from += " AND t.assignee_id = ?"
+		args = append(args, filter.AssigneeID)
+	}
+	if filter.SprintID == "none" {
+		from += " AND t.sprint_id IS NULL"
+	} else if filter.SprintID != "" {
+		from += " AND t.sprint_id = ?"
+		args = append(args, filter.SprintID)
+	}
+	if filter.MilestoneID == "none" {
+		from += " AND t.milestone_id IS NULL"
+	} else if filter.MilestoneID != "" {
+		from += " AND t.milestone_id = ?"
+		args = append(args, filter.MilestoneID)
+	}
+	if filter.Status != "" {
+		from += " AND t.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Priority != "" {
+		from += " AND t.priority = ?"
+		args = append(args, filter.Priority)
+	}
+	if filter.Expr != "" {
+		cond, exprArgs, err := s.compileTicketQuery(filter.Expr)
+		if err != nil {
+			return "", nil, false, err
+		}
+		if cond != "" {
+			from += " AND " + cond
+			args = append(args, exprArgs...)
+		}
+	}
+	if len(filter.Fields) > 0 {
+		cond, fieldArgs, err := s.compileFieldFilters(filter.Fields)
+		if err != nil {
+			return "", nil, false, err
+		}
+		from += " AND " + cond
+		args = append(args, fieldArgs...)
+	}
+	return from, args, true, nil
 }
 
 func (s *Store) GetTicket(id string) (*models.Ticket, error) {
 	var t models.Ticket
 	err := s.db.QueryRow(
-		`SELECT t.id, t.project_id, t.team_id, t.number, t.title, t.description,
-		t.status, t.priority, t.due_date, t.position, t.created_at, t.updated_at,
+		`SELECT t.id, t.project_id, t.team_id, t.assignee_id, t.sprint_id, t.milestone_id, t.number, t.title, t.description,
+		t.status, t.priority, t.due_date, t.estimate, t.position, t.created_at, t.updated_at,
 		COALESCE(p.prefix, '') as project_prefix
-		FROM tickets t LEFT JOIN projects p ON t.project_id = p.id WHERE t.id = ?`, id,
-	).Scan(&t.ID, &t.ProjectID, &t.TeamID, &t.Number, &t.Title, &t.Description,
-		&t.Status, &t.Priority, &t.DueDate, &t.Position, &t.CreatedAt, &t.UpdatedAt,
+		FROM tickets t LEFT JOIN projects p ON t.project_id = p.id WHERE t.id = ? AND t.deleted_at IS NULL`, id,
+	).Scan(&t.ID, &t.ProjectID, &t.TeamID, &t.AssigneeID, &t.SprintID, &t.MilestoneID, &t.Number, &t.Title, &t.Description,
+		&t.Status, &t.Priority, &t.DueDate, &t.Estimate, &t.Position, &t.CreatedAt, &t.UpdatedAt,
 		&t.ProjectPrefix)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -289,78 +586,198 @@ func (s *Store) GetTicket(id string) (*models.Ticket, error) {
 		return nil, err
 	}
 
-	t.Labels, _ = s.getTicketLabels(t.ID)
-	t.Subtasks, _ = s.getTicketSubtasks(t.ID)
-	t.BlockedBy, _ = s.getTicketBlockedBy(t.ID)
+	tickets := []models.Ticket{t}
+	if err := s.loadTicketDetails(tickets); err != nil {
+		return nil, fmt.Errorf("loading ticket details: %w", err)
+	}
+	t = tickets[0]
+	if t.Relations, err = s.ListRelations(t.ID); err != nil {
+		return nil, err
+	}
+	if t.Comments, err = s.ListComments(t.ID); err != nil {
+		return nil, err
+	}
+	if t.Attachments, err = s.ListAttachments(t.ID); err != nil {
+		return nil, err
+	}
+	if t.Watchers, err = s.watcherIDs(t.ID); err != nil {
+		return nil, err
+	}
 
 	return &t, nil
 }
 
+// GetTicketByKey returns the ticket with a key such as AUTH-12, or nil if
+// there is none. The prefix is matched case-insensitively.
+func (s *Store) GetTicketByKey(key string) (*models.Ticket, error) {
+	i := strings.LastIndex(key, "-")
+	if i <= 0 {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return nil, nil
+	}
+	var id string
+	err = s.db.QueryRow(`SELECT t.id FROM tickets t JOIN projects p ON p.id = t.project_id
+		WHERE p.prefix = ? COLLATE NOCASE AND t.number = ? AND t.deleted_at IS NULL AND p.deleted_at IS NULL`,
+		key[:i], number).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTicket(id)
+}
+
+// CreateTicket validates req and inserts the ticket together with its labels
+// and blockers in one transaction. Every invalid field is reported at once in
+// a *ValidationError.
 func (s *Store) CreateTicket(req models.CreateTicketRequest) (*models.Ticket, error) {
-	num, err := s.nextTicketNumber(req.ProjectID)
+	return s.createTicket(req, nil)
+}
+
+// createTicket is CreateTicket with subtasks to add after those of the
+// ticket's template.
+func (s *Store) createTicket(req models.CreateTicketRequest, subtasks []models.Subtask) (*models.Ticket, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("getting next ticket number: %w", err)
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	t, err := s.inTx(tx).addTicket(tx, req, subtasks)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.getTicketWithWarning(t.ID, t.Warnings...)
+}
 
-	status := req.Status
-	if status == "" {
-		status = "todo"
+// addTicket validates req and inserts the ticket in tx, checking the WIP
+// limits of its status and returning a warning from one with the warn policy
+// in Warnings. s must run its queries in tx too (see inTx).
+func (s *Store) addTicket(tx *sql.Tx, req models.CreateTicketRequest, subtasks []models.Subtask) (*models.Ticket, error) {
+	v := &ValidationError{}
+	if req.ProjectID == "" {
+		v.add("projectId", "is required")
+		return nil, v
 	}
-	priority := req.Priority
-	if priority == "" {
-		priority = "medium"
+	req, templateSubtasks, err := s.expandTemplate(v, req)
+	if err != nil {
+		return nil, err
 	}
+	subtasks = append(templateSubtasks, subtasks...)
 
 	t := models.Ticket{
 		ID:          newID(),
 		ProjectID:   req.ProjectID,
 		TeamID:      req.TeamID,
-		Number:      num,
+		AssigneeID:  req.AssigneeID,
+		SprintID:    optionalID(req.SprintID),
+		MilestoneID: optionalID(req.MilestoneID),
+		Estimate:    optionalEstimate(req.Estimate),
 		Title:       req.Title,
 		Description: req.Description,
-		Status:      status,
-		Priority:    priority,
-		Position:    float64(num) * 1000,
+		Status:      req.Status,
+		Priority:    req.Priority,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	err = s.db.QueryRow("SELECT prefix FROM projects WHERE id = ? AND deleted_at IS NULL", t.ProjectID).Scan(&t.ProjectPrefix)
+	if err == sql.ErrNoRows {
+		v.add("projectId", "project %s not found", t.ProjectID)
+		return nil, v
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	if req.DueDate != nil {
-		parsed, err := time.Parse("2006-01-02", *req.DueDate)
-		if err == nil {
-			t.DueDate = &parsed
+	if t.Status == "" {
+		statuses, err := s.projectStatuses(t.ProjectID)
+		if err != nil {
+			return nil, err
 		}
+		t.Status = statuses[0].Status
+	}
+	if t.Priority == "" {
+		t.Priority = models.PriorityMedium
+	}
+	if req.DueDate != nil {
+		t.DueDate = parseDueDate(v, *req.DueDate)
+	}
+	if err := s.validateTicket(v, nil, &t, req.Labels, req.BlockedBy); err != nil {
+		return nil, err
+	}
+	if err := s.setFieldValues(v, &t, req.Fields); err != nil {
+		return nil, err
+	}
+	if err := v.err(); err != nil {
+		return nil, err
 	}
 
-	_, err = s.db.Exec(
-		`INSERT INTO tickets (id, project_id, team_id, number, title, description, status, priority, due_date, position, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		t.ID, t.ProjectID, t.TeamID, t.Number, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Position, t.CreatedAt, t.UpdatedAt,
-	)
+	if t.Number, err = nextTicketNumber(tx, t.ProjectID); err != nil {
+		return nil, fmt.Errorf("getting next ticket number: %w", err)
+	}
+	// A new ticket is not in any column yet, so every limit of its status
+	// counts.
+	outside := t
+	outside.Status = ""
+	wipWarning, err := s.checkWIPLimits(&outside, t.Status, t.TeamID)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(req.Labels) > 0 {
-		for _, labelID := range req.Labels {
-			s.db.Exec("INSERT OR IGNORE INTO ticket_labels (ticket_id, label_id) VALUES (?, ?)", t.ID, labelID)
-		}
+	if t.Position, err = movePosition(tx, &t, t.Status, nil, nil); err != nil {
+		return nil, err
 	}
 
-	if len(req.BlockedBy) > 0 {
-		for _, blockerID := range req.BlockedBy {
-			s.db.Exec("INSERT OR IGNORE INTO ticket_dependencies (ticket_id, blocked_by_id) VALUES (?, ?)", t.ID, blockerID)
-		}
+	if err := insertTicket(tx, &t); err != nil {
+		return nil, err
+	}
+	if err := addTicketLabels(tx, t.ID, req.Labels); err != nil {
+		return nil, err
+	}
+	if err := addTicketBlockers(tx, t.ID, req.BlockedBy); err != nil {
+		return nil, err
+	}
+	if err := insertSubtasks(tx, t.ID, subtasks); err != nil {
+		return nil, err
+	}
+	if err := writeFieldValues(tx, t.ID, t.ProjectID, t.Fields); err != nil {
+		return nil, err
 	}
 
-	return s.GetTicket(t.ID)
+	if err := s.recordTicketEvent(tx, &t, models.EventCreated, nil); err != nil {
+		return nil, fmt.Errorf("recording activity: %w", err)
+	}
+	after := newTicketState(&t, req.Labels, req.BlockedBy)
+	if err := s.recordChange(tx, models.ChangeTicket, t.ID, "create "+t.DisplayKey(), nil, after); err != nil {
+		return nil, err
+	}
+	if wipWarning != "" {
+		t.Warnings = append(t.Warnings, wipWarning)
+	}
+	return &t, nil
 }
 
+// UpdateTicket validates the fields set in req and applies them, replacing
+// labels and blockers when given, in one transaction. It returns nil if the
+// ticket does not exist and a *ValidationError listing every invalid field.
 func (s *Store) UpdateTicket(id string, req models.UpdateTicketRequest) (*models.Ticket, error) {
-	t, err := s.GetTicket(id)
-	if err != nil || t == nil {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	before, err := ts.GetTicket(id)
+	if err != nil || before == nil {
+		tx.Rollback()
 		return nil, err
 	}
+	t := *before
+	v := &ValidationError{}
 
 	if req.Title != nil {
 		t.Title = *req.Title
@@ -378,95 +795,371 @@ func (s *Store) UpdateTicket(id string, req models.UpdateTicketRequest) (*models
 		t.Position = *req.Position
 	}
 	if req.TeamID != nil {
-		t.TeamID = req.TeamID
+		t.TeamID = optionalID(req.TeamID)
+	}
+	if req.AssigneeID != nil {
+		t.AssigneeID = optionalID(req.AssigneeID)
+	}
+	if req.SprintID != nil {
+		t.SprintID = optionalID(req.SprintID)
+	}
+	if req.MilestoneID != nil {
+		t.MilestoneID = optionalID(req.MilestoneID)
+	}
+	if req.Estimate != nil {
+		t.Estimate = optionalEstimate(req.Estimate)
 	}
 	if req.DueDate != nil {
-		parsed, err := time.Parse("2006-01-02", *req.DueDate)
-		if err == nil {
-			t.DueDate = &parsed
-		}
+		t.DueDate = parseDueDate(v, *req.DueDate)
 	}
 	t.UpdatedAt = time.Now()
 
-	_, err = s.db.Exec(
-		`UPDATE tickets SET team_id=?, title=?, description=?, status=?, priority=?, due_date=?, position=?, updated_at=? WHERE id=?`,
-		t.TeamID, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Position, t.UpdatedAt, t.ID,
+	if err := ts.validateTicket(v, before, &t, req.Labels, req.BlockedBy); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := ts.setFieldValues(v, &t, req.Fields); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := v.err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if req.BlockedBy != nil {
+		if err := ts.checkBlockers(id, req.BlockedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	var warning, wipWarning string
+	if t.Status != before.Status {
+		if warning, err = ts.checkBlockedMove(before, t.Status); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if t.Status != before.Status || stringValue(t.TeamID) != stringValue(before.TeamID) {
+		if wipWarning, err = ts.checkWIPLimits(before, t.Status, t.TeamID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if t.Status != before.Status && req.Position == nil {
+		if t.Position, err = movePosition(tx, before, t.Status, nil, nil); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE tickets SET team_id=?, assignee_id=?, sprint_id=?, milestone_id=?, title=?, description=?, status=?, priority=?, due_date=?, estimate=?, position=?, updated_at=? WHERE id=?`,
+		t.TeamID, t.AssigneeID, t.SprintID, t.MilestoneID, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Estimate, t.Position, t.UpdatedAt, t.ID,
 	)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
+	changes := ticketChanges(before, &t)
+
+	// Links to trashed labels and blockers are not part of the ticket as the
+	// request sees it, so they are kept for when those come back.
 	if req.Labels != nil {
-		s.db.Exec("DELETE FROM ticket_labels WHERE ticket_id = ?", id)
-		for _, labelID := range req.Labels {
-			s.db.Exec("INSERT OR IGNORE INTO ticket_labels (ticket_id, label_id) VALUES (?, ?)", id, labelID)
+		if _, err := tx.Exec("DELETE FROM ticket_labels WHERE ticket_id = ? AND label_id IN (SELECT id FROM labels WHERE deleted_at IS NULL)", id); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := addTicketLabels(tx, id, req.Labels); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if oldValue, newValue := idsValue(labelIDs(before.Labels)), idsValue(req.Labels); oldValue != newValue {
+			changes = append(changes, fieldChange{"labels", oldValue, newValue})
 		}
 	}
 
 	if req.BlockedBy != nil {
-		s.db.Exec("DELETE FROM ticket_dependencies WHERE ticket_id = ?", id)
-		for _, blockerID := range req.BlockedBy {
-			s.db.Exec("INSERT OR IGNORE INTO ticket_dependencies (ticket_id, blocked_by_id) VALUES (?, ?)", id, blockerID)
+		if _, err := tx.Exec(`DELETE FROM ticket_relations WHERE ticket_id = ? AND type = ?
+			AND related_id IN (SELECT id FROM tickets WHERE deleted_at IS NULL)`, id, models.RelationBlockedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := addTicketBlockers(tx, id, req.BlockedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if oldValue, newValue := idsValue(before.BlockedBy), idsValue(req.BlockedBy); oldValue != newValue {
+			changes = append(changes, fieldChange{"blockedBy", oldValue, newValue})
 		}
 	}
 
-	return s.GetTicket(id)
+	if req.Fields != nil {
+		if err := writeFieldValues(tx, id, t.ProjectID, t.Fields); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		changes = append(changes, fieldChanges(before.Fields, t.Fields)...)
+	}
+
+	if err := s.recordTicketEvent(tx, &t, models.EventUpdated, changes); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("recording activity: %w", err)
+	}
+	labels, blockedBy := labelIDs(before.Labels), before.BlockedBy
+	if req.Labels != nil {
+		labels = req.Labels
+	}
+	if req.BlockedBy != nil {
+		blockedBy = req.BlockedBy
+	}
+	summary := "update " + t.DisplayKey()
+	if len(changes) > 0 {
+		fields := make([]string, len(changes))
+		for i, c := range changes {
+			fields[i] = c.field
+		}
+		summary += " " + strings.Join(fields, ", ")
+	}
+	beforeState := newTicketState(before, labelIDs(before.Labels), before.BlockedBy)
+	if err := s.recordChange(tx, models.ChangeTicket, id, summary, beforeState, newTicketState(&t, labels, blockedBy)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getTicketWithWarning(id, warning, wipWarning)
 }
 
+// MoveTicket moves a ticket to req.Status, at req.Position, between the
+// tickets req.After and req.Before of that column (whose status is used when
+// req.Status is empty), or otherwise at the bottom of the column.
 func (s *Store) MoveTicket(id string, req models.MoveTicketRequest) (*models.Ticket, error) {
-	now := time.Now()
-	position := float64(0)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	t, err := ts.GetTicket(id)
+	if err != nil || t == nil {
+		tx.Rollback()
+		return nil, err
+	}
+	beforeTicket, afterTicket, err := ts.moveAnchors(t, &req)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := ts.validateStatus(t.ProjectID, req.Status); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var warning, wipWarning string
+	if t.Status != req.Status {
+		if warning, err = ts.checkBlockedMove(t, req.Status); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if wipWarning, err = ts.checkWIPLimits(t, req.Status, t.TeamID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	var position float64
 	if req.Position != nil {
 		position = *req.Position
-	} else {
-		var maxPos float64
-		s.db.QueryRow("SELECT COALESCE(MAX(position), 0) + 1000 FROM tickets WHERE status = ?", req.Status).Scan(&maxPos)
-		position = maxPos
+	} else if position, err = movePosition(tx, t, req.Status, beforeTicket, afterTicket); err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-
-	_, err := s.db.Exec("UPDATE tickets SET status=?, position=?, updated_at=? WHERE id=?",
-		req.Status, position, now, id)
+	_, err = tx.Exec("UPDATE tickets SET status=?, position=?, updated_at=? WHERE id=?",
+		req.Status, position, time.Now(), id)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	return s.GetTicket(id)
+	var changes []fieldChange
+	if t.Status != req.Status {
+		changes = append(changes, fieldChange{"status", t.Status, req.Status})
+	}
+	if err := s.recordTicketEvent(tx, t, models.EventMoved, changes); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("recording activity: %w", err)
+	}
+	before := newTicketState(t, labelIDs(t.Labels), t.BlockedBy)
+	after := *before
+	after.Status, after.Position = req.Status, position
+	if err := s.recordChange(tx, models.ChangeTicket, id, "move "+t.DisplayKey()+" to "+req.Status, before, &after); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.getTicketWithWarning(id, warning, wipWarning)
 }
 
-func (s *Store) DeleteTicket(id string) error {
-	_, err := s.db.Exec("DELETE FROM tickets WHERE id = ?", id)
+func insertTicket(e execer, t *models.Ticket) error {
+	_, err := e.Exec(
+		`INSERT INTO tickets (id, project_id, team_id, assignee_id, sprint_id, milestone_id, number, title, description, status, priority, due_date, estimate, position, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.ProjectID, t.TeamID, t.AssigneeID, t.SprintID, t.MilestoneID, t.Number, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Estimate, t.Position, t.CreatedAt, t.UpdatedAt,
+	)
 	return err
 }
 
+// insertSubtasks adds subtasks to a new ticket in the order given.
+func insertSubtasks(e execer, ticketID string, subtasks []models.Subtask) error {
+	for i, st := range subtasks {
+		if _, err := e.Exec("INSERT INTO subtasks (id, ticket_id, title, completed, position) VALUES (?, ?, ?, ?, ?)",
+			newID(), ticketID, st.Title, st.Completed, i); err != nil {
+			return fmt.Errorf("adding subtask: %w", err)
+		}
+	}
+	return nil
+}
+
+func addTicketLabels(e execer, ticketID string, ids []string) error {
+	for _, labelID := range ids {
+		if _, err := e.Exec("INSERT OR IGNORE INTO ticket_labels (ticket_id, label_id) VALUES (?, ?)", ticketID, labelID); err != nil {
+			return fmt.Errorf("adding label %s: %w", labelID, err)
+		}
+	}
+	return nil
+}
+
+func addTicketBlockers(e execer, ticketID string, ids []string) error {
+	for _, blockerID := range ids {
+		if _, err := e.Exec("INSERT OR IGNORE INTO ticket_relations (ticket_id, related_id, type) VALUES (?, ?, ?)",
+			ticketID, blockerID, models.RelationBlockedBy); err != nil {
+			return fmt.Errorf("adding blocker %s: %w", blockerID, err)
+		}
+	}
+	return nil
+}
+
+// optionalID turns the empty string an update request uses to clear a
+// reference into nil.
+func optionalID(id *string) *string {
+	if id == nil || *id == "" {
+		return nil
+	}
+	return id
+}
+
+// optionalEstimate treats a zero estimate as no estimate.
+func optionalEstimate(e *float64) *float64 {
+	if e == nil || *e == 0 {
+		return nil
+	}
+	return e
+}
+
+// getTicketWithWarning reloads a ticket after a write and attaches warnings
+// produced while making it.
+func (s *Store) getTicketWithWarning(id string, warnings ...string) (*models.Ticket, error) {
+	t, err := s.GetTicket(id)
+	if t == nil {
+		return t, err
+	}
+	for _, warning := range warnings {
+		if warning != "" {
+			t.Warnings = append(t.Warnings, warning)
+		}
+	}
+	return t, err
+}
+
+// DeleteTicket moves a ticket to the trash.
+func (s *Store) DeleteTicket(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	t, err := s.inTx(tx).GetTicket(id)
+	if err != nil || t == nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tickets SET deleted_at = ? WHERE id = ?", time.Now(), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.recordTicketEvent(tx, t, models.EventDeleted, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("recording activity: %w", err)
+	}
+	before := newTicketState(t, labelIDs(t.Labels), t.BlockedBy)
+	if err := s.recordChange(tx, models.ChangeTicket, id, "delete "+t.DisplayKey(), before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func (s *Store) GetBoard(projectID string) (*models.Board, error) {
-	statuses := []string{"todo", "in_progress", "done"}
+	return s.buildBoard(projectID, models.TicketFilter{ProjectID: projectID})
+}
+
+// buildBoard lays the tickets matching filter out in the workflow columns of
+// projectID, or of every project when it is empty.
+func (s *Store) buildBoard(projectID string, filter models.TicketFilter) (*models.Board, error) {
+	var statuses []models.WorkflowStatus
+	var err error
+	if projectID != "" {
+		statuses, err = s.projectStatuses(projectID)
+	} else {
+		statuses, err = s.ListWorkflowStatuses()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Load every ticket once and deal them into columns; ListTickets already
+	// returns them in column order.
+	tickets, err := s.ListTickets(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	board := &models.Board{
 		ProjectID: projectID,
+		SprintID:  filter.SprintID,
 		Columns:   make([]models.Column, len(statuses)),
 	}
-
-	for i, status := range statuses {
-		filter := models.TicketFilter{Status: status}
-		if projectID != "" {
-			filter.ProjectID = projectID
-		}
-		tickets, err := s.ListTickets(filter)
-		if err != nil {
-			return nil, err
+	columns := make(map[string]*models.Column, len(statuses))
+	for i, st := range statuses {
+		board.Columns[i] = models.Column{
+			Status:   st.Status,
+			Name:     st.Name,
+			Category: st.Category,
+			Tickets:  []models.Ticket{},
 		}
-		if tickets == nil {
-			tickets = []models.Ticket{}
+		columns[st.Status] = &board.Columns[i]
+	}
+	for _, t := range tickets {
+		if col, ok := columns[t.Status]; ok {
+			col.Tickets = append(col.Tickets, t)
 		}
-		board.Columns[i] = models.Column{
-			Status:  status,
-			Tickets: tickets,
+	}
+	if projectID == "" {
+		for i := range board.Columns {
+			board.Columns[i].Count = len(board.Columns[i].Tickets)
 		}
+		return board, nil
+	}
+	if err := s.setColumnLimits(projectID, board.Columns); err != nil {
+		return nil, err
 	}
 
 	return board, nil
 }
 
 func (s *Store) ListLabels() ([]models.Label, error) {
-	rows, err := s.db.Query("SELECT id, name, color FROM labels ORDER BY name")
+	rows, err := s.db.Query("SELECT id, name, color FROM labels WHERE deleted_at IS NULL ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -491,7 +1184,7 @@ func (s *Store) CreateLabel(req models.CreateLabelRequest) (*models.Label, error
 
 func (s *Store) UpdateLabel(id string, req models.UpdateLabelRequest) (*models.Label, error) {
 	var l models.Label
-	err := s.db.QueryRow("SELECT id, name, color FROM labels WHERE id = ?", id).Scan(&l.ID, &l.Name, &l.Color)
+	err := s.db.QueryRow("SELECT id, name, color FROM labels WHERE id = ? AND deleted_at IS NULL", id).Scan(&l.ID, &l.Name, &l.Color)
 	if err != nil {
 		return nil, err
 	}
@@ -505,14 +1198,37 @@ func (s *Store) UpdateLabel(id string, req models.UpdateLabelRequest) (*models.L
 	return &l, err
 }
 
+// DeleteLabel moves a label to the trash. Tickets keep it, hidden, until it
+// is restored or purged.
 func (s *Store) DeleteLabel(id string) error {
-	_, err := s.db.Exec("DELETE FROM labels WHERE id = ?", id)
-	return err
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	res, err := tx.Exec("UPDATE labels SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if err := s.recordLabelShown(tx, id, false); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (s *Store) AddSubtask(ticketID string, req models.CreateSubtaskRequest) (*models.Subtask, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
 	var maxPos int
-	s.db.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM subtasks WHERE ticket_id = ?", ticketID).Scan(&maxPos)
+	if err := tx.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM subtasks WHERE ticket_id = ?", ticketID).Scan(&maxPos); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	st := models.Subtask{
 		ID:       newID(),
@@ -520,81 +1236,83 @@ func (s *Store) AddSubtask(ticketID string, req models.CreateSubtaskRequest) (*m
 		Title:    req.Title,
 		Position: maxPos,
 	}
-	_, err := s.db.Exec("INSERT INTO subtasks (id, ticket_id, title, completed, position) VALUES (?, ?, ?, ?, ?)",
+	_, err = tx.Exec("INSERT INTO subtasks (id, ticket_id, title, completed, position) VALUES (?, ?, ?, ?, ?)",
 		st.ID, st.TicketID, st.Title, st.Completed, st.Position)
-	return &st, err
-}
-
-func (s *Store) ToggleSubtask(id string) (*models.Subtask, error) {
-	_, err := s.db.Exec("UPDATE subtasks SET completed = NOT completed WHERE id = ?", id)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
+	summary := fmt.Sprintf("add subtask %q to %s", st.Title, s.inTx(tx).ticketKey(ticketID))
+	if err := s.recordChange(tx, models.ChangeSubtask, st.ID, summary, nil, newSubtaskState(&st)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &st, tx.Commit()
+}
+
+func (s *Store) getSubtask(id string) (*models.Subtask, error) {
 	var st models.Subtask
-	err = s.db.QueryRow("SELECT id, ticket_id, title, completed, position FROM subtasks WHERE id = ?", id).
+	err := s.db.QueryRow("SELECT id, ticket_id, title, completed, position FROM subtasks WHERE id = ?", id).
 		Scan(&st.ID, &st.TicketID, &st.Title, &st.Completed, &st.Position)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	return &st, err
 }
 
-func (s *Store) DeleteSubtask(id string) error {
-	_, err := s.db.Exec("DELETE FROM subtasks WHERE id = ?", id)
-	return err
-}
-
-func (s *Store) getTicketLabels(ticketID string) ([]models.Label, error) {
-	rows, err := s.db.Query(
-		"SELECT l.id, l.name, l.color FROM labels l JOIN ticket_labels tl ON l.id = tl.label_id WHERE tl.ticket_id = ?",
-		ticketID)
+func (s *Store) ToggleSubtask(id string) (*models.Subtask, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	ts := s.inTx(tx)
+	st, err := ts.getSubtask(id)
+	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	defer rows.Close()
-
-	var labels []models.Label
-	for rows.Next() {
-		var l models.Label
-		if err := rows.Scan(&l.ID, &l.Name, &l.Color); err != nil {
-			return nil, err
-		}
-		labels = append(labels, l)
+	if st == nil {
+		tx.Rollback()
+		return nil, sql.ErrNoRows
 	}
-	return labels, rows.Err()
-}
+	before := newSubtaskState(st)
+	st.Completed = !st.Completed
 
-func (s *Store) getTicketSubtasks(ticketID string) ([]models.Subtask, error) {
-	rows, err := s.db.Query(
-		"SELECT id, ticket_id, title, completed, position FROM subtasks WHERE ticket_id = ? ORDER BY position",
-		ticketID)
-	if err != nil {
+	if _, err := tx.Exec("UPDATE subtasks SET completed = ? WHERE id = ?", st.Completed, id); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	defer rows.Close()
-
-	var subtasks []models.Subtask
-	for rows.Next() {
-		var st models.Subtask
-		if err := rows.Scan(&st.ID, &st.TicketID, &st.Title, &st.Completed, &st.Position); err != nil {
-			return nil, err
-		}
-		subtasks = append(subtasks, st)
+	verb := "check"
+	if !st.Completed {
+		verb = "uncheck"
+	}
+	summary := fmt.Sprintf("%s subtask %q on %s", verb, st.Title, ts.ticketKey(st.TicketID))
+	if err := s.recordChange(tx, models.ChangeSubtask, id, summary, before, newSubtaskState(st)); err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	return subtasks, rows.Err()
+	return st, tx.Commit()
 }
 
-func (s *Store) getTicketBlockedBy(ticketID string) ([]string, error) {
-	rows, err := s.db.Query("SELECT blocked_by_id FROM ticket_dependencies WHERE ticket_id = ?", ticketID)
+func (s *Store) DeleteSubtask(id string) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("beginning transaction: %w", err)
 	}
-	defer rows.Close()
-
-	var ids []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
+	ts := s.inTx(tx)
+	st, err := ts.getSubtask(id)
+	if err != nil || st == nil {
+		tx.Rollback()
+		return err
 	}
-	return ids, rows.Err()
+	if _, err := tx.Exec("DELETE FROM subtasks WHERE id = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	summary := fmt.Sprintf("delete subtask %q from %s", st.Title, ts.ticketKey(st.TicketID))
+	if err := s.recordChange(tx, models.ChangeSubtask, id, summary, newSubtaskState(st), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+var benchSizes = []int{2000, 10000}
+
+type benchStore struct {
+	store     *Store
+	projectID string
+}
+
+// benchStores caches seeded stores by size, since the benchmark framework
+// calls each sub-benchmark more than once.
+var benchStores = map[int]benchStore{}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "taskboard-bench")
+	if err != nil {
+		panic(err)
+	}
+	benchDir = dir
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var benchDir string
+
+// newBenchStore returns a store holding one project with n tickets spread
+// over the default workflow. Every ticket has two labels and three subtasks,
+// and every fifth ticket is blocked by the one before it.
+func newBenchStore(b *testing.B, n int) (*Store, string) {
+	b.Helper()
+	if bs, ok := benchStores[n]; ok {
+		return bs.store, bs.projectID
+	}
+	database, err := OpenAt(filepath.Join(benchDir, fmt.Sprintf("bench-%d.db", n)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := NewStore(database)
+
+	p, err := s.CreateProject(models.CreateProjectRequest{Name: "Bench", Prefix: "BENCH"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	labels := make([]string, 8)
+	for i := range labels {
+		l, err := s.CreateLabel(models.CreateLabelRequest{Name: fmt.Sprintf("label-%d", i)})
+		if err != nil {
+			b.Fatal(err)
+		}
+		labels[i] = l.ID
+	}
+
+	statuses := []string{"todo", "in_progress", "done"}
+	tx, err := database.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	now := time.Now()
+	var prev string
+	for i := 1; i <= n; i++ {
+		id := newID()
+		exec := func(query string, args ...any) {
+			if _, err := tx.Exec(query, args...); err != nil {
+				tx.Rollback()
+				b.Fatal(err)
+			}
+		}
+		exec(`INSERT INTO tickets (id, project_id, number, title, description, status, priority, position, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, 'medium', ?, ?, ?)`,
+			id, p.ID, i, fmt.Sprintf("Ticket %d", i), "Benchmark ticket", statuses[i%len(statuses)], float64(i)*1000, now, now)
+		exec("INSERT INTO ticket_labels (ticket_id, label_id) VALUES (?, ?), (?, ?)",
+			id, labels[i%len(labels)], id, labels[(i+1)%len(labels)])
+		for j := 0; j < 3; j++ {
+			exec("INSERT INTO subtasks (id, ticket_id, title, position) VALUES (?, ?, ?, ?)",
+				newID(), id, fmt.Sprintf("Step %d", j), j)
+		}
+		if i%5 == 0 {
+			exec("INSERT INTO ticket_relations (ticket_id, related_id, type) VALUES (?, ?, ?)", id, prev, models.RelationBlockedBy)
+		}
+		prev = id
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	benchStores[n] = benchStore{s, p.ID}
+	return s, p.ID
+}
+
+func BenchmarkListTickets(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("tickets=%d", n), func(b *testing.B) {
+			s, projectID := newBenchStore(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tickets, err := s.ListTickets(models.TicketFilter{ProjectID: projectID})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(tickets) != n {
+					b.Fatalf("got %d tickets, want %d", len(tickets), n)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetBoard(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("tickets=%d", n), func(b *testing.B) {
+			s, projectID := newBenchStore(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.GetBoard(projectID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// newTestStore returns a store on an empty database of its own.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	database, err := OpenAt(filepath.Join(t.TempDir(), "taskboard.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewStore(database)
+}
+
+// mustProject creates a project with the default workflow, failing the
+// test on error.
+func mustProject(t *testing.T, s *Store, req models.CreateProjectRequest) *models.Project {
+	t.Helper()
+	p, err := s.CreateProject(req)
+	if err != nil {
+		t.Fatalf("creating project %q: %v", req.Name, err)
+	}
+	return p
+}
+
+// mustTicket creates a ticket, failing the test on error.
+func mustTicket(t *testing.T, s *Store, req models.CreateTicketRequest) *models.Ticket {
+	t.Helper()
+	ticket, err := s.CreateTicket(req)
+	if err != nil {
+		t.Fatalf("creating ticket %q: %v", req.Title, err)
+	}
+	return ticket
+}
+
+// mustGetTicket loads a ticket that has to exist, failing the test
+// otherwise.
+func mustGetTicket(t *testing.T, s *Store, id string) *models.Ticket {
+	t.Helper()
+	ticket, err := s.GetTicket(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket == nil {
+		t.Fatalf("ticket %s not found", id)
+	}
+	return ticket
+}
+
+// displayKeys returns the display keys of tickets in their order.
+func displayKeys(tickets []models.Ticket) []string {
+	keys := make([]string, len(tickets))
+	for i, t := range tickets {
+		keys[i] = t.DisplayKey()
+	}
+	return keys
+}
@@ -0,0 +1,323 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+const templateColumns = "id, project_id, name, title, description, priority, team_id, subtasks, created_at, updated_at"
+
+func scanTemplate(row interface{ Scan(...any) error }) (*models.TicketTemplate, error) {
+	var t models.TicketTemplate
+	var subtasks string
+	err := row.Scan(&t.ID, &t.ProjectID, &t.Name, &t.Title, &t.Description, &t.Priority, &t.TeamID, &subtasks,
+		&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(subtasks), &t.Subtasks); err != nil {
+		return nil, fmt.Errorf("decoding template %s subtasks: %w", t.ID, err)
+	}
+	return &t, nil
+}
+
+func (s *Store) loadTemplateLabels(t *models.TicketTemplate) error {
+	rows, err := s.db.Query(`SELECT l.id, l.name, l.color FROM template_labels tl JOIN labels l ON l.id = tl.label_id
+		WHERE tl.template_id = ? AND l.deleted_at IS NULL ORDER BY l.name`, t.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	t.Labels = []models.Label{}
+	for rows.Next() {
+		var l models.Label
+		if err := rows.Scan(&l.ID, &l.Name, &l.Color); err != nil {
+			return err
+		}
+		t.Labels = append(t.Labels, l)
+	}
+	return rows.Err()
+}
+
+// ListTemplates returns ticket templates by name: all of them, or only those
+// of projectID when it is set.
+func (s *Store) ListTemplates(projectID string) ([]models.TicketTemplate, error) {
+	q := "SELECT " + templateColumns + " FROM ticket_templates WHERE " + inLiveProject("project_id")
+	args := []any{}
+	if projectID != "" {
+		q += " AND project_id = ?"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY name COLLATE NOCASE"
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	var templates []models.TicketTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range templates {
+		if err := s.loadTemplateLabels(&templates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func (s *Store) GetTemplate(id string) (*models.TicketTemplate, error) {
+	return s.getTemplate("SELECT "+templateColumns+" FROM ticket_templates WHERE id = ?", id)
+}
+
+// FindTemplate returns the template of projectID with the given ID or name,
+// ignoring case.
+func (s *Store) FindTemplate(projectID, ref string) (*models.TicketTemplate, error) {
+	return s.getTemplate("SELECT "+templateColumns+" FROM ticket_templates WHERE project_id = ? AND (id = ? OR name = ? COLLATE NOCASE)",
+		projectID, ref, ref)
+}
+
+func (s *Store) getTemplate(q string, args ...any) (*models.TicketTemplate, error) {
+	t, err := scanTemplate(s.db.QueryRow(q, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadTemplateLabels(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Store) CreateTemplate(req models.CreateTemplateRequest) (*models.TicketTemplate, error) {
+	v := &ValidationError{}
+	t := models.TicketTemplate{
+		ID:          newID(),
+		ProjectID:   req.ProjectID,
+		Name:        strings.TrimSpace(req.Name),
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Priority:    req.Priority,
+		TeamID:      optionalID(req.TeamID),
+		Subtasks:    trimSubtasks(req.Subtasks),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if t.ProjectID == "" {
+		v.add("projectId", "is required")
+	} else if err := s.checkExists(v, "projectId", "projects", "project", t.ProjectID); err != nil {
+		return nil, err
+	}
+	if err := s.validateTemplate(v, &t, req.Labels); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	if err := insertTemplate(tx, &t); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := addTemplateLabels(tx, t.ID, req.Labels); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(t.ID)
+}
+
+func (s *Store) UpdateTemplate(id string, req models.UpdateTemplateRequest) (*models.TicketTemplate, error) {
+	t, err := s.GetTemplate(id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	v := &ValidationError{}
+
+	if req.Name != nil {
+		t.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Title != nil {
+		t.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		t.Description = *req.Description
+	}
+	if req.Priority != nil {
+		t.Priority = *req.Priority
+	}
+	if req.TeamID != nil {
+		t.TeamID = optionalID(req.TeamID)
+	}
+	if req.Subtasks != nil {
+		t.Subtasks = trimSubtasks(req.Subtasks)
+	}
+	if err := s.validateTemplate(v, t, req.Labels); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	subtasks, _ := json.Marshal(t.Subtasks)
+	_, err = tx.Exec("UPDATE ticket_templates SET name=?, title=?, description=?, priority=?, team_id=?, subtasks=?, updated_at=? WHERE id=?",
+		t.Name, t.Title, t.Description, t.Priority, t.TeamID, string(subtasks), time.Now(), t.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("updating template: %w", err)
+	}
+	if req.Labels != nil {
+		if _, err := tx.Exec("DELETE FROM template_labels WHERE template_id = ?", t.ID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := addTemplateLabels(tx, t.ID, req.Labels); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(id)
+}
+
+// DeleteTemplate removes a template. Tickets created from it are kept.
+func (s *Store) DeleteTemplate(id string) error {
+	_, err := s.db.Exec("DELETE FROM ticket_templates WHERE id = ?", id)
+	return err
+}
+
+// validateTemplate adds the checks of t and the labels about to be attached
+// to it to v and returns the collected errors. Names are unique within a
+// project, ignoring case.
+func (s *Store) validateTemplate(v *ValidationError, t *models.TicketTemplate, labels []string) error {
+	if t.Name == "" {
+		v.add("name", "must not be empty")
+	} else {
+		var other string
+		err := s.db.QueryRow("SELECT id FROM ticket_templates WHERE project_id = ? AND name = ? COLLATE NOCASE AND id != ?",
+			t.ProjectID, t.Name, t.ID).Scan(&other)
+		if err == nil {
+			v.add("name", "the project already has a template named %q", t.Name)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	if t.Priority != "" && !slices.Contains(models.Priorities(), t.Priority) {
+		v.add("priority", "must be one of %s, got %q", strings.Join(models.Priorities(), ", "), t.Priority)
+	}
+	if t.TeamID != nil {
+		if err := s.checkExists(v, "teamId", "teams", "team", *t.TeamID); err != nil {
+			return err
+		}
+	}
+	for _, id := range labels {
+		if err := s.checkExists(v, "labels", "labels", "label", id); err != nil {
+			return err
+		}
+	}
+	for _, title := range t.Subtasks {
+		if title == "" {
+			v.add("subtasks", "titles must not be empty")
+			break
+		}
+	}
+	return v.err()
+}
+
+func trimSubtasks(titles []string) []string {
+	trimmed := make([]string, len(titles))
+	for i, title := range titles {
+		trimmed[i] = strings.TrimSpace(title)
+	}
+	return trimmed
+}
+
+func insertTemplate(e execer, t *models.TicketTemplate) error {
+	subtasks, _ := json.Marshal(t.Subtasks)
+	_, err := e.Exec("INSERT INTO ticket_templates ("+templateColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		t.ID, t.ProjectID, t.Name, t.Title, t.Description, t.Priority, t.TeamID, string(subtasks), t.CreatedAt, t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting template: %w", err)
+	}
+	return nil
+}
+
+func addTemplateLabels(e execer, templateID string, ids []string) error {
+	for _, labelID := range ids {
+		if _, err := e.Exec("INSERT OR IGNORE INTO template_labels (template_id, label_id) VALUES (?, ?)", templateID, labelID); err != nil {
+			return fmt.Errorf("adding label %s: %w", labelID, err)
+		}
+	}
+	return nil
+}
+
+// expandTemplate fills in the fields req leaves empty from the template it
+// names, if any, and returns the subtasks to add to the new ticket. An
+// unknown template is recorded in v.
+func (s *Store) expandTemplate(v *ValidationError, req models.CreateTicketRequest) (models.CreateTicketRequest, []models.Subtask, error) {
+	if req.Template == "" {
+		return req, nil, nil
+	}
+	t, err := s.FindTemplate(req.ProjectID, req.Template)
+	if err != nil {
+		return req, nil, err
+	}
+	if t == nil {
+		v.add("template", "the project has no template %q", req.Template)
+		return req, nil, nil
+	}
+
+	// A pattern with {title} wraps the given title; one without replaces
+	// a missing title.
+	if strings.Contains(t.Title, "{title}") == (req.Title != "") {
+		title := strings.ReplaceAll(t.Title, "{title}", req.Title)
+		req.Title = strings.ReplaceAll(title, "{date}", time.Now().Format("2006-01-02"))
+	}
+	if req.Description == "" {
+		req.Description = t.Description
+	}
+	if req.Priority == "" {
+		req.Priority = t.Priority
+	}
+	if req.TeamID == nil {
+		req.TeamID = t.TeamID
+	}
+	labels := labelIDs(t.Labels)
+	for _, id := range req.Labels {
+		if !slices.Contains(labels, id) {
+			labels = append(labels, id)
+		}
+	}
+	req.Labels = labels
+
+	subtasks := make([]models.Subtask, len(t.Subtasks))
+	for i, title := range t.Subtasks {
+		subtasks[i] = models.Subtask{Title: title}
+	}
+	return req, subtasks, nil
+}
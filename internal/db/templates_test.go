@@ -0,0 +1,55 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestCreateTicketFromTemplate(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	bug, err := s.CreateLabel(models.CreateLabelRequest{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui, err := s.CreateLabel(models.CreateLabelRequest{Name: "ui"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateTemplate(models.CreateTemplateRequest{ProjectID: p.ID, Name: "Bug", Title: "Bug: {title}",
+		Description: "Steps to reproduce:", Priority: "high", Labels: []string{bug.ID},
+		Subtasks: []string{"Reproduce", "Fix", "Add a test"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Template: "bug", Title: "Login crashes",
+		Priority: "urgent", Labels: []string{ui.ID}})
+	ticket = mustGetTicket(t, s, ticket.ID)
+	if ticket.Title != "Bug: Login crashes" || ticket.Description != "Steps to reproduce:" || ticket.Priority != "urgent" {
+		t.Errorf("got title %q, description %q, priority %s; want the template's title and description and the request's priority",
+			ticket.Title, ticket.Description, ticket.Priority)
+	}
+	var labels, subtasks []string
+	for _, l := range ticket.Labels {
+		labels = append(labels, l.Name)
+	}
+	for _, st := range ticket.Subtasks {
+		subtasks = append(subtasks, st.Title)
+	}
+	slices.Sort(labels)
+	if !slices.Equal(labels, []string{"bug", "ui"}) {
+		t.Errorf("labels = %v, want [bug ui]", labels)
+	}
+	if !slices.Equal(subtasks, []string{"Reproduce", "Fix", "Add a test"}) {
+		t.Errorf("subtasks = %v, want the template's checklist in order", subtasks)
+	}
+
+	_, err = s.CreateTicket(models.CreateTicketRequest{ProjectID: p.ID, Template: "feature", Title: "Dark mode"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Fields[0].Field != "template" {
+		t.Errorf("unknown template: got %v, want a validation error on template", err)
+	}
+}
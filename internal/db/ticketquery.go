@@ -0,0 +1,427 @@
+package db
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/query"
+)
+
+// Fields understood by ticket queries, for help text and error messages.
+var ticketQueryFields = []string{
+	"project", "status", "priority", "label", "assignee", "team", "sprint", "milestone",
+	"due", "created", "updated", "number", "is", "text",
+}
+
+var ticketQueryStates = []string{"blocked", "assigned", "unassigned", "overdue", "open", "done"}
+
+const ticketIsDone = `EXISTS (SELECT 1 FROM workflow_statuses w
+	WHERE w.project_id = t.project_id AND w.status = t.status AND w.category = 'done')`
+
+// priorityRankExpr ranks t.priority so that more urgent sorts higher.
+const priorityRankExpr = "CASE t.priority WHEN 'urgent' THEN 3 WHEN 'high' THEN 2 WHEN 'medium' THEN 1 ELSE 0 END"
+
+// ticketQueryCompiler turns a parsed ticket query into a WHERE condition on
+// tickets t. Values are always bound as parameters.
+type ticketQueryCompiler struct {
+	s        *Store
+	args     []any
+	statuses []models.WorkflowStatus
+}
+
+// compileTicketQuery parses text and compiles it. Errors are *query.Error
+// and point at the offending part of the query.
+func (s *Store) compileTicketQuery(text string) (string, []any, error) {
+	n, err := query.Parse(text)
+	if err != nil || n == nil {
+		return "", nil, err
+	}
+	c := &ticketQueryCompiler{s: s}
+	cond, err := c.compile(n)
+	if err != nil {
+		return "", nil, err
+	}
+	return cond, c.args, nil
+}
+
+func (c *ticketQueryCompiler) compile(n query.Node) (string, error) {
+	switch n := n.(type) {
+	case *query.And:
+		return c.join(n.Terms, " AND ")
+	case *query.Or:
+		return c.join(n.Terms, " OR ")
+	case *query.Not:
+		cond, err := c.compile(n.Term)
+		if err != nil {
+			return "", err
+		}
+		// A comparison with a missing value is NULL, which NOT leaves NULL;
+		// treat it as false so "-due<2026-01-01" keeps undated tickets.
+		return "NOT COALESCE(" + cond + ", 0)", nil
+	case *query.Text:
+		return c.text(n.Value, n.Pos)
+	case *query.Field:
+		return c.field(n)
+	}
+	return "", query.Errorf(1, "unsupported expression")
+}
+
+func (c *ticketQueryCompiler) join(terms []query.Node, sep string) (string, error) {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		cond, err := c.compile(t)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = cond
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+func (c *ticketQueryCompiler) bind(args ...any) {
+	c.args = append(c.args, args...)
+}
+
+func (c *ticketQueryCompiler) text(value string, pos int) (string, error) {
+	match := ftsQuery(value)
+	if match == "" {
+		return "", query.Errorf(pos, "nothing to search for in %q", value)
+	}
+	c.bind(match)
+	return "t.id IN (SELECT ticket_id FROM ticket_search WHERE ticket_search MATCH ?)", nil
+}
+
+func (c *ticketQueryCompiler) field(f *query.Field) (string, error) {
+	if f.Name == "text" {
+		if f.Op != query.OpEq && f.Op != query.OpNe {
+			return "", query.Errorf(f.Pos, "text only supports : and :!")
+		}
+		return c.eachValue(f, func(v query.Value) (string, error) { return c.text(v.Text, v.Pos) })
+	}
+
+	switch f.Name {
+	case "project":
+		return c.equality(f, func(v string) string {
+			c.bind(v, v)
+			return "t.project_id IN (SELECT id FROM projects WHERE id = ? OR prefix = ? COLLATE NOCASE)"
+		})
+	case "status":
+		return c.equality(f, func(v string) string {
+			c.bind(v)
+			return "t.status = ?"
+		}, c.checkStatus)
+	case "label":
+		return c.equality(f, func(v string) string {
+			c.bind(v, v)
+			return `EXISTS (SELECT 1 FROM ticket_labels tl JOIN labels l ON l.id = tl.label_id
+				WHERE tl.ticket_id = t.id AND l.deleted_at IS NULL AND (l.id = ? OR l.name = ? COLLATE NOCASE))`
+		})
+	case "team":
+		return c.equality(f, func(v string) string {
+			if v == "none" {
+				return "t.team_id IS NULL"
+			}
+			c.bind(v, v)
+			return "t.team_id IN (SELECT id FROM teams WHERE id = ? OR name = ? COLLATE NOCASE)"
+		})
+	case "assignee":
+		return c.equality(f, func(v string) string {
+			if v == "none" {
+				return "t.assignee_id IS NULL"
+			}
+			if v == "me" {
+				v = c.s.actor.Name
+			}
+			v = strings.TrimPrefix(v, "@")
+			c.bind(v, v)
+			return "t.assignee_id IN (SELECT id FROM members WHERE id = ? OR handle = ? COLLATE NOCASE)"
+		})
+	case "sprint":
+		return c.equality(f, func(v string) string {
+			switch v {
+			case "none":
+				return "t.sprint_id IS NULL"
+			case "active":
+				c.bind(time.Now().Format("2006-01-02"))
+				return `t.sprint_id IN (SELECT id FROM sprints
+					WHERE closed_at IS NULL AND substr(CAST(start_date AS TEXT), 1, 10) <= ?)`
+			}
+			c.bind(v, v)
+			return "t.sprint_id IN (SELECT id FROM sprints WHERE id = ? OR name = ? COLLATE NOCASE)"
+		})
+	case "milestone":
+		return c.equality(f, func(v string) string {
+			if v == "none" {
+				return "t.milestone_id IS NULL"
+			}
+			c.bind(v, v)
+			return "t.milestone_id IN (SELECT id FROM milestones WHERE id = ? OR name = ? COLLATE NOCASE)"
+		})
+	case "priority":
+		return c.ordered(f, priorityRankExpr, func(v query.Value) (any, error) {
+			i := slices.Index(models.Priorities(), strings.ToLower(v.Text))
+			if i < 0 {
+				return nil, query.Errorf(v.Pos, "unknown priority %q (want %s)", v.Text, strings.Join(models.Priorities(), ", "))
+			}
+			return len(models.Priorities()) - 1 - i, nil
+		})
+	case "number":
+		return c.ordered(f, "t.number", func(v query.Value) (any, error) {
+			n, err := strconv.Atoi(v.Text)
+			if err != nil {
+				return nil, query.Errorf(v.Pos, "number must be an integer, got %q", v.Text)
+			}
+			return n, nil
+		})
+	case "due", "created", "updated":
+		column := map[string]string{"due": "t.due_date", "created": "t.created_at", "updated": "t.updated_at"}[f.Name]
+		if f.Name == "due" && len(f.Values) == 1 && f.Values[0].Text == "none" && !f.Op.Ordered() {
+			cond := "t.due_date IS NULL"
+			if f.Op == query.OpNe {
+				cond = "t.due_date IS NOT NULL"
+			}
+			return cond, nil
+		}
+		return c.ordered(f, "substr(CAST("+column+" AS TEXT), 1, 10)", func(v query.Value) (any, error) {
+			return queryDate(v)
+		})
+	case "is":
+		return c.equality(f, nil, func(v query.Value) error {
+			if !slices.Contains(ticketQueryStates, v.Text) {
+				return query.Errorf(v.Pos, "unknown state %q (want %s)", v.Text, strings.Join(ticketQueryStates, ", "))
+			}
+			return nil
+		})
+	}
+
+	fields, err := c.s.customFields(" AND key = ?", f.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) > 0 {
+		return c.customField(f, fields)
+	}
+	return "", query.Errorf(f.Pos, "unknown field %q (want %s, or a custom field key)", f.Name, strings.Join(ticketQueryFields, ", "))
+}
+
+// customField compiles a comparison against the custom fields with the
+// key f names, one per project that defines it. Number and date fields
+// support every comparison and the others only : and :!. "none" matches
+// tickets without a value.
+func (c *ticketQueryCompiler) customField(f *query.Field, fields []models.CustomField) (string, error) {
+	op := "="
+	if f.Op.Ordered() {
+		for _, cf := range fields {
+			if cf.Type != models.FieldNumber && cf.Type != models.FieldDate {
+				return "", query.Errorf(f.Pos, "%s only supports : and :!", f.Name)
+			}
+		}
+		op = string(f.Op)
+	}
+	return c.eachValue(f, func(v query.Value) (string, error) {
+		if v.Text == "none" && !f.Op.Ordered() {
+			c.bind(f.Name)
+			return `NOT EXISTS (SELECT 1 FROM ticket_field_values fv JOIN custom_fields cf ON cf.id = fv.field_id
+				WHERE fv.ticket_id = t.id AND cf.key = ?)`, nil
+		}
+		// A value that cannot match one project's field, such as an
+		// option only another project has, only leaves that field out.
+		var parts []string
+		var firstErr error
+		for _, cf := range fields {
+			cond, arg, err := customFieldCondition(cf, op, v)
+			if err != nil {
+				firstErr = cmp.Or(firstErr, err)
+				continue
+			}
+			c.bind(cf.ID, arg)
+			parts = append(parts, "EXISTS (SELECT 1 FROM ticket_field_values fv WHERE fv.ticket_id = t.id AND fv.field_id = ? AND "+cond+")")
+		}
+		if len(parts) == 0 {
+			return "", firstErr
+		}
+		return "(" + strings.Join(parts, " OR ") + ")", nil
+	})
+}
+
+// customFieldCondition compares the value fv.value of field cf against v
+// with op, returning the condition and its argument.
+func customFieldCondition(cf models.CustomField, op string, v query.Value) (string, any, error) {
+	switch cf.Type {
+	case models.FieldNumber:
+		n, err := strconv.ParseFloat(v.Text, 64)
+		if err != nil {
+			return "", nil, query.Errorf(v.Pos, "%s must be a number, got %q", cf.Key, v.Text)
+		}
+		return "json_extract(fv.value, '$') " + op + " ?", n, nil
+	case models.FieldDate:
+		date, err := queryDate(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return "json_extract(fv.value, '$') " + op + " ?", date, nil
+	case models.FieldSelect, models.FieldMultiSelect:
+		option, err := fieldOption(cf, v.Text)
+		if err != nil {
+			return "", nil, query.Errorf(v.Pos, "unknown %s %q (want %s)", cf.Key, v.Text, strings.Join(cf.Options, ", "))
+		}
+		if cf.Type == models.FieldMultiSelect {
+			return "EXISTS (SELECT 1 FROM json_each(fv.value) WHERE value = ?)", option, nil
+		}
+		return "json_extract(fv.value, '$') = ?", option, nil
+	}
+	return "json_extract(fv.value, '$') = ? COLLATE NOCASE", v.Text, nil
+}
+
+// equality compiles a field that only supports : and :! comparisons. With a
+// nil cond the field is "is" and each value names a state.
+func (c *ticketQueryCompiler) equality(f *query.Field, cond func(string) string, checks ...func(query.Value) error) (string, error) {
+	if f.Op.Ordered() {
+		return "", query.Errorf(f.Pos, "%s only supports : and :!", f.Name)
+	}
+	for _, check := range checks {
+		for _, v := range f.Values {
+			if err := check(v); err != nil {
+				return "", err
+			}
+		}
+	}
+	if cond == nil {
+		cond = c.state
+	}
+	return c.eachValue(f, func(v query.Value) (string, error) { return cond(v.Text), nil })
+}
+
+// eachValue ORs the condition for every value and applies negation.
+func (c *ticketQueryCompiler) eachValue(f *query.Field, cond func(query.Value) (string, error)) (string, error) {
+	parts := make([]string, len(f.Values))
+	for i, v := range f.Values {
+		part, err := cond(v)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	joined := "(" + strings.Join(parts, " OR ") + ")"
+	if f.Op == query.OpNe {
+		return "NOT COALESCE(" + joined + ", 0)", nil
+	}
+	return joined, nil
+}
+
+// ordered compiles a field that supports every comparison against expr.
+func (c *ticketQueryCompiler) ordered(f *query.Field, expr string, parse func(query.Value) (any, error)) (string, error) {
+	values := make([]any, len(f.Values))
+	for i, v := range f.Values {
+		parsed, err := parse(v)
+		if err != nil {
+			return "", err
+		}
+		values[i] = parsed
+	}
+	if f.Op.Ordered() {
+		c.bind(values[0])
+		return expr + " " + string(f.Op) + " ?", nil
+	}
+	i := 0
+	return c.eachValue(f, func(query.Value) (string, error) {
+		c.bind(values[i])
+		i++
+		return expr + " = ?", nil
+	})
+}
+
+func (c *ticketQueryCompiler) state(v string) string {
+	switch v {
+	case "blocked":
+		return `EXISTS (SELECT 1 FROM ticket_relations r JOIN tickets b ON b.id = r.related_id
+			WHERE r.ticket_id = t.id AND r.type = 'blocked_by' AND b.deleted_at IS NULL AND ` + openBlockerCondition + `)`
+	case "assigned":
+		return "t.assignee_id IS NOT NULL"
+	case "unassigned":
+		return "t.assignee_id IS NULL"
+	case "overdue":
+		c.bind(time.Now().Format("2006-01-02"))
+		return "(substr(CAST(t.due_date AS TEXT), 1, 10) < ? AND NOT " + ticketIsDone + ")"
+	case "done":
+		return ticketIsDone
+	default: // open
+		return "NOT " + ticketIsDone
+	}
+}
+
+// checkStatus rejects statuses that no project's workflow defines, which
+// are almost always typos.
+func (c *ticketQueryCompiler) checkStatus(v query.Value) error {
+	if c.statuses == nil {
+		statuses, err := c.s.ListWorkflowStatuses()
+		if err != nil {
+			return err
+		}
+		c.statuses = statuses
+	}
+	if hasStatus(c.statuses, v.Text) {
+		return nil
+	}
+	keys := make([]string, len(c.statuses))
+	for i, st := range c.statuses {
+		keys[i] = st.Status
+	}
+	return query.Errorf(v.Pos, "unknown status %q (want %s)", v.Text, strings.Join(keys, ", "))
+}
+
+// queryDate accepts YYYY-MM-DD or today, tomorrow and yesterday.
+func queryDate(v query.Value) (any, error) {
+	now := time.Now()
+	switch strings.ToLower(v.Text) {
+	case "today":
+		return now.Format("2006-01-02"), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1).Format("2006-01-02"), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format("2006-01-02"), nil
+	}
+	if _, err := time.Parse("2006-01-02", v.Text); err != nil {
+		return nil, query.Errorf(v.Pos, "%q is not a date (use YYYY-MM-DD, today, tomorrow or yesterday)", v.Text)
+	}
+	return v.Text, nil
+}
+
+// compileFieldFilters compiles custom field filters, given as key and
+// value, into a condition on tickets t. Each matches like the key:value
+// query term. Unknown keys and values are reported in a *ValidationError.
+func (s *Store) compileFieldFilters(filters map[string]string) (string, []any, error) {
+	c := &ticketQueryCompiler{s: s}
+	v := &ValidationError{}
+	var parts []string
+	for _, key := range slices.Sorted(maps.Keys(filters)) {
+		fields, err := s.customFields(" AND key = ?", key)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(fields) == 0 {
+			v.add("fields."+key, "no project has a field %q", key)
+			continue
+		}
+		cond, err := c.customField(&query.Field{Name: key, Op: query.OpEq, Values: []query.Value{{Text: filters[key]}}}, fields)
+		var qerr *query.Error
+		if errors.As(err, &qerr) {
+			v.add("fields."+key, "%s", qerr.Msg)
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, cond)
+	}
+	if err := v.err(); err != nil {
+		return "", nil, err
+	}
+	return strings.Join(parts, " AND "), c.args, nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/query"
+)
+
+func TestTicketQuery(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	for _, req := range []models.CreateCustomFieldRequest{
+		{ProjectID: p.ID, Key: "points", Type: models.FieldNumber},
+		{ProjectID: p.ID, Key: "env", Type: models.FieldSelect, Options: []string{"prod", "staging"}},
+		{ProjectID: p.ID, Key: "browsers", Type: models.FieldMultiSelect, Options: []string{"chrome", "firefox"}},
+	} {
+		if _, err := s.CreateCustomField(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bug, err := s.CreateLabel(models.CreateLabelRequest{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := "2026-03-01"
+
+	// AUTH-1 blocks AUTH-2; AUTH-3 is done.
+	t1 := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login page crashes", Priority: "urgent",
+		Labels: []string{bug.ID}, DueDate: &due, Fields: map[string]any{"points": 5, "env": "prod", "browsers": []string{"chrome"}}})
+	mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Password reset email", Priority: "low",
+		BlockedBy: []string{t1.ID}, Fields: map[string]any{"points": 2, "env": "staging", "browsers": []string{"chrome", "firefox"}}})
+	mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: `Audit "remember me" cookie`, Status: "done", Priority: "high"})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"AUTH-1", "AUTH-2", "AUTH-3"}},
+		{"login", []string{"AUTH-1"}},
+		{`"remember me"`, []string{"AUTH-3"}},
+		{`text:"reset email"`, []string{"AUTH-2"}},
+		{"project:auth", []string{"AUTH-1", "AUTH-2", "AUTH-3"}},
+		{"status:done", []string{"AUTH-3"}},
+		{"status:!done", []string{"AUTH-1", "AUTH-2"}},
+		{"priority>=high", []string{"AUTH-1", "AUTH-3"}},
+		{"priority<medium", []string{"AUTH-2"}},
+		{"priority:low,urgent", []string{"AUTH-1", "AUTH-2"}},
+		{"number>1 number<=3", []string{"AUTH-2", "AUTH-3"}},
+		{"label:BUG", []string{"AUTH-1"}},
+		{"-label:bug", []string{"AUTH-2", "AUTH-3"}},
+		{"due<2026-04-01", []string{"AUTH-1"}},
+		{"-due<2026-04-01", []string{"AUTH-2", "AUTH-3"}},
+		{"due:none", []string{"AUTH-2", "AUTH-3"}},
+		{"is:blocked", []string{"AUTH-2"}},
+		{"is:done OR is:blocked", []string{"AUTH-2", "AUTH-3"}},
+		{"is:open priority:low OR priority:high", []string{"AUTH-2", "AUTH-3"}},
+		{"is:open (priority:low OR priority:high)", []string{"AUTH-2"}},
+		{"NOT (is:done OR is:blocked)", []string{"AUTH-1"}},
+		{"assignee:none", []string{"AUTH-1", "AUTH-2", "AUTH-3"}},
+
+		// Custom fields.
+		{"points>3", []string{"AUTH-1"}},
+		{"points:2", []string{"AUTH-2"}},
+		{"points:none", []string{"AUTH-3"}},
+		{"env:staging", []string{"AUTH-2"}},
+		{"env:!prod", []string{"AUTH-2", "AUTH-3"}},
+		{"browsers:firefox", []string{"AUTH-2"}},
+		{"browsers:chrome,firefox", []string{"AUTH-1", "AUTH-2"}},
+	}
+	for _, tt := range tests {
+		tickets, err := s.ListTickets(models.TicketFilter{Expr: tt.query})
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		got := displayKeys(tickets)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTicketQueryErrors(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	for _, req := range []models.CreateCustomFieldRequest{
+		{ProjectID: p.ID, Key: "points", Type: models.FieldNumber},
+		{ProjectID: p.ID, Key: "env", Type: models.FieldSelect, Options: []string{"prod", "staging"}},
+	} {
+		if _, err := s.CreateCustomField(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"colour:red", 1, `unknown field "colour"`},
+		{"a status:doing", 10, `unknown status "doing"`},
+		{"priority>=hihg", 11, `unknown priority "hihg"`},
+		{"label>bug", 1, "label only supports : and :!"},
+		{"due<soon", 5, `"soon" is not a date`},
+		{"number:one", 8, "number must be an integer"},
+		{"is:stuck", 4, `unknown state "stuck"`},
+		{"text>login", 1, "text only supports : and :!"},
+		{"points>many", 8, "points must be a number"},
+		{"env:dev", 5, `unknown env "dev"`},
+		{"env>prod", 1, "env only supports : and :!"},
+		{"-(a", 2, `missing ")"`},
+	}
+	for _, tt := range tests {
+		_, err := s.ListTickets(models.TicketFilter{Expr: tt.query})
+		var qerr *query.Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("%q = %v, want a *query.Error", tt.query, err)
+			continue
+		}
+		if qerr.Pos != tt.pos || !strings.Contains(qerr.Msg, tt.msg) {
+			t.Errorf("%q = %d: %s, want %d: ...%s...", tt.query, qerr.Pos, qerr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+const timeEntryColumns = "id, ticket_id, minutes, note, date, author, created_at"
+
+func scanTimeEntry(row interface{ Scan(...any) error }) (*models.TimeEntry, error) {
+	var e models.TimeEntry
+	err := row.Scan(&e.ID, &e.TicketID, &e.Minutes, &e.Note, &e.Date, &e.Author, &e.CreatedAt)
+	return &e, err
+}
+
+// ListTimeEntries returns the time logged on a ticket, oldest first.
+func (s *Store) ListTimeEntries(ticketID string) ([]models.TimeEntry, error) {
+	rows, err := s.db.Query("SELECT "+timeEntryColumns+" FROM time_entries WHERE ticket_id = ? ORDER BY date, created_at", ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TimeEntry
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *Store) GetTimeEntry(id string) (*models.TimeEntry, error) {
+	e, err := scanTimeEntry(s.db.QueryRow("SELECT "+timeEntryColumns+" FROM time_entries WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// LogTime records time spent on a ticket. It returns nil if the ticket does
+// not exist.
+func (s *Store) LogTime(ticketID string, req models.LogTimeRequest) (*models.TimeEntry, error) {
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tickets WHERE id = ? AND deleted_at IS NULL", ticketID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, nil
+	}
+
+	v := &ValidationError{}
+	e := models.TimeEntry{
+		ID:        newID(),
+		TicketID:  ticketID,
+		Minutes:   parseMinutes(v, "duration", req.Duration),
+		Note:      req.Note,
+		Author:    req.Author,
+		CreatedAt: time.Now(),
+	}
+	if req.Date == "" {
+		e.Date, _ = time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	} else if date := parseDate(v, "date", req.Date); date != nil {
+		e.Date = *date
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if e.Author == "" {
+		e.Author = s.actor.Name
+	}
+	if e.Author == "" {
+		e.Author = "anonymous"
+	}
+
+	_, err := s.db.Exec("INSERT INTO time_entries ("+timeEntryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.ID, e.TicketID, e.Minutes, e.Note, e.Date, e.Author, e.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting time entry: %w", err)
+	}
+	return &e, nil
+}
+
+func (s *Store) DeleteTimeEntry(id string) error {
+	_, err := s.db.Exec("DELETE FROM time_entries WHERE id = ?", id)
+	return err
+}
+
+// parseMinutes reads a duration such as 2h, 45m or 1h30m as whole minutes,
+// recording a field error unless it is at least a minute.
+func parseMinutes(v *ValidationError, field, value string) int {
+	if value == "" {
+		v.add(field, "is required")
+		return 0
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		v.add(field, "%q is not a duration like 2h, 45m or 1h30m", value)
+		return 0
+	}
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 1 {
+		v.add(field, "must be at least a minute")
+	}
+	return minutes
+}
+
+// TimeRollups totals estimates and logged time per project or per team,
+// listing every project or team even if nothing was estimated or logged.
+func (s *Store) TimeRollups(groupBy string) ([]models.TimeRollup, error) {
+	var groups, key string
+	switch groupBy {
+	case models.RollupByProject:
+		groups, key = "projects", "project_id"
+	case models.RollupByTeam:
+		groups, key = "teams", "team_id"
+	default:
+		v := &ValidationError{}
+		v.add("by", "must be %s or %s, got %q", models.RollupByProject, models.RollupByTeam, groupBy)
+		return nil, v
+	}
+
+	rows, err := s.db.Query(`SELECT g.id, g.name, COUNT(t.id), COUNT(t.estimate),
+		COALESCE(SUM(CASE WHEN p.estimate_unit = 'hours' THEN 0 ELSE t.estimate END), 0),
+		COALESCE(SUM(CASE WHEN p.estimate_unit = 'hours' THEN t.estimate ELSE 0 END), 0),
+		COALESCE(SUM(te.minutes), 0)
+		FROM ` + groups + ` g
+		LEFT JOIN tickets t ON t.` + key + ` = g.id AND t.deleted_at IS NULL AND ` + inLiveProject("t.project_id") + `
+		LEFT JOIN projects p ON p.id = t.project_id
+		LEFT JOIN (SELECT ticket_id, SUM(minutes) AS minutes FROM time_entries GROUP BY ticket_id) te ON te.ticket_id = t.id
+		WHERE g.deleted_at IS NULL
+		GROUP BY g.id ORDER BY g.name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []models.TimeRollup{}
+	for rows.Next() {
+		var r models.TimeRollup
+		if err := rows.Scan(&r.ID, &r.Name, &r.Tickets, &r.EstimatedTickets, &r.EstimatedPoints, &r.EstimatedHours, &r.LoggedMinutes); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, rows.Err()
+}
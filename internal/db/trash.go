@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ErrProjectInTrash is returned when restoring a ticket whose project is in
+// the trash.
+var ErrProjectInTrash = errors.New("the ticket's project is in the trash; restore the project first")
+
+// trashTables are the tables whose rows are moved to the trash by setting
+// deleted_at rather than deleted.
+var trashTables = map[string]bool{"projects": true, "tickets": true, "teams": true, "labels": true}
+
+// inLiveProject is the condition that column names a project that is not in
+// the trash.
+func inLiveProject(column string) string {
+	return column + " IN (SELECT id FROM projects WHERE deleted_at IS NULL)"
+}
+
+// trashQuery selects every item in the trash. Tickets deleted along with
+// their project share its deleted_at and are marked as bundled.
+const trashQuery = `SELECT * FROM (
+	SELECT 'project' AS type, p.id, p.name, p.id AS project_id,
+		(SELECT COUNT(*) FROM tickets t WHERE t.project_id = p.id AND t.deleted_at = p.deleted_at) AS tickets,
+		0 AS bundled, p.deleted_at
+	FROM projects p WHERE p.deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'ticket', t.id, COALESCE(p.prefix || '-', '') || t.number || ' ' || t.title, t.project_id, 0,
+		COALESCE(p.deleted_at = t.deleted_at, 0), t.deleted_at
+	FROM tickets t LEFT JOIN projects p ON p.id = t.project_id WHERE t.deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'team', id, name, '', 0, 0, deleted_at FROM teams WHERE deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'label', id, name, '', 0, 0, deleted_at FROM labels WHERE deleted_at IS NOT NULL
+)`
+
+// trashEntry is a trash item and whether it went with its project.
+type trashEntry struct {
+	models.TrashItem
+	bundled bool
+}
+
+func (s *Store) trashEntries(where string, args ...any) ([]trashEntry, error) {
+	rows, err := s.db.Query(trashQuery+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []trashEntry
+	for rows.Next() {
+		var e trashEntry
+		if err := rows.Scan(&e.Type, &e.ID, &e.Name, &e.ProjectID, &e.Tickets, &e.bundled, &e.DeletedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ListTrash returns what is in the trash, most recently deleted first.
+func (s *Store) ListTrash() ([]models.TrashItem, error) {
+	entries, err := s.trashEntries(" WHERE NOT bundled")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]models.TrashItem, len(entries))
+	for i, e := range entries {
+		items[i] = e.TrashItem
+	}
+	slices.SortStableFunc(items, func(a, b models.TrashItem) int { return b.DeletedAt.Compare(a.DeletedAt) })
+	return items, nil
+}
+
+// RestoreFromTrash takes the project, ticket, team or label with the given ID
+// out of the trash and returns it as it was listed there. A project comes
+// back with the tickets deleted along with it. It returns nil if nothing in
+// the trash has that ID, and ErrProjectInTrash for a ticket whose project
+// is still in the trash.
+func (s *Store) RestoreFromTrash(id string) (*models.TrashItem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	entries, err := s.inTx(tx).trashEntries(" WHERE id = ?", id)
+	if err != nil || len(entries) == 0 {
+		tx.Rollback()
+		return nil, err
+	}
+	item := entries[0].TrashItem
+	if err := s.restore(tx, item); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Store) restore(tx *sql.Tx, item models.TrashItem) error {
+	switch item.Type {
+	case models.TrashProject:
+		tickets, err := ticketRefs(tx, "SELECT id FROM tickets WHERE project_id = ? AND deleted_at = (SELECT deleted_at FROM projects WHERE id = ?)",
+			item.ID, item.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE tickets SET deleted_at = NULL
+			WHERE project_id = ? AND deleted_at = (SELECT deleted_at FROM projects WHERE id = ?)`, item.ID, item.ID)
+		if err != nil {
+			return fmt.Errorf("restoring tickets: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE projects SET deleted_at = NULL WHERE id = ?", item.ID); err != nil {
+			return err
+		}
+		for _, t := range tickets {
+			if err := s.recordTicketEvent(tx, t, models.EventRestored, nil); err != nil {
+				return fmt.Errorf("recording activity: %w", err)
+			}
+		}
+		return nil
+	case models.TrashTicket:
+		var live int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM projects WHERE id = ? AND deleted_at IS NULL", item.ProjectID).Scan(&live); err != nil {
+			return err
+		}
+		if live == 0 {
+			return ErrProjectInTrash
+		}
+		if _, err := tx.Exec("UPDATE tickets SET deleted_at = NULL WHERE id = ?", item.ID); err != nil {
+			return err
+		}
+		t, _, err := ticketRef(tx, item.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordTicketEvent(tx, t, models.EventRestored, nil); err != nil {
+			return fmt.Errorf("recording activity: %w", err)
+		}
+		return nil
+	case models.TrashTeam:
+		_, err := tx.Exec("UPDATE teams SET deleted_at = NULL WHERE id = ?", item.ID)
+		return err
+	case models.TrashLabel:
+		if _, err := tx.Exec("UPDATE labels SET deleted_at = NULL WHERE id = ?", item.ID); err != nil {
+			return err
+		}
+		return s.recordLabelShown(tx, item.ID, true)
+	}
+	return fmt.Errorf("unknown trash item type %q", item.Type)
+}
+
+// ticketRefs reads what the activity log needs to name the tickets selected
+// by query, with args, which returns their IDs.
+func ticketRefs(e querier, query string, args ...any) ([]*models.Ticket, error) {
+	rows, err := e.Query(`SELECT t.id, t.project_id, t.number, COALESCE(p.prefix, '')
+		FROM tickets t LEFT JOIN projects p ON p.id = t.project_id WHERE t.id IN (`+query+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []*models.Ticket
+	for rows.Next() {
+		t := &models.Ticket{}
+		if err := rows.Scan(&t.ID, &t.ProjectID, &t.Number, &t.ProjectPrefix); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
+
+// recordLabelShown records a labels change on the live tickets carrying a
+// label that has just been restored, or moved to the trash when restored is
+// false, as it appears on or disappears from them.
+func (s *Store) recordLabelShown(e querier, labelID string, restored bool) error {
+	tickets, err := ticketRefs(e, `SELECT ticket_id FROM ticket_labels
+		WHERE label_id = ? AND ticket_id IN (SELECT id FROM tickets WHERE deleted_at IS NULL)`, labelID)
+	if err != nil {
+		return err
+	}
+	for _, t := range tickets {
+		rows, err := e.Query(`SELECT tl.label_id FROM ticket_labels tl JOIN labels l ON l.id = tl.label_id
+			WHERE tl.ticket_id = ? AND tl.label_id != ? AND l.deleted_at IS NULL`, t.ID, labelID)
+		if err != nil {
+			return err
+		}
+		var others []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			others = append(others, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		change := fieldChange{"labels", idsValue(append(others, labelID)), idsValue(others)}
+		if restored {
+			change.oldValue, change.newValue = change.newValue, change.oldValue
+		}
+		if err := s.recordTicketEvent(e, t, models.EventUpdated, []fieldChange{change}); err != nil {
+			return fmt.Errorf("recording activity: %w", err)
+		}
+	}
+	return nil
+}
+
+// trashItemTables are the tables that PurgeTrash deletes each type of trash
+// item from.
+var trashItemTables = map[string]string{
+	models.TrashProject: "projects",
+	models.TrashTicket:  "tickets",
+	models.TrashTeam:    "teams",
+	models.TrashLabel:   "labels",
+}
+
+// EmptyTrash permanently deletes everything in the trash and returns how
+// many items were removed.
+func (s *Store) EmptyTrash() (int, error) {
+	return s.PurgeTrash(time.Now())
+}
+
+// PurgeTrash permanently deletes everything that went into the trash at or
+// before the given time, along with everything that belongs to it, and
+// returns how many trash items were removed. Tickets deleted along with
+// their project are not counted separately.
+func (s *Store) PurgeTrash(before time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	entries, err := s.inTx(tx).trashEntries("")
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	purged := 0
+	for _, e := range entries {
+		if e.DeletedAt.After(before) {
+			continue
+		}
+		table, ok := trashItemTables[e.Type]
+		if !ok {
+			tx.Rollback()
+			return 0, fmt.Errorf("unknown trash item type %q", e.Type)
+		}
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE id = ?", e.ID); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("purging %s %s: %w", e.Type, e.ID, err)
+		}
+		if !e.bundled {
+			purged++
+		}
+	}
+	if err := deleteUnusedBlobs(tx); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
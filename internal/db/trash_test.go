@@ -0,0 +1,197 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// count returns the number of rows q finds, failing the test on error.
+func count(t *testing.T, s *Store, q string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := s.db.QueryRow(q, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// history returns the actions in a ticket's history, with the field for
+// updates, as in "updated labels".
+func history(t *testing.T, s *Store, ticketID string) []string {
+	t.Helper()
+	events, err := s.GetTicketHistory(ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]string, len(events))
+	for i, e := range events {
+		actions[i] = e.Action
+		if e.Field != "" {
+			actions[i] += " " + e.Field
+		}
+	}
+	return actions
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	alone := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Deleted first"})
+	kept := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Deleted with the project"})
+	r, err := s.CreateRecurrence(models.CreateRecurrenceRequest{ProjectID: p.ID, Rule: "@daily",
+		Ticket: models.CreateTicketRequest{Title: "Rotate logs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTicket(alone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteProject(p.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := s.ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Type != models.TrashProject || items[0].Tickets != 1 || items[1].ID != alone.ID {
+		t.Fatalf("trash = %+v, want the project holding one ticket, then AUTH-1", items)
+	}
+	if got, err := s.GetTicket(kept.ID); err != nil || got != nil {
+		t.Errorf("GetTicket of a trashed ticket = %v, %v, want nil", got, err)
+	}
+
+	if got, err := s.GetRecurrence(r.ID); err != nil || got != nil {
+		t.Errorf("GetRecurrence in a trashed project = %v, %v, want nil", got, err)
+	}
+	if _, err := s.RestoreFromTrash(alone.ID); !errors.Is(err, ErrProjectInTrash) {
+		t.Errorf("restoring AUTH-1 before its project: got %v, want ErrProjectInTrash", err)
+	}
+	if _, err := s.RestoreFromTrash(p.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustGetTicket(t, s, kept.ID)
+	if got := history(t, s, kept.ID); !slices.Equal(got, []string{"created", "deleted", "restored"}) {
+		t.Errorf("AUTH-2 history = %v, want it created, deleted and restored", got)
+	}
+	if got, err := s.GetTicket(alone.ID); err != nil || got != nil {
+		t.Errorf("AUTH-1 came back with its project: %v, %v", got, err)
+	}
+	if _, err := s.RestoreFromTrash(alone.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustGetTicket(t, s, alone.ID)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	bug, err := s.CreateLabel(models.CreateLabelRequest{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocker := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocker", Labels: []string{bug.ID}})
+	blocked := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocked", BlockedBy: []string{blocker.ID}})
+	if _, err := s.AddSubtask(blocker.ID, models.CreateSubtaskRequest{Title: "Step"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTicket(blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing older than the deletion goes.
+	if n, err := s.PurgeTrash(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("purging before the deletion: got %d, %v, want nothing purged", n, err)
+	}
+	if n, err := s.EmptyTrash(); err != nil || n != 1 {
+		t.Fatalf("emptying the trash: got %d, %v, want one item purged", n, err)
+	}
+
+	for _, c := range []struct{ name, q string }{
+		{"ticket", "SELECT COUNT(*) FROM tickets WHERE id = ?"},
+		{"links", "SELECT COUNT(*) FROM ticket_relations WHERE ticket_id = ?1 OR related_id = ?1"},
+		{"labels", "SELECT COUNT(*) FROM ticket_labels WHERE ticket_id = ?"},
+		{"subtasks", "SELECT COUNT(*) FROM subtasks WHERE ticket_id = ?"},
+	} {
+		if n := count(t, s, c.q, blocker.ID); n != 0 {
+			t.Errorf("%d %s rows left for the purged ticket", n, c.name)
+		}
+	}
+	if got := mustGetTicket(t, s, blocked.ID).BlockedBy; len(got) != 0 {
+		t.Errorf("AUTH-2 is still blocked by %v", got)
+	}
+	if items, err := s.ListTrash(); err != nil || len(items) != 0 {
+		t.Errorf("trash after emptying = %v, %v, want empty", items, err)
+	}
+}
+
+func TestEditKeepsTrashedLinks(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	var labels []*models.Label
+	for _, name := range []string{"bug", "ui", "backend"} {
+		l, err := s.CreateLabel(models.CreateLabelRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels = append(labels, l)
+	}
+	bug, ui, backend := labels[0], labels[1], labels[2]
+	trashedBlocker := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Trashed blocker"})
+	liveBlocker := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Live blocker"})
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login",
+		Labels: []string{bug.ID, ui.ID}, BlockedBy: []string{trashedBlocker.ID, liveBlocker.ID}})
+
+	if err := s.DeleteLabel(bug.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTicket(trashedBlocker.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Labels: []string{backend.ID}, BlockedBy: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{bug.ID, trashedBlocker.ID} {
+		if _, err := s.RestoreFromTrash(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := mustGetTicket(t, s, ticket.ID)
+	var names []string
+	for _, l := range got.Labels {
+		names = append(names, l.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"backend", "bug"}) {
+		t.Errorf("labels after restoring bug = %v, want [backend bug]", names)
+	}
+	if !slices.Equal(got.BlockedBy, []string{trashedBlocker.ID}) {
+		t.Errorf("blocked by %v after restoring the trashed blocker, want only it", got.BlockedBy)
+	}
+}
+
+func TestTrashLabelHistory(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	bug, err := s.CreateLabel(models.CreateLabelRequest{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login", Labels: []string{bug.ID}})
+
+	// The label leaving and coming back shows in the ticket's history.
+	if err := s.DeleteLabel(bug.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreFromTrash(bug.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := history(t, s, ticket.ID); !slices.Equal(got, []string{"created", "updated labels", "updated labels"}) {
+		t.Errorf("history = %v, want the label removed and added back", got)
+	}
+}
@@ -0,0 +1,605 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// undoStackSize is how many of their latest changes an actor can undo.
+const undoStackSize = 100
+
+// ErrNoActor is returned by Undo and Redo for a store without an actor name:
+// everyone who leaves it unset would share one undo stack.
+var ErrNoActor = errors.New("cannot undo or redo without knowing whose changes they are")
+
+// ConflictError is returned when a change cannot be undone or redone because
+// what it touched has been changed again since.
+type ConflictError struct {
+	Op      string // "undo" or "redo"
+	Summary string
+	Reason  string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("cannot %s %q: %s", e.Op, e.Summary, e.Reason)
+}
+
+// ticketState is what undoing a ticket change restores. DueDate is a
+// YYYY-MM-DD date, Labels and BlockedBy are sorted IDs and Fields is nil
+// without custom field values, so that equal states encode to equal JSON.
+type ticketState struct {
+	ProjectID   string         `json:"projectId"`
+	TeamID      *string        `json:"teamId"`
+	AssigneeID  *string        `json:"assigneeId"`
+	SprintID    *string        `json:"sprintId"`
+	MilestoneID *string        `json:"milestoneId"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Priority    string         `json:"priority"`
+	DueDate     string         `json:"dueDate"`
+	Estimate    *float64       `json:"estimate"`
+	Position    float64        `json:"position"`
+	Labels      []string       `json:"labels"`
+	BlockedBy   []string       `json:"blockedBy"`
+	Fields      map[string]any `json:"fields"`
+}
+
+func newTicketState(t *models.Ticket, labels, blockedBy []string) *ticketState {
+	st := &ticketState{
+		ProjectID:   t.ProjectID,
+		TeamID:      t.TeamID,
+		AssigneeID:  t.AssigneeID,
+		SprintID:    t.SprintID,
+		MilestoneID: t.MilestoneID,
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		DueDate:     dateValue(t.DueDate),
+		Estimate:    t.Estimate,
+		Position:    t.Position,
+		Labels:      sortedIDs(labels),
+		BlockedBy:   sortedIDs(blockedBy),
+		Fields:      t.Fields,
+	}
+	if len(st.Fields) == 0 {
+		st.Fields = nil
+	}
+	return st
+}
+
+// ticket returns the ticket fields of the state, for the activity log.
+func (st *ticketState) ticket(id string) *models.Ticket {
+	t := &models.Ticket{
+		ID:          id,
+		ProjectID:   st.ProjectID,
+		TeamID:      st.TeamID,
+		AssigneeID:  st.AssigneeID,
+		SprintID:    st.SprintID,
+		MilestoneID: st.MilestoneID,
+		Title:       st.Title,
+		Description: st.Description,
+		Status:      st.Status,
+		Priority:    st.Priority,
+		Estimate:    st.Estimate,
+		Position:    st.Position,
+		Fields:      st.Fields,
+	}
+	if st.DueDate != "" {
+		due, _ := time.Parse("2006-01-02", st.DueDate)
+		t.DueDate = &due
+	}
+	return t
+}
+
+func sortedIDs(ids []string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(append([]string{}, ids...))))
+}
+
+// projectState is what undoing a project change restores.
+type projectState struct {
+	Name              string `json:"name"`
+	Prefix            string `json:"prefix"`
+	Description       string `json:"description"`
+	Icon              string `json:"icon"`
+	Color             string `json:"color"`
+	Status            string `json:"status"`
+	BlockedMovePolicy string `json:"blockedMovePolicy"`
+	EstimateUnit      string `json:"estimateUnit"`
+}
+
+func newProjectState(p *models.Project) *projectState {
+	return &projectState{
+		Name:              p.Name,
+		Prefix:            p.Prefix,
+		Description:       p.Description,
+		Icon:              p.Icon,
+		Color:             p.Color,
+		Status:            p.Status,
+		BlockedMovePolicy: p.BlockedMovePolicy,
+		EstimateUnit:      p.EstimateUnit,
+	}
+}
+
+// subtaskState is what undoing a subtask change restores.
+type subtaskState struct {
+	TicketID  string `json:"ticketId"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+	Position  int    `json:"position"`
+}
+
+func newSubtaskState(st *models.Subtask) *subtaskState {
+	return &subtaskState{TicketID: st.TicketID, Title: st.Title, Completed: st.Completed, Position: st.Position}
+}
+
+// recordChange pushes a change made by the store's actor onto their undo
+// stack, clearing their redo stack. before and after are the entity's
+// states, nil when it did not exist or was in the trash. Changes that leave
+// the state as it was are not recorded.
+func (s *Store) recordChange(e execer, entity, id, summary string, before, after any) error {
+	beforeJSON, err := stateJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := stateJSON(after)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(beforeJSON, afterJSON) {
+		return nil
+	}
+
+	if _, err := e.Exec("DELETE FROM undo_log WHERE actor = ? AND source = ? AND undone_at IS NOT NULL",
+		s.actor.Name, s.actor.Source); err != nil {
+		return fmt.Errorf("clearing redo stack: %w", err)
+	}
+	_, err = e.Exec(`INSERT INTO undo_log (actor, source, entity, entity_id, summary, before, after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.actor.Name, s.actor.Source, entity, id, summary, nullJSON(beforeJSON), nullJSON(afterJSON), time.Now())
+	if err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	_, err = e.Exec(`DELETE FROM undo_log WHERE actor = ? AND source = ? AND id NOT IN
+		(SELECT id FROM undo_log WHERE actor = ? AND source = ? ORDER BY id DESC LIMIT ?)`,
+		s.actor.Name, s.actor.Source, s.actor.Name, s.actor.Source, undoStackSize)
+	return err
+}
+
+// stateJSON encodes an entity state, returning nil for a nil state.
+func stateJSON(state any) (json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func nullJSON(data json.RawMessage) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+// Undo reverts the latest change the store's actor has not undone yet and
+// returns it. It returns nil when there is nothing to undo, a *ConflictError
+// when a later change touched the same fields, and ErrNoActor when the actor
+// has no name.
+func (s *Store) Undo() (*models.Change, error) {
+	return s.replay(true)
+}
+
+// Redo applies again the change the store's actor undid last. It returns
+// nil when there is nothing to redo, and a *ConflictError when a later
+// change touched the same fields.
+func (s *Store) Redo() (*models.Change, error) {
+	return s.replay(false)
+}
+
+func (s *Store) replay(undo bool) (*models.Change, error) {
+	if s.actor.Name == "" {
+		return nil, ErrNoActor
+	}
+	// Undo takes the newest change that is not undone; redo the oldest one
+	// that is, which is the one undone last.
+	q := "SELECT id, entity, entity_id, summary, actor, source, before, after, created_at FROM undo_log WHERE actor = ? AND source = ?"
+	op := "undo"
+	if undo {
+		q += " AND undone_at IS NULL ORDER BY id DESC LIMIT 1"
+	} else {
+		q += " AND undone_at IS NOT NULL ORDER BY id LIMIT 1"
+		op = "redo"
+	}
+
+	// The transaction takes the write lock up front, so nothing can change
+	// between checking the current state and writing the new one.
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	var c models.Change
+	var before, after sql.NullString
+	err = tx.QueryRow(q, s.actor.Name, s.actor.Source).Scan(&c.ID, &c.Entity, &c.EntityID, &c.Summary,
+		&c.Actor, &c.Source, &before, &after, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return nil, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	from, to := rawState(after), rawState(before)
+	if !undo {
+		from, to = to, from
+	}
+	if err := s.applyChange(tx, &c, op, from, to); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var undoneAt any
+	if undo {
+		undoneAt = time.Now()
+	}
+	if _, err := tx.Exec("UPDATE undo_log SET undone_at = ? WHERE id = ?", undoneAt, c.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func rawState(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
+
+// applyChange takes the entity of c from state from to state to. Only the
+// fields that differ between the two are written, so later changes to other
+// fields are kept; if any of those fields no longer holds its from value, a
+// *ConflictError is returned instead.
+func (s *Store) applyChange(tx *sql.Tx, c *models.Change, op string, from, to json.RawMessage) error {
+	ts := s.inTx(tx)
+	current, err := ts.currentState(c.Entity, c.EntityID)
+	if err != nil {
+		return err
+	}
+	conflict := func(reason string) error {
+		return &ConflictError{Op: op, Summary: c.Summary, Reason: reason + s.laterChangeBy(tx, c)}
+	}
+
+	var target json.RawMessage
+	switch {
+	case from == nil:
+		if current != nil {
+			return conflict("it has been restored since")
+		}
+		target = to
+	case current == nil:
+		return conflict("it has been deleted since")
+	case to == nil:
+		same, err := sameState(c.Entity, current, from)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return conflict("it has been changed since")
+		}
+	default:
+		var fromFields, toFields, fields map[string]json.RawMessage
+		if err := json.Unmarshal(from, &fromFields); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(to, &toFields); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(current, &fields); err != nil {
+			return err
+		}
+		var changed []string
+		for name, value := range toFields {
+			if bytes.Equal(value, fromFields[name]) {
+				continue
+			}
+			if !bytes.Equal(fields[name], fromFields[name]) && !unchecked(c.Entity, name) {
+				changed = append(changed, name)
+			}
+			fields[name] = value
+		}
+		if len(changed) > 0 {
+			slices.Sort(changed)
+			return conflict(strings.Join(changed, ", ") + " changed since")
+		}
+		if target, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+
+	if c.Entity == models.ChangeTicket && target != nil {
+		reason, err := ts.checkTicketState(tx, c.EntityID, current, target)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return &ConflictError{Op: op, Summary: c.Summary, Reason: reason}
+		}
+	}
+
+	switch c.Entity {
+	case models.ChangeTicket:
+		return s.writeTicketState(tx, c.EntityID, current, target)
+	case models.ChangeProject:
+		return s.writeProjectState(tx, c.EntityID, target)
+	case models.ChangeSubtask:
+		return s.writeSubtaskState(tx, c.EntityID, target)
+	}
+	return fmt.Errorf("unknown entity %q", c.Entity)
+}
+
+// checkTicketState returns why ticket id cannot go from state current, nil
+// when it is in the trash, to state target, or "" if it can. The status,
+// team, sprint or milestone it goes back to may be gone since, and the
+// column it goes back to may be at a WIP limit with the block policy.
+func (s *Store) checkTicketState(tx *sql.Tx, id string, current, target json.RawMessage) (string, error) {
+	var st ticketState
+	if err := json.Unmarshal(target, &st); err != nil {
+		return "", err
+	}
+	t := st.ticket(id)
+	ref, _, err := ticketRef(tx, id)
+	if err != nil {
+		return "", err
+	}
+	before := &models.Ticket{ID: id, ProjectID: st.ProjectID, Number: ref.Number, ProjectPrefix: ref.ProjectPrefix}
+	var old *models.Ticket
+	if current != nil {
+		var cur ticketState
+		if err := json.Unmarshal(current, &cur); err != nil {
+			return "", err
+		}
+		old = cur.ticket(id)
+		before.Status, before.TeamID = old.Status, old.TeamID
+	}
+
+	v := &ValidationError{}
+	if err := s.validateTicket(v, old, t, nil, nil); err != nil {
+		return "", err
+	}
+	if len(v.Fields) > 0 {
+		reasons := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			reasons[i] = f.Message
+		}
+		return strings.Join(reasons, "; "), nil
+	}
+	if old == nil || old.Status != t.Status || stringValue(old.TeamID) != stringValue(t.TeamID) {
+		if _, err := s.checkWIPLimits(before, t.Status, t.TeamID); errors.Is(err, ErrWIPLimit) {
+			return err.Error(), nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// unchecked reports whether field of entity is left out of conflict checks.
+// A ticket's position also changes when its column is renumbered to make room
+// for another ticket, which records no change of its own.
+func unchecked(entity, field string) bool {
+	return entity == models.ChangeTicket && field == "position"
+}
+
+// sameState reports whether states a and b of entity are equal in every field
+// that is checked for conflicts.
+func sameState(entity string, a, b json.RawMessage) (bool, error) {
+	var aFields, bFields map[string]json.RawMessage
+	if err := json.Unmarshal(a, &aFields); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &bFields); err != nil {
+		return false, err
+	}
+	if len(aFields) != len(bFields) {
+		return false, nil
+	}
+	for name, value := range aFields {
+		if !unchecked(entity, name) && !bytes.Equal(value, bFields[name]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// laterChangeBy names who last changed the entity of c after c, as " by
+// alice (web)", or returns "" if that was not recorded.
+func (s *Store) laterChangeBy(tx *sql.Tx, c *models.Change) string {
+	var actor, source string
+	err := tx.QueryRow(`SELECT actor, source FROM undo_log WHERE entity_id = ? AND id > ? AND undone_at IS NULL
+		ORDER BY id DESC LIMIT 1`, c.EntityID, c.ID).Scan(&actor, &source)
+	if err != nil {
+		return ""
+	}
+	if actor == "" {
+		return " via " + source
+	}
+	return fmt.Sprintf(" by %s (%s)", actor, source)
+}
+
+// currentState returns the state of an entity as it would be recorded now,
+// or nil if it does not exist or is in the trash.
+func (s *Store) currentState(entity, id string) (json.RawMessage, error) {
+	var state any
+	switch entity {
+	case models.ChangeTicket:
+		t, err := s.GetTicket(id)
+		if err != nil || t == nil {
+			return nil, err
+		}
+		state = newTicketState(t, labelIDs(t.Labels), t.BlockedBy)
+	case models.ChangeProject:
+		p, err := s.GetProject(id)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		state = newProjectState(p)
+	case models.ChangeSubtask:
+		st, err := s.getSubtask(id)
+		if err != nil || st == nil {
+			return nil, err
+		}
+		state = newSubtaskState(st)
+	default:
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+	return stateJSON(state)
+}
+
+// writeTicketState moves a ticket to the trash when state is nil, and
+// otherwise takes it out of the trash if needed and writes state to it.
+// Links to trashed labels and blockers are left alone.
+func (s *Store) writeTicketState(tx *sql.Tx, id string, current, state json.RawMessage) error {
+	ref, deleted, err := ticketRef(tx, id)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		if _, err := tx.Exec("UPDATE tickets SET deleted_at = ? WHERE id = ?", time.Now(), id); err != nil {
+			return err
+		}
+		return s.recordTicketEvent(tx, ref, models.EventDeleted, nil)
+	}
+
+	var st ticketState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	if deleted {
+		if err := s.restore(tx, models.TrashItem{Type: models.TrashTicket, ID: id, ProjectID: ref.ProjectID}); err != nil {
+			return err
+		}
+	}
+	t := st.ticket(id)
+	_, err = tx.Exec(
+		`UPDATE tickets SET team_id=?, assignee_id=?, sprint_id=?, milestone_id=?, title=?, description=?, status=?, priority=?, due_date=?, estimate=?, position=?, updated_at=? WHERE id=?`,
+		t.TeamID, t.AssigneeID, t.SprintID, t.MilestoneID, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Estimate, t.Position, time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM ticket_labels WHERE ticket_id = ? AND label_id IN (SELECT id FROM labels WHERE deleted_at IS NULL)", id); err != nil {
+		return err
+	}
+	if err := addTicketLabels(tx, id, st.Labels); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM ticket_relations WHERE ticket_id = ? AND type = ?
+		AND related_id IN (SELECT id FROM tickets WHERE deleted_at IS NULL)`, id, models.RelationBlockedBy); err != nil {
+		return err
+	}
+	if err := addTicketBlockers(tx, id, st.BlockedBy); err != nil {
+		return err
+	}
+	if err := writeFieldValues(tx, id, st.ProjectID, st.Fields); err != nil {
+		return err
+	}
+
+	if current == nil {
+		return nil
+	}
+	var old ticketState
+	if err := json.Unmarshal(current, &old); err != nil {
+		return err
+	}
+	t.Number, t.ProjectPrefix = ref.Number, ref.ProjectPrefix
+	changes := ticketChanges(old.ticket(id), t)
+	if oldValue, newValue := idsValue(old.Labels), idsValue(st.Labels); oldValue != newValue {
+		changes = append(changes, fieldChange{"labels", oldValue, newValue})
+	}
+	if oldValue, newValue := idsValue(old.BlockedBy), idsValue(st.BlockedBy); oldValue != newValue {
+		changes = append(changes, fieldChange{"blockedBy", oldValue, newValue})
+	}
+	changes = append(changes, fieldChanges(old.Fields, st.Fields)...)
+	return s.recordTicketEvent(tx, t, models.EventUpdated, changes)
+}
+
+// ticketRef reads what the activity log needs to name a ticket, in the trash
+// or not, and whether it is in the trash.
+func ticketRef(tx *sql.Tx, id string) (*models.Ticket, bool, error) {
+	t := &models.Ticket{ID: id}
+	var deleted bool
+	err := tx.QueryRow(`SELECT t.project_id, t.number, COALESCE(p.prefix, ''), t.deleted_at IS NOT NULL
+		FROM tickets t LEFT JOIN projects p ON p.id = t.project_id WHERE t.id = ?`, id).
+		Scan(&t.ProjectID, &t.Number, &t.ProjectPrefix, &deleted)
+	if err == sql.ErrNoRows {
+		return nil, false, fmt.Errorf("ticket %s has been purged from the trash", id)
+	}
+	return t, deleted, err
+}
+
+// writeProjectState moves a project and its tickets to the trash when state
+// is nil, and otherwise takes it out of the trash if needed and writes state
+// to it.
+func (s *Store) writeProjectState(tx *sql.Tx, id string, state json.RawMessage) error {
+	var deleted bool
+	err := tx.QueryRow("SELECT deleted_at IS NOT NULL FROM projects WHERE id = ?", id).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("project %s has been purged from the trash", id)
+	}
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return s.trashProject(tx, id, time.Now())
+	}
+
+	var p projectState
+	if err := json.Unmarshal(state, &p); err != nil {
+		return err
+	}
+	if deleted {
+		if err := s.restore(tx, models.TrashItem{Type: models.TrashProject, ID: id}); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(
+		"UPDATE projects SET name=?, prefix=?, description=?, icon=?, color=?, status=?, blocked_move_policy=?, estimate_unit=?, updated_at=? WHERE id=?",
+		p.Name, p.Prefix, p.Description, p.Icon, p.Color, p.Status, p.BlockedMovePolicy, p.EstimateUnit, time.Now(), id,
+	)
+	return err
+}
+
+// writeSubtaskState deletes a subtask when state is nil, and otherwise
+// writes state to it, creating it again if needed.
+func (s *Store) writeSubtaskState(tx *sql.Tx, id string, state json.RawMessage) error {
+	if state == nil {
+		_, err := tx.Exec("DELETE FROM subtasks WHERE id = ?", id)
+		return err
+	}
+	var st subtaskState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO subtasks (id, ticket_id, title, completed, position) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET title = excluded.title, completed = excluded.completed, position = excluded.position`,
+		id, st.TicketID, st.Title, st.Completed, st.Position)
+	return err
+}
@@ -0,0 +1,223 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestUndoRedo(t *testing.T) {
+	s := newTestStore(t).WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login"})
+	title := "Login page"
+	if _, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: "in_progress"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo walks back one change at a time, newest first.
+	for _, want := range []struct{ title, status string }{
+		{"Login page", "todo"},
+		{"Login", "todo"},
+	} {
+		if c, err := s.Undo(); err != nil || c == nil {
+			t.Fatalf("undo: got %v, %v", c, err)
+		}
+		got := mustGetTicket(t, s, ticket.ID)
+		if got.Title != want.title || got.Status != want.status {
+			t.Errorf("after undo: %q in %s, want %q in %s", got.Title, got.Status, want.title, want.status)
+		}
+	}
+
+	// Redo replays them oldest first.
+	for _, want := range []struct{ title, status string }{
+		{"Login page", "todo"},
+		{"Login page", "in_progress"},
+	} {
+		if c, err := s.Redo(); err != nil || c == nil {
+			t.Fatalf("redo: got %v, %v", c, err)
+		}
+		got := mustGetTicket(t, s, ticket.ID)
+		if got.Title != want.title || got.Status != want.status {
+			t.Errorf("after redo: %q in %s, want %q in %s", got.Title, got.Status, want.title, want.status)
+		}
+	}
+	if c, err := s.Redo(); err != nil || c != nil {
+		t.Errorf("redo with nothing undone: got %v, %v, want nil", c, err)
+	}
+
+	// Undoing the creation trashes the ticket.
+	for range 3 {
+		if _, err := s.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := s.GetTicket(ticket.ID); err != nil || got != nil {
+		t.Errorf("after undoing the creation: %v, %v, want no ticket", got, err)
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	bob := s.WithActor(models.Actor{Name: "bob", Source: models.SourceWeb})
+	p := mustProject(t, alice, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	ticket := mustTicket(t, bob, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login"})
+
+	title, priority := "Login page", "high"
+	if _, err := alice.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Priority: &priority}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bob's change touched another field, so alice's undo keeps it.
+	if _, err := alice.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	got := mustGetTicket(t, s, ticket.ID)
+	if got.Title != "Login" || got.Priority != "high" {
+		t.Errorf("after undo: %q at %s priority, want Login at high", got.Title, got.Priority)
+	}
+
+	// Once bob edits the title too, alice's redo would overwrite it.
+	title = "Sign-in page"
+	if _, err := bob.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := alice.Redo()
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !strings.Contains(err.Error(), "title changed since by bob") {
+		t.Errorf("redo: got %v, want a conflict naming the title and bob", err)
+	}
+	if got := mustGetTicket(t, s, ticket.ID).Title; got != "Sign-in page" {
+		t.Errorf("title after a refused redo = %q, want bob's", got)
+	}
+}
+
+func TestUndoAfterRenumbering(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	bob := s.WithActor(models.Actor{Name: "bob", Source: models.SourceWeb})
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	tickets := make([]*models.Ticket, 4)
+	for i := range tickets {
+		tickets[i] = mustTicket(t, bob, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket"})
+	}
+	if _, err := alice.MoveTicket(tickets[3].ID, models.MoveTicketRequest{Status: "in_progress"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bob's moves squeeze AUTH-2 and AUTH-3 after AUTH-1 until the column
+	// is renumbered, which also moves AUTH-4's position.
+	position := mustGetTicket(t, s, tickets[3].ID).Position
+	if _, err := bob.MoveTicket(tickets[0].ID, models.MoveTicketRequest{Before: tickets[3].ID}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 60 {
+		if _, err := bob.MoveTicket(tickets[1+i%2].ID, models.MoveTicketRequest{After: tickets[0].ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if mustGetTicket(t, s, tickets[3].ID).Position == position {
+		t.Fatal("the column was not renumbered")
+	}
+
+	if _, err := alice.Undo(); err != nil {
+		t.Fatalf("undoing a move after its column was renumbered: %v", err)
+	}
+	if got := mustGetTicket(t, s, tickets[3].ID).Status; got != "todo" {
+		t.Errorf("AUTH-4 is in %s after undo, want todo", got)
+	}
+
+	if _, err := s.Undo(); !errors.Is(err, ErrNoActor) {
+		t.Errorf("undo without an actor: got %v, want ErrNoActor", err)
+	}
+}
+
+func TestUndoLink(t *testing.T) {
+	s := newTestStore(t).WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	blocker := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocker"})
+	blocked := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Blocked"})
+
+	// The link is stored on the blocked ticket, whichever side made it.
+	if _, err := s.AddRelation(blocker.ID, models.CreateRelationRequest{Type: models.RelationBlocks, TicketID: blocked.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := s.Undo(); err != nil || c == nil || c.EntityID != blocked.ID {
+		t.Fatalf("undoing the link: got %v, %v, want a change to AUTH-2", c, err)
+	}
+	if got := mustGetTicket(t, s, blocked.ID).BlockedBy; len(got) != 0 {
+		t.Errorf("AUTH-2 is blocked by %v after undoing the link, want nothing", got)
+	}
+	if _, err := s.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustGetTicket(t, s, blocked.ID).BlockedBy; len(got) != 1 || got[0] != blocker.ID {
+		t.Errorf("AUTH-2 is blocked by %v after redoing the link, want AUTH-1", got)
+	}
+
+	if _, err := s.RemoveRelation(blocked.ID, models.RelationBlockedBy, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustGetTicket(t, s, blocked.ID).BlockedBy; len(got) != 1 || got[0] != blocker.ID {
+		t.Errorf("AUTH-2 is blocked by %v after undoing the unlink, want AUTH-1", got)
+	}
+}
+
+func TestUndoInvalidTarget(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.WithActor(models.Actor{Name: "alice", Source: models.SourceCLI})
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	a := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket", Status: "in_progress"})
+	b := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket"})
+	team, err := s.CreateTeam(models.CreateTeamRequest{Name: "Platform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The team alice's redo would set has been deleted since.
+	if _, err := alice.UpdateTicket(b.ID, models.UpdateTicketRequest{TeamID: &team.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTeam(team.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = alice.Redo()
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("redo onto a deleted team: got %v, want a conflict", err)
+	}
+
+	// The column alice's undo would take AUTH-1 back to is full.
+	if _, err := alice.MoveTicket(a.ID, models.MoveTicketRequest{Status: "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateWIPLimits(p.ID, models.UpdateWIPLimitsRequest{Limits: []models.WIPLimit{
+		{Status: "in_progress", Limit: 1, Policy: models.WIPBlock},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MoveTicket(b.ID, models.MoveTicketRequest{Status: "in_progress"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.Undo(); !errors.As(err, &conflict) || !strings.Contains(err.Error(), "WIP limit") {
+		t.Errorf("undo into a full column: got %v, want a conflict naming the WIP limit", err)
+	}
+	if got := mustGetTicket(t, s, a.ID).Status; got != "done" {
+		t.Errorf("AUTH-1 is in %s after a refused undo, want done", got)
+	}
+}
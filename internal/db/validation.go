@@ -0,0 +1,147 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field of a request so callers can
+// report them together instead of one per round trip.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns e if any field was rejected, and nil otherwise.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// parseDueDate parses a YYYY-MM-DD due date. An empty string means no date.
+func parseDueDate(v *ValidationError, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	return parseDate(v, "dueDate", value)
+}
+
+// parseDate parses a YYYY-MM-DD date, recording a field error if it is not
+// one.
+func parseDate(v *ValidationError, field, value string) *time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		v.add(field, "%q is not a date in YYYY-MM-DD form", value)
+		return nil
+	}
+	return &parsed
+}
+
+// validateTicket checks the fields of t that differ from before, or all of
+// them when before is nil, along with the label and blocker IDs about to be
+// attached to it. Unchanged fields are trusted so that old data does not
+// stop unrelated edits.
+func (s *Store) validateTicket(v *ValidationError, before, t *models.Ticket, labels, blockedBy []string) error {
+	isNew := before == nil
+	if isNew {
+		before = &models.Ticket{}
+	}
+	changed := func(oldValue, newValue string) bool {
+		return isNew || oldValue != newValue
+	}
+
+	if changed(before.Title, t.Title) && strings.TrimSpace(t.Title) == "" {
+		v.add("title", "must not be empty")
+	}
+	if changed(before.Priority, t.Priority) && !slices.Contains(models.Priorities(), t.Priority) {
+		v.add("priority", "must be one of %s, got %q", strings.Join(models.Priorities(), ", "), t.Priority)
+	}
+	if t.Estimate != nil && *t.Estimate < 0 {
+		v.add("estimate", "must not be negative")
+	}
+	if changed(before.Status, t.Status) {
+		statuses, err := s.projectStatuses(t.ProjectID)
+		if err != nil {
+			return err
+		}
+		if !hasStatus(statuses, t.Status) {
+			v.add("status", "%q is not part of the project workflow", t.Status)
+		}
+	}
+
+	if t.TeamID != nil && changed(stringValue(before.TeamID), *t.TeamID) {
+		if err := s.checkExists(v, "teamId", "teams", "team", *t.TeamID); err != nil {
+			return err
+		}
+	}
+	if t.AssigneeID != nil && changed(stringValue(before.AssigneeID), *t.AssigneeID) {
+		if err := s.checkExists(v, "assigneeId", "members", "member", *t.AssigneeID); err != nil {
+			return err
+		}
+	}
+	if t.SprintID != nil && changed(stringValue(before.SprintID), *t.SprintID) {
+		if err := s.checkSprint(v, "sprintId", *t.SprintID, t.ProjectID); err != nil {
+			return err
+		}
+	}
+	if t.MilestoneID != nil && changed(stringValue(before.MilestoneID), *t.MilestoneID) {
+		if err := s.checkMilestone(v, "milestoneId", *t.MilestoneID, t.ProjectID); err != nil {
+			return err
+		}
+	}
+	for _, id := range labels {
+		if err := s.checkExists(v, "labels", "labels", "label", id); err != nil {
+			return err
+		}
+	}
+	for _, id := range blockedBy {
+		if id == t.ID {
+			v.add("blockedBy", "a ticket cannot block itself")
+			continue
+		}
+		if err := s.checkExists(v, "blockedBy", "tickets", "ticket", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkExists records a field error if table has no row with the given ID,
+// counting trashed rows as missing.
+func (s *Store) checkExists(v *ValidationError, field, table, noun, id string) error {
+	q := "SELECT 1 FROM " + table + " WHERE id = ?"
+	if trashTables[table] {
+		q += " AND deleted_at IS NULL"
+	}
+	var one int
+	err := s.db.QueryRow(q, id).Scan(&one)
+	if err == sql.ErrNoRows {
+		v.add(field, "%s %s not found", noun, id)
+		return nil
+	}
+	return err
+}
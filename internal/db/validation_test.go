@@ -0,0 +1,91 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// fieldNames returns the fields a *ValidationError rejected, or fails the
+// test if err is not one.
+func fieldNames(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	names := make([]string, len(verr.Fields))
+	for i, f := range verr.Fields {
+		names[i] = f.Field
+	}
+	return names
+}
+
+func TestCreateTicketValidation(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	label, err := s.CreateLabel(models.CreateLabelRequest{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := "next week"
+	_, err = s.CreateTicket(models.CreateTicketRequest{
+		ProjectID: p.ID,
+		Title:     "  ",
+		Status:    "doing",
+		Priority:  "asap",
+		DueDate:   &due,
+		Labels:    []string{label.ID, "no-such-label"},
+		BlockedBy: []string{"no-such-ticket"},
+	})
+	want := []string{"dueDate", "title", "priority", "status", "labels", "blockedBy"}
+	if got := fieldNames(t, err); !slices.Equal(got, want) {
+		t.Errorf("rejected %v, want %v", got, want)
+	}
+
+	// Nothing of the rejected ticket is written, not even its valid label.
+	tickets, err := s.ListTickets(models.TicketFilter{ProjectID: p.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 0 {
+		t.Errorf("found %v after a rejected create", displayKeys(tickets))
+	}
+	var links int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM ticket_labels").Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("%d label links left after a rejected create", links)
+	}
+}
+
+func TestUpdateTicketValidation(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Login page"})
+
+	title, priority, assignee := "Login form", "asap", "no-such-member"
+	_, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Title: &title, Priority: &priority, AssigneeID: &assignee})
+	if got, want := fieldNames(t, err), []string{"priority", "assigneeId"}; !slices.Equal(got, want) {
+		t.Errorf("rejected %v, want %v", got, want)
+	}
+	if got := mustGetTicket(t, s, ticket.ID).Title; got != "Login page" {
+		t.Errorf("title is %q after a rejected update", got)
+	}
+
+	// A stored value that is no longer valid does not block other edits.
+	if _, err := s.db.Exec("UPDATE tickets SET priority = 'legacy' WHERE id = ?", ticket.ID); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := s.UpdateTicket(ticket.ID, models.UpdateTicketRequest{Title: &title})
+	if err != nil {
+		t.Fatalf("editing the title of a ticket with an old priority: %v", err)
+	}
+	if updated.Title != title {
+		t.Errorf("title is %q, want %q", updated.Title, title)
+	}
+}
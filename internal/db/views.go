@@ -0,0 +1,271 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/query"
+)
+
+const viewColumns = "id, name, project_id, query, sort, sort_order, group_by, created_at, updated_at"
+
+func scanView(row interface{ Scan(...any) error }) (*models.SavedView, error) {
+	var v models.SavedView
+	err := row.Scan(&v.ID, &v.Name, &v.ProjectID, &v.Query, &v.Sort, &v.Order, &v.GroupBy, &v.CreatedAt, &v.UpdatedAt)
+	return &v, err
+}
+
+// ListViews returns every saved view, or only the global views and those of
+// projectID when it is set.
+func (s *Store) ListViews(projectID string) ([]models.SavedView, error) {
+	q := "SELECT " + viewColumns + " FROM saved_views"
+	args := []any{}
+	if projectID != "" {
+		q += " WHERE project_id IS NULL OR project_id = ?"
+		args = append(args, projectID)
+	}
+	q += " ORDER BY name COLLATE NOCASE"
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []models.SavedView
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *v)
+	}
+	return views, rows.Err()
+}
+
+func (s *Store) GetView(id string) (*models.SavedView, error) {
+	return s.getViewWhere("id", id)
+}
+
+// GetViewByName looks a view up by its name, ignoring case.
+func (s *Store) GetViewByName(name string) (*models.SavedView, error) {
+	return s.getViewWhere("name", name)
+}
+
+func (s *Store) getViewWhere(column, value string) (*models.SavedView, error) {
+	v, err := scanView(s.db.QueryRow("SELECT "+viewColumns+" FROM saved_views WHERE "+column+" = ?", value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *Store) CreateView(req models.CreateViewRequest) (*models.SavedView, error) {
+	now := time.Now()
+	v := &models.SavedView{
+		ID:        newID(),
+		Name:      strings.TrimSpace(req.Name),
+		ProjectID: optionalID(req.ProjectID),
+		Query:     req.Query,
+		Sort:      req.Sort,
+		Order:     strings.ToLower(req.Order),
+		GroupBy:   req.GroupBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.validateView(v); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec("INSERT INTO saved_views ("+viewColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		v.ID, v.Name, v.ProjectID, v.Query, v.Sort, v.Order, v.GroupBy, v.CreatedAt, v.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("inserting view: %w", err)
+	}
+	return v, nil
+}
+
+func (s *Store) UpdateView(id string, req models.UpdateViewRequest) (*models.SavedView, error) {
+	v, err := s.GetView(id)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		v.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.ProjectID != nil {
+		v.ProjectID = optionalID(req.ProjectID)
+	}
+	if req.Query != nil {
+		v.Query = *req.Query
+	}
+	if req.Sort != nil {
+		v.Sort = *req.Sort
+	}
+	if req.Order != nil {
+		v.Order = strings.ToLower(*req.Order)
+	}
+	if req.GroupBy != nil {
+		v.GroupBy = *req.GroupBy
+	}
+	if err := s.validateView(v); err != nil {
+		return nil, err
+	}
+	v.UpdatedAt = time.Now()
+
+	_, err = s.db.Exec(
+		"UPDATE saved_views SET name=?, project_id=?, query=?, sort=?, sort_order=?, group_by=?, updated_at=? WHERE id=?",
+		v.Name, v.ProjectID, v.Query, v.Sort, v.Order, v.GroupBy, v.UpdatedAt, v.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("updating view: %w", err)
+	}
+	return v, nil
+}
+
+func (s *Store) DeleteView(id string) error {
+	_, err := s.db.Exec("DELETE FROM saved_views WHERE id = ?", id)
+	return err
+}
+
+// validateView checks that the name is free and that the query, sort and
+// grouping would run, so a broken view is refused when it is saved rather
+// than every time it is opened.
+func (s *Store) validateView(view *models.SavedView) error {
+	v := &ValidationError{}
+	if view.Name == "" {
+		v.add("name", "must not be empty")
+	} else {
+		var other string
+		err := s.db.QueryRow("SELECT id FROM saved_views WHERE name = ? AND id != ?", view.Name, view.ID).Scan(&other)
+		if err == nil {
+			v.add("name", "a view named %q already exists", view.Name)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	if view.ProjectID != nil {
+		if err := s.checkExists(v, "projectId", "projects", "project", *view.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	var qerr *query.Error
+	if _, _, err := s.compileTicketQuery(view.Query); errors.As(err, &qerr) {
+		v.add("query", "%s at position %d", qerr.Msg, qerr.Pos)
+	} else if err != nil {
+		return err
+	}
+	var verr *ValidationError
+	if _, err := resolveTicketSort(models.TicketFilter{Sort: view.Sort, Order: view.Order}); errors.As(err, &verr) {
+		v.Fields = append(v.Fields, verr.Fields...)
+	}
+	if view.GroupBy != "" && !slices.Contains(models.ViewGroupings(), view.GroupBy) {
+		v.add("groupBy", "must be one of %s, got %q", strings.Join(models.ViewGroupings(), ", "), view.GroupBy)
+	}
+	return v.err()
+}
+
+// RunView returns a page of the tickets matching view, grouped when the view
+// says so. The query is evaluated as the store's actor, so assignee:me
+// follows whoever runs it.
+func (s *Store) RunView(view *models.SavedView, cursor string, limit int) (*models.ViewResult, error) {
+	filter := models.TicketFilter{
+		ProjectID: stringValue(view.ProjectID),
+		Expr:      view.Query,
+		Sort:      view.Sort,
+		Order:     view.Order,
+		Cursor:    cursor,
+		Limit:     limit,
+	}
+	page, err := s.ListTicketPage(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ViewResult{View: *view, Tickets: page.Tickets, Total: page.Total, NextCursor: page.NextCursor}
+	if view.GroupBy != "" {
+		if result.Groups, err = s.groupTickets(page.Tickets, view.GroupBy); err != nil {
+			return nil, fmt.Errorf("grouping tickets: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// groupTickets splits tickets by a grouping key, keeping their order.
+func (s *Store) groupTickets(tickets []models.Ticket, groupBy string) ([]models.TicketGroup, error) {
+	var names map[string]string
+	var err error
+	switch groupBy {
+	case models.GroupByAssignee:
+		names, err = s.namesByID("SELECT id, handle FROM members")
+	case models.GroupByTeam:
+		names, err = s.namesByID("SELECT id, name FROM teams")
+	}
+	if err != nil {
+		return nil, err
+	}
+	name := func(id *string) string {
+		if id == nil {
+			return "none"
+		}
+		if n, ok := names[*id]; ok {
+			return n
+		}
+		return *id
+	}
+
+	groups := []models.TicketGroup{}
+	index := map[string]int{}
+	for _, t := range tickets {
+		var key string
+		switch groupBy {
+		case models.GroupByStatus:
+			key = t.Status
+		case models.GroupByPriority:
+			key = t.Priority
+		case models.GroupByAssignee:
+			key = name(t.AssigneeID)
+		case models.GroupByTeam:
+			key = name(t.TeamID)
+		case models.GroupByProject:
+			key = t.ProjectPrefix
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.TicketGroup{Key: key})
+		}
+		groups[i].TicketIDs = append(groups[i].TicketIDs, t.ID)
+	}
+	return groups, nil
+}
+
+// namesByID runs a two-column id, name query into a map.
+func (s *Store) namesByID(q string) (map[string]string, error) {
+	rows, err := s.db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
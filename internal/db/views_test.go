@@ -0,0 +1,76 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestViewValidation(t *testing.T) {
+	s := newTestStore(t)
+	if _, err := s.CreateView(models.CreateViewRequest{Name: "Mine", Query: "assignee:me"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		req   models.CreateViewRequest
+		field string
+	}{
+		{"taken name", models.CreateViewRequest{Name: "Mine"}, "name"},
+		{"blank name", models.CreateViewRequest{Name: "  "}, "name"},
+		{"bad query", models.CreateViewRequest{Name: "Doing", Query: "status:doing"}, "query"},
+		{"bad sort", models.CreateViewRequest{Name: "Sorted", Sort: "colour"}, "sort"},
+		{"bad grouping", models.CreateViewRequest{Name: "Grouped", GroupBy: "colour"}, "groupBy"},
+	}
+	for _, tt := range tests {
+		_, err := s.CreateView(tt.req)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.field {
+			t.Errorf("%s: got %v, want a validation error on %s", tt.name, err, tt.field)
+		}
+	}
+}
+
+func TestRunView(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	for _, req := range []models.CreateTicketRequest{
+		{Title: "Login page crashes", Priority: "urgent"},
+		{Title: "Password reset email", Priority: "low"},
+		{Title: "Session timeout", Priority: "urgent"},
+		{Title: "Audit cookies", Priority: "low", Status: "done"},
+	} {
+		req.ProjectID = p.ID
+		mustTicket(t, s, req)
+	}
+	view, err := s.CreateView(models.CreateViewRequest{Name: "Open", ProjectID: &p.ID, Query: "is:open",
+		Sort: models.SortNumber, GroupBy: models.GroupByPriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.RunView(view, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := displayKeys(first.Tickets); !slices.Equal(got, []string{"AUTH-1", "AUTH-2"}) || first.Total != 3 || first.NextCursor == "" {
+		t.Errorf("first page holds %v of %d, next cursor %q; want [AUTH-1 AUTH-2] of 3 and a cursor", got, first.Total, first.NextCursor)
+	}
+	if len(first.Groups) != 2 || first.Groups[0].Key != "urgent" || first.Groups[1].Key != "low" {
+		t.Errorf("first page groups = %+v, want urgent then low", first.Groups)
+	}
+
+	second, err := s.RunView(view, first.NextCursor, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := displayKeys(second.Tickets); !slices.Equal(got, []string{"AUTH-3"}) || second.NextCursor != "" {
+		t.Errorf("second page holds %v, next cursor %q; want [AUTH-3] and no cursor", got, second.NextCursor)
+	}
+	if len(second.Groups) != 1 || second.Groups[0].Key != "urgent" || len(second.Groups[0].TicketIDs) != 1 {
+		t.Errorf("second page groups = %+v, want one urgent ticket", second.Groups)
+	}
+}
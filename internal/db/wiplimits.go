@@ -0,0 +1,248 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+// ErrWIPLimit is returned when moving a ticket into a status would take it
+// past a WIP limit with the block policy.
+var ErrWIPLimit = errors.New("WIP limit reached")
+
+// GetWIPLimits returns a project's WIP limits, or nil if the project does
+// not exist.
+func (s *Store) GetWIPLimits(projectID string) (*models.WIPLimits, error) {
+	p, err := s.GetProject(projectID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	limits, err := s.wipLimits(projectID, "")
+	if err != nil {
+		return nil, err
+	}
+	if limits == nil {
+		limits = []models.WIPLimit{}
+	}
+	return &models.WIPLimits{ProjectID: projectID, Limits: limits}, nil
+}
+
+// wipLimits returns a project's limits in workflow order, project-wide
+// limits first, optionally only those for one status.
+func (s *Store) wipLimits(projectID, status string) ([]models.WIPLimit, error) {
+	query := `SELECT l.status, l.team_id, l.max_tickets, l.policy FROM wip_limits l
+		LEFT JOIN workflow_statuses w ON w.project_id = l.project_id AND w.status = l.status
+		WHERE l.project_id = ?`
+	args := []any{projectID}
+	if status != "" {
+		query += " AND l.status = ?"
+		args = append(args, status)
+	}
+	rows, err := s.db.Query(query+" ORDER BY w.position, l.status, l.team_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []models.WIPLimit
+	for rows.Next() {
+		var l models.WIPLimit
+		var teamID string
+		if err := rows.Scan(&l.Status, &teamID, &l.Limit, &l.Policy); err != nil {
+			return nil, err
+		}
+		if teamID != "" {
+			l.TeamID = &teamID
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+// UpdateWIPLimits replaces a project's WIP limits. It returns nil if the
+// project does not exist.
+func (s *Store) UpdateWIPLimits(projectID string, req models.UpdateWIPLimitsRequest) (*models.WIPLimits, error) {
+	p, err := s.GetProject(projectID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	statuses, err := s.projectStatuses(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &ValidationError{}
+	seen := make(map[string]bool, len(req.Limits))
+	for i := range req.Limits {
+		l := &req.Limits[i]
+		field := fmt.Sprintf("limits[%d]", i)
+		if !hasStatus(statuses, l.Status) {
+			v.add(field+".status", "%q is not part of the project workflow", l.Status)
+		}
+		if l.TeamID != nil && *l.TeamID == "" {
+			l.TeamID = nil
+		}
+		if l.TeamID != nil {
+			if err := s.checkExists(v, field+".teamId", "teams", "team", *l.TeamID); err != nil {
+				return nil, err
+			}
+		}
+		if l.Limit < 1 {
+			v.add(field+".limit", "must be at least 1")
+		}
+		if l.Policy == "" {
+			l.Policy = models.WIPWarn
+		}
+		if l.Policy != models.WIPWarn && l.Policy != models.WIPBlock {
+			v.add(field+".policy", "must be warn or block, got %q", l.Policy)
+		}
+		key := l.Status + "/" + stringValue(l.TeamID)
+		if seen[key] {
+			v.add(field, "duplicates another limit for %s", l.Status)
+		}
+		seen[key] = true
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM wip_limits WHERE project_id = ?", projectID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertWIPLimits(tx, projectID, req.Limits); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetWIPLimits(projectID)
+}
+
+func insertWIPLimits(e execer, projectID string, limits []models.WIPLimit) error {
+	for _, l := range limits {
+		if _, err := e.Exec("INSERT INTO wip_limits (project_id, status, team_id, max_tickets, policy) VALUES (?, ?, ?, ?, ?)",
+			projectID, l.Status, stringValue(l.TeamID), l.Limit, l.Policy); err != nil {
+			return fmt.Errorf("inserting WIP limit for %s: %w", l.Status, err)
+		}
+	}
+	return nil
+}
+
+// checkWIPLimits checks the limits of status before ticket t, with the team
+// it will have, moves into it or changes team within it. It returns a
+// warning for a limit with the warn policy and an ErrWIPLimit error for one
+// with the block policy. Run it on a store in the write transaction (see
+// inTx) so that concurrent moves cannot both take the last place.
+func (s *Store) checkWIPLimits(t *models.Ticket, status string, teamID *string) (string, error) {
+	limits, err := s.wipLimits(t.ProjectID, status)
+	if err != nil || len(limits) == 0 {
+		return "", err
+	}
+	name := status
+	if statuses, err := s.projectStatuses(t.ProjectID); err == nil {
+		for _, st := range statuses {
+			if st.Status == status {
+				name = st.Name
+			}
+		}
+	}
+
+	var warning string
+	for _, l := range limits {
+		if l.TeamID == nil && status == t.Status {
+			// Already counted in the column.
+			continue
+		}
+		query := "SELECT COUNT(*) FROM tickets WHERE project_id = ? AND status = ? AND id != ? AND deleted_at IS NULL"
+		args := []any{t.ProjectID, status, t.ID}
+		scope := "the project"
+		if l.TeamID != nil {
+			if teamID == nil || *teamID != *l.TeamID {
+				continue
+			}
+			query += " AND team_id = ?"
+			args = append(args, *l.TeamID)
+			scope = "team " + *l.TeamID
+			if team, err := s.GetTeam(*l.TeamID); err == nil && team != nil {
+				scope = "team " + team.Name
+			}
+		}
+		var count int
+		if err := s.db.QueryRow(query, args...).Scan(&count); err != nil {
+			return "", err
+		}
+		if count < l.Limit {
+			continue
+		}
+		tickets := "tickets"
+		if count == 1 {
+			tickets = "ticket"
+		}
+		msg := fmt.Sprintf("%s already has %d %s for %s, at its WIP limit of %d; finish or move one out before starting %s",
+			name, count, tickets, scope, l.Limit, t.DisplayKey())
+		if l.Policy == models.WIPBlock {
+			return "", fmt.Errorf("%w: %s", ErrWIPLimit, msg)
+		}
+		if warning == "" {
+			warning = msg
+		}
+	}
+	return warning, nil
+}
+
+// setColumnLimits fills in the counts and WIP limits of a project board's
+// columns from the project's tickets, whatever the board shows.
+func (s *Store) setColumnLimits(projectID string, columns []models.Column) error {
+	limits, err := s.wipLimits(projectID, "")
+	if err != nil {
+		return err
+	}
+	rows, err := s.db.Query(`SELECT status, COALESCE(team_id, ''), COUNT(*) FROM tickets
+		WHERE project_id = ? AND deleted_at IS NULL GROUP BY status, team_id`, projectID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	teamCounts := map[[2]string]int{}
+	for rows.Next() {
+		var status, teamID string
+		var n int
+		if err := rows.Scan(&status, &teamID, &n); err != nil {
+			return err
+		}
+		counts[status] += n
+		teamCounts[[2]string{status, teamID}] += n
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range columns {
+		col := &columns[i]
+		col.Count = counts[col.Status]
+		for _, l := range limits {
+			if l.Status != col.Status {
+				continue
+			}
+			if l.TeamID == nil {
+				col.WIPLimit = l.Limit
+				col.OverLimit = col.Count > l.Limit
+				continue
+			}
+			n := teamCounts[[2]string{col.Status, *l.TeamID}]
+			col.TeamLimits = append(col.TeamLimits, models.TeamWIPUsage{
+				TeamID: *l.TeamID, Count: n, WIPLimit: l.Limit, OverLimit: n > l.Limit,
+			})
+		}
+	}
+	return nil
+}
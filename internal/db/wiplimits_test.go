@@ -0,0 +1,130 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+func TestWIPLimits(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	tickets := make([]*models.Ticket, 3)
+	for i := range tickets {
+		tickets[i] = mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Ticket"})
+	}
+	if _, err := s.UpdateWIPLimits(p.ID, models.UpdateWIPLimitsRequest{Limits: []models.WIPLimit{
+		{Status: "in_progress", Limit: 1, Policy: models.WIPBlock},
+		{Status: "done", Limit: 1},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	start := func(ticket *models.Ticket) (*models.Ticket, error) {
+		return s.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: "in_progress"})
+	}
+
+	if _, err := start(tickets[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := start(tickets[1]); !errors.Is(err, ErrWIPLimit) || !strings.Contains(err.Error(), "AUTH-2") {
+		t.Errorf("moving past a block limit: got %v, want ErrWIPLimit naming AUTH-2", err)
+	}
+	status := "in_progress"
+	if _, err := s.UpdateTicket(tickets[1].ID, models.UpdateTicketRequest{Status: &status}); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("updating past a block limit: got %v, want ErrWIPLimit", err)
+	}
+	if got := mustGetTicket(t, s, tickets[1].ID).Status; got != "todo" {
+		t.Errorf("refused ticket is in %s, want todo", got)
+	}
+	// A ticket already in the column does not count against itself.
+	if _, err := start(tickets[0]); err != nil {
+		t.Errorf("moving within a full column: %v", err)
+	}
+
+	// The warn policy lets the move through with a warning.
+	for i, ticket := range tickets[1:] {
+		moved, err := s.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: "done"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(moved.Warnings); got != i {
+			t.Errorf("moving %s to done: %d warnings, want %d", moved.DisplayKey(), got, i)
+		}
+	}
+
+	board, err := s.GetBoard(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, col := range board.Columns {
+		if col.Status == "done" && (col.Count != 2 || col.WIPLimit != 1 || !col.OverLimit) {
+			t.Errorf("done column: %d of %d, over limit %v; want 2 of 1, over limit", col.Count, col.WIPLimit, col.OverLimit)
+		}
+	}
+}
+
+func TestTeamWIPLimits(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	backend, err := s.CreateTeam(models.CreateTeamRequest{Name: "Backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frontend, err := s.CreateTeam(models.CreateTeamRequest{Name: "Frontend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateWIPLimits(p.ID, models.UpdateWIPLimitsRequest{Limits: []models.WIPLimit{
+		{Status: "in_progress", TeamID: &backend.ID, Limit: 1, Policy: models.WIPBlock},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	started := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "API", TeamID: &backend.ID})
+	other := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "UI", TeamID: &frontend.ID})
+	for _, ticket := range []*models.Ticket{started, other} {
+		if _, err := s.MoveTicket(ticket.ID, models.MoveTicketRequest{Status: "in_progress"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Handing the frontend ticket to the backend team would take the
+	// column past the backend's limit without changing its status.
+	if _, err := s.UpdateTicket(other.ID, models.UpdateTicketRequest{TeamID: &backend.ID}); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("changing team into a full column: got %v, want ErrWIPLimit", err)
+	}
+	if got := mustGetTicket(t, s, other.ID).TeamID; got == nil || *got != frontend.ID {
+		t.Errorf("refused ticket has team %v, want Frontend", got)
+	}
+	if _, err := s.UpdateTicket(started.ID, models.UpdateTicketRequest{TeamID: &frontend.ID}); err != nil {
+		t.Errorf("changing team out of the limit: %v", err)
+	}
+}
+
+func TestWIPLimitsOnCreate(t *testing.T) {
+	s := newTestStore(t)
+	p := mustProject(t, s, models.CreateProjectRequest{Name: "Auth", Prefix: "AUTH"})
+	if _, err := s.UpdateWIPLimits(p.ID, models.UpdateWIPLimitsRequest{Limits: []models.WIPLimit{
+		{Status: "in_progress", Limit: 1, Policy: models.WIPBlock},
+		{Status: "todo", Limit: 1},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating straight into a full column is a move into it.
+	mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Started", Status: "in_progress"})
+	if _, err := s.CreateTicket(models.CreateTicketRequest{ProjectID: p.ID, Title: "Also started", Status: "in_progress"}); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("creating past a block limit: got %v, want ErrWIPLimit", err)
+	}
+	if n := count(t, s, "SELECT COUNT(*) FROM tickets WHERE project_id = ?", p.ID); n != 1 {
+		t.Errorf("project has %d tickets after a refused create, want 1", n)
+	}
+
+	for i := range 2 {
+		ticket := mustTicket(t, s, models.CreateTicketRequest{ProjectID: p.ID, Title: "Later"})
+		if got := len(ticket.Warnings); got != i {
+			t.Errorf("creating %s in todo: %d warnings, want %d", ticket.DisplayKey(), got, i)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/tcarac/taskboard/internal/models"
+)
+
+var (
+	ErrInvalidStatus   = errors.New("invalid status")
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+)
+
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func validCategory(category string) bool {
+	switch category {
+	case models.StatusCategoryBacklog, models.StatusCategoryActive, models.StatusCategoryDone:
+		return true
+	}
+	return false
+}
+
+// GetWorkflow returns the ordered statuses for a project. Projects without a
+// stored workflow fall back to the default todo/in_progress/done columns.
+func (s *Store) GetWorkflow(projectID string) (*models.Workflow, error) {
+	p, err := s.GetProject(projectID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	statuses, err := s.projectStatuses(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Workflow{ProjectID: projectID, Statuses: statuses}, nil
+}
+
+// UpdateWorkflow replaces a project's workflow. Statuses still used by tickets
+// in the project cannot be removed, and the WIP limits of removed statuses
+// go with them.
+func (s *Store) UpdateWorkflow(projectID string, req models.UpdateWorkflowRequest) (*models.Workflow, error) {
+	p, err := s.GetProject(projectID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	if len(req.Statuses) == 0 {
+		return nil, fmt.Errorf("%w: at least one status is required", ErrInvalidWorkflow)
+	}
+	existing, err := s.projectStatuses(projectID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(existing))
+	for _, st := range existing {
+		names[st.Status] = st.Name
+	}
+
+	seen := make(map[string]bool, len(req.Statuses))
+	for i := range req.Statuses {
+		st := &req.Statuses[i]
+		if !statusKeyPattern.MatchString(st.Status) {
+			return nil, fmt.Errorf("%w: status %q must be lowercase letters, digits and underscores", ErrInvalidWorkflow, st.Status)
+		}
+		if seen[st.Status] {
+			return nil, fmt.Errorf("%w: duplicate status %q", ErrInvalidWorkflow, st.Status)
+		}
+		seen[st.Status] = true
+		if st.Name == "" {
+			st.Name = names[st.Status]
+		}
+		if st.Name == "" {
+			st.Name = st.Status
+		}
+		if st.Category == "" {
+			st.Category = models.StatusCategoryActive
+		}
+		if !validCategory(st.Category) {
+			return nil, fmt.Errorf("%w: category %q must be backlog, active or done", ErrInvalidWorkflow, st.Category)
+		}
+		st.Position = i
+	}
+
+	// Check the statuses in use inside the transaction, so that no ticket
+	// can move into a removed status in the meantime.
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	rows, err := tx.Query("SELECT DISTINCT status FROM tickets WHERE project_id = ?", projectID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var inUse []string
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+		inUse = append(inUse, status)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for _, status := range inUse {
+		if !seen[status] {
+			tx.Rollback()
+			return nil, fmt.Errorf("%w: status %q is still used by tickets in this project", ErrInvalidWorkflow, status)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM workflow_statuses WHERE project_id = ?", projectID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertWorkflowStatuses(tx, projectID, req.Statuses); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM wip_limits WHERE project_id = ?
+		AND status NOT IN (SELECT status FROM workflow_statuses WHERE project_id = ?)`, projectID, projectID); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("removing WIP limits of removed statuses: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetWorkflow(projectID)
+}
+
+// ListWorkflowStatuses merges the workflows of every project into a single
+// ordered list, used for the cross-project board and MCP schemas.
+func (s *Store) ListWorkflowStatuses() ([]models.WorkflowStatus, error) {
+	rows, err := s.db.Query(
+		`SELECT w.status, w.name, w.category, w.position FROM workflow_statuses w
+		JOIN projects p ON w.project_id = p.id WHERE p.deleted_at IS NULL ORDER BY w.position, p.created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []models.WorkflowStatus{}
+	seen := map[string]bool{}
+	for rows.Next() {
+		var st models.WorkflowStatus
+		if err := rows.Scan(&st.Status, &st.Name, &st.Category, &st.Position); err != nil {
+			return nil, err
+		}
+		if seen[st.Status] {
+			continue
+		}
+		seen[st.Status] = true
+		st.Position = len(statuses)
+		statuses = append(statuses, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		statuses = models.DefaultWorkflowStatuses()
+	}
+	return statuses, nil
+}
+
+// validateStatus checks that status is part of the project's workflow.
+func (s *Store) validateStatus(projectID, status string) error {
+	statuses, err := s.projectStatuses(projectID)
+	if err != nil {
+		return err
+	}
+	if hasStatus(statuses, status) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is not part of the project workflow", ErrInvalidStatus, status)
+}
+
+func hasStatus(statuses []models.WorkflowStatus, status string) bool {
+	for _, st := range statuses {
+		if st.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) projectStatuses(projectID string) ([]models.WorkflowStatus, error) {
+	statuses, err := s.getWorkflowStatuses(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		statuses = models.DefaultWorkflowStatuses()
+	}
+	return statuses, nil
+}
+
+func (s *Store) getWorkflowStatuses(projectID string) ([]models.WorkflowStatus, error) {
+	rows, err := s.db.Query(
+		"SELECT status, name, category, position FROM workflow_statuses WHERE project_id = ? ORDER BY position",
+		projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []models.WorkflowStatus
+	for rows.Next() {
+		var st models.WorkflowStatus
+		if err := rows.Scan(&st.Status, &st.Name, &st.Category, &st.Position); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, rows.Err()
+}
+
+func insertWorkflowStatuses(e execer, projectID string, statuses []models.WorkflowStatus) error {
+	for _, st := range statuses {
+		if _, err := e.Exec(
+			"INSERT INTO workflow_statuses (project_id, status, name, category, position) VALUES (?, ?, ?, ?, ?)",
+			projectID, st.Status, st.Name, st.Category, st.Position,
+		); err != nil {
+			return fmt.Errorf("inserting status %s: %w", st.Status, err)
+		}
+	}
+	return nil
+}
@@ -3,20 +3,25 @@ package mcp
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/tcarac/taskboard/internal/db"
 	"github.com/tcarac/taskboard/internal/models"
 )
 
+// maxAttachmentText is how much of a text attachment read_attachment returns.
+const maxAttachmentText = 256 << 10
+
 type MCPServer struct {
 	store *db.Store
 }
 
 func NewServer(store *db.Store) *MCPServer {
-	return &MCPServer{store: store}
+	return &MCPServer{store: store.WithActor(models.Actor{Source: models.SourceMCP})}
 }
 
 type jsonrpcRequest struct {
@@ -48,13 +53,15 @@ type jsonSchema struct {
 	Type       string                `json:"type"`
 	Properties map[string]schemaProp `json:"properties,omitempty"`
 	Required   []string              `json:"required,omitempty"`
+	Enum       []string              `json:"enum,omitempty"`
 }
 
 type schemaProp struct {
-	Type        string      `json:"type"`
-	Description string      `json:"description"`
-	Enum        []string    `json:"enum,omitempty"`
-	Items       *jsonSchema `json:"items,omitempty"`
+	Type        string                `json:"type"`
+	Description string                `json:"description"`
+	Enum        []string              `json:"enum,omitempty"`
+	Items       *jsonSchema           `json:"items,omitempty"`
+	Properties  map[string]schemaProp `json:"properties,omitempty"`
 }
 
 type textContent struct {
@@ -97,6 +104,14 @@ func (s *MCPServer) Run() error {
 func (s *MCPServer) handleRequest(req jsonrpcRequest) *jsonrpcResponse {
 	switch req.Method {
 	case "initialize":
+		var params struct {
+			ClientInfo struct {
+				Name string `json:"name"`
+			} `json:"clientInfo"`
+		}
+		if json.Unmarshal(req.Params, &params) == nil && params.ClientInfo.Name != "" {
+			s.store = s.store.WithActor(models.Actor{Name: params.ClientInfo.Name, Source: models.SourceMCP})
+		}
 		return &jsonrpcResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
@@ -151,7 +166,7 @@ func (s *MCPServer) handleToolCall(req jsonrpcRequest) *jsonrpcResponse {
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result: map[string]any{
-				"content": []textContent{{Type: "text", Text: fmt.Sprintf("Error: %s", err.Error())}},
+				"content": []textContent{{Type: "text", Text: errorText(err)}},
 				"isError": true,
 			},
 		}
@@ -167,6 +182,21 @@ func (s *MCPServer) handleToolCall(req jsonrpcRequest) *jsonrpcResponse {
 	}
 }
 
+// errorText renders a tool error. Validation errors list each invalid field
+// on its own line so the caller can fix them all before retrying.
+func errorText(err error) string {
+	var verr *db.ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Sprintf("Error: %s", err.Error())
+	}
+	var b strings.Builder
+	b.WriteString("Error: invalid arguments")
+	for _, f := range verr.Fields {
+		fmt.Fprintf(&b, "\n- %s: %s", f.Field, f.Message)
+	}
+	return b.String()
+}
+
 func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 	switch name {
 	case "list_projects":
@@ -200,6 +230,20 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 		json.Unmarshal(args, &a)
 		return s.store.UpdateProject(a.ID, a.UpdateProjectRequest)
 
+	case "clone_project":
+		var a struct {
+			ID string `json:"id"`
+			models.CloneProjectRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		p, err := s.store.CloneProject(a.ID, a.CloneProjectRequest)
+		if p == nil && err == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		return p, err
+
 	case "delete_project":
 		var a struct {
 			ID string `json:"id"`
@@ -207,6 +251,52 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 		json.Unmarshal(args, &a)
 		return map[string]bool{"deleted": true}, s.store.DeleteProject(a.ID)
 
+	case "get_workflow":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		wf, err := s.store.GetWorkflow(a.ProjectID)
+		if wf == nil && err == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		return wf, err
+
+	case "update_workflow":
+		var a struct {
+			ProjectID string `json:"projectId"`
+			models.UpdateWorkflowRequest
+		}
+		json.Unmarshal(args, &a)
+		wf, err := s.store.UpdateWorkflow(a.ProjectID, a.UpdateWorkflowRequest)
+		if wf == nil && err == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		return wf, err
+
+	case "get_wip_limits":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		limits, err := s.store.GetWIPLimits(a.ProjectID)
+		if limits == nil && err == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		return limits, err
+
+	case "set_wip_limits":
+		var a struct {
+			ProjectID string `json:"projectId"`
+			models.UpdateWIPLimitsRequest
+		}
+		json.Unmarshal(args, &a)
+		limits, err := s.store.UpdateWIPLimits(a.ProjectID, a.UpdateWIPLimitsRequest)
+		if limits == nil && err == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		return limits, err
+
 	case "list_teams":
 		return s.store.ListTeams()
 
@@ -241,9 +331,30 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 		json.Unmarshal(args, &a)
 		return map[string]bool{"deleted": true}, s.store.DeleteTeam(a.ID)
 
+	case "list_members":
+		var a struct {
+			TeamID string `json:"teamId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListMembers(a.TeamID)
+
 	case "list_tickets":
 		var a models.TicketFilter
 		json.Unmarshal(args, &a)
+		if a.Limit <= 0 {
+			a.Limit = 50
+		}
+		return s.store.ListTicketPage(a)
+
+	case "search_tickets":
+		var a models.TicketFilter
+		json.Unmarshal(args, &a)
+		if a.Query == "" {
+			return nil, fmt.Errorf("query is required")
+		}
+		if a.Limit <= 0 {
+			a.Limit = 20
+		}
 		return s.store.ListTickets(a)
 
 	case "get_ticket":
@@ -259,7 +370,9 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 
 	case "create_ticket":
 		var a models.CreateTicketRequest
-		json.Unmarshal(args, &a)
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
 		return s.store.CreateTicket(a)
 
 	case "update_ticket":
@@ -267,8 +380,14 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 			ID string `json:"id"`
 			models.UpdateTicketRequest
 		}
-		json.Unmarshal(args, &a)
-		return s.store.UpdateTicket(a.ID, a.UpdateTicketRequest)
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		t, err := s.store.UpdateTicket(a.ID, a.UpdateTicketRequest)
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return t, err
 
 	case "move_ticket":
 		var a struct {
@@ -276,7 +395,25 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 			models.MoveTicketRequest
 		}
 		json.Unmarshal(args, &a)
-		return s.store.MoveTicket(a.ID, a.MoveTicketRequest)
+		t, err := s.store.MoveTicket(a.ID, a.MoveTicketRequest)
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return t, err
+
+	case "clone_ticket":
+		var a struct {
+			ID string `json:"id"`
+			models.CloneTicketRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		t, err := s.store.CloneTicket(a.ID, a.CloneTicketRequest)
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return t, err
 
 	case "delete_ticket":
 		var a struct {
@@ -288,9 +425,240 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 	case "get_board":
 		var a struct {
 			ProjectID string `json:"projectId"`
+			SprintID  string `json:"sprintId"`
+		}
+		json.Unmarshal(args, &a)
+		if a.SprintID == "" {
+			return s.store.GetBoard(a.ProjectID)
+		}
+		if a.SprintID == "current" {
+			sp, err := s.store.CurrentSprint(a.ProjectID)
+			if err != nil {
+				return nil, err
+			}
+			if sp == nil {
+				return nil, fmt.Errorf("no active sprint")
+			}
+			a.SprintID = sp.ID
+		}
+		board, err := s.store.GetSprintBoard(a.SprintID)
+		if board == nil && err == nil {
+			return nil, fmt.Errorf("sprint not found")
+		}
+		return board, err
+
+	case "list_sprints":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListSprints(a.ProjectID)
+
+	case "create_sprint":
+		var a models.CreateSprintRequest
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.store.CreateSprint(a)
+
+	case "update_sprint":
+		var a struct {
+			ID string `json:"id"`
+			models.UpdateSprintRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		sp, err := s.store.UpdateSprint(a.ID, a.UpdateSprintRequest)
+		if sp == nil && err == nil {
+			return nil, fmt.Errorf("sprint not found")
+		}
+		return sp, err
+
+	case "close_sprint":
+		var a struct {
+			ID string `json:"id"`
+			models.CloseSprintRequest
+		}
+		json.Unmarshal(args, &a)
+		result, err := s.store.CloseSprint(a.ID, a.CloseSprintRequest)
+		if result == nil && err == nil {
+			return nil, fmt.Errorf("sprint not found")
+		}
+		return result, err
+
+	case "list_milestones":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListMilestones(a.ProjectID)
+
+	case "create_milestone":
+		var a models.CreateMilestoneRequest
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.store.CreateMilestone(a)
+
+	case "update_milestone":
+		var a struct {
+			ID string `json:"id"`
+			models.UpdateMilestoneRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		m, err := s.store.UpdateMilestone(a.ID, a.UpdateMilestoneRequest)
+		if m == nil && err == nil {
+			return nil, fmt.Errorf("milestone not found")
+		}
+		return m, err
+
+	case "list_templates":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListTemplates(a.ProjectID)
+
+	case "create_template":
+		var a models.CreateTemplateRequest
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.store.CreateTemplate(a)
+
+	case "update_template":
+		var a struct {
+			ID string `json:"id"`
+			models.UpdateTemplateRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		t, err := s.store.UpdateTemplate(a.ID, a.UpdateTemplateRequest)
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("template not found")
+		}
+		return t, err
+
+	case "list_custom_fields":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListCustomFields(a.ProjectID)
+
+	case "create_custom_field":
+		var a models.CreateCustomFieldRequest
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.store.CreateCustomField(a)
+
+	case "update_custom_field":
+		var a struct {
+			ID string `json:"id"`
+			models.UpdateCustomFieldRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		f, err := s.store.UpdateCustomField(a.ID, a.UpdateCustomFieldRequest)
+		if f == nil && err == nil {
+			return nil, fmt.Errorf("field not found")
+		}
+		return f, err
+
+	case "delete_custom_field":
+		var a struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(args, &a)
+		return map[string]bool{"deleted": true}, s.store.DeleteCustomField(a.ID)
+
+	case "list_recurrences":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListRecurrences(a.ProjectID)
+
+	case "create_recurrence":
+		var a models.CreateRecurrenceRequest
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.store.CreateRecurrence(a)
+
+	case "update_recurrence":
+		var a struct {
+			ID string `json:"id"`
+			models.UpdateRecurrenceRequest
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		r, err := s.store.UpdateRecurrence(a.ID, a.UpdateRecurrenceRequest)
+		if r == nil && err == nil {
+			return nil, fmt.Errorf("recurrence not found")
+		}
+		return r, err
+
+	case "list_trash":
+		return s.store.ListTrash()
+
+	case "restore_from_trash":
+		var a struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(args, &a)
+		item, err := s.store.RestoreFromTrash(a.ID)
+		if item == nil && err == nil {
+			return nil, fmt.Errorf("%s is not in the trash", a.ID)
+		}
+		return item, err
+
+	case "undo_last_change":
+		c, err := s.store.Undo()
+		if c == nil && err == nil {
+			return nil, fmt.Errorf("nothing to undo")
+		}
+		return c, err
+
+	case "list_views":
+		var a struct {
+			ProjectID string `json:"projectId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListViews(a.ProjectID)
+
+	case "run_view":
+		var a struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Cursor string `json:"cursor"`
+			Limit  int    `json:"limit"`
 		}
 		json.Unmarshal(args, &a)
-		return s.store.GetBoard(a.ProjectID)
+		var v *models.SavedView
+		var err error
+		if a.ID != "" {
+			v, err = s.store.GetView(a.ID)
+		} else {
+			v, err = s.store.GetViewByName(a.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("view not found")
+		}
+		if a.Limit <= 0 {
+			a.Limit = 50
+		}
+		return s.store.RunView(v, a.Cursor, a.Limit)
 
 	case "create_subtask":
 		var a struct {
@@ -338,19 +706,209 @@ func (s *MCPServer) callTool(name string, args json.RawMessage) (any, error) {
 		json.Unmarshal(args, &a)
 		return s.store.ToggleSubtask(a.ID)
 
+	case "link_tickets":
+		var a struct {
+			ID        string `json:"id"`
+			Type      string `json:"type"`
+			RelatedID string `json:"relatedId"`
+		}
+		json.Unmarshal(args, &a)
+		t, err := s.store.AddRelation(a.ID, models.CreateRelationRequest{Type: a.Type, TicketID: a.RelatedID})
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return t, err
+
+	case "unlink_tickets":
+		var a struct {
+			ID        string `json:"id"`
+			Type      string `json:"type"`
+			RelatedID string `json:"relatedId"`
+		}
+		json.Unmarshal(args, &a)
+		t, err := s.store.RemoveRelation(a.ID, a.Type, a.RelatedID)
+		if t == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return t, err
+
+	case "get_ticket_history":
+		var a struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.GetTicketHistory(a.ID)
+
+	case "add_comment":
+		var a struct {
+			TicketID string `json:"ticketId"`
+			models.CreateCommentRequest
+		}
+		json.Unmarshal(args, &a)
+		if a.TicketID == "" || a.Body == "" {
+			return nil, fmt.Errorf("ticketId and body are required")
+		}
+		if a.Author == "" {
+			a.Author = "assistant"
+		}
+		c, err := s.store.AddComment(a.TicketID, a.CreateCommentRequest)
+		if c == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return c, err
+
+	case "list_comments":
+		var a struct {
+			TicketID string `json:"ticketId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListComments(a.TicketID)
+
+	case "list_attachments":
+		var a struct {
+			TicketID string `json:"ticketId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListAttachments(a.TicketID)
+
+	case "read_attachment":
+		var a struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(args, &a)
+		att, content, err := s.store.ReadTextAttachment(a.ID)
+		if att == nil && err == nil {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		truncated := len(content) > maxAttachmentText
+		if truncated {
+			content = strings.ToValidUTF8(content[:maxAttachmentText], "")
+		}
+		return struct {
+			*models.Attachment
+			Content   string `json:"content"`
+			Truncated bool   `json:"truncated,omitempty"`
+		}{att, content, truncated}, nil
+
+	case "log_time":
+		var a struct {
+			TicketID string `json:"ticketId"`
+			models.LogTimeRequest
+		}
+		json.Unmarshal(args, &a)
+		e, err := s.store.LogTime(a.TicketID, a.LogTimeRequest)
+		if e == nil && err == nil {
+			return nil, fmt.Errorf("ticket not found")
+		}
+		return e, err
+
+	case "list_time_entries":
+		var a struct {
+			TicketID string `json:"ticketId"`
+		}
+		json.Unmarshal(args, &a)
+		return s.store.ListTimeEntries(a.TicketID)
+
+	case "get_time_rollup":
+		var a struct {
+			By string `json:"by"`
+		}
+		json.Unmarshal(args, &a)
+		if a.By == "" {
+			a.By = models.RollupByProject
+		}
+		return s.store.TimeRollups(a.By)
+
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
 }
 
-func (s *MCPServer) toolDefinitions() []toolDef {
-	return []toolDef{
-		// --- Projects (top-level grouping) ---
-		{
-			Name:        "list_projects",
-			Description: "List all projects with optional status filter. Projects are the top-level grouping — use them like epics or initiatives to organize related work.",
-			InputSchema: jsonSchema{
-				Type: "object",
+// statusEnum lists every status key used by any project workflow so tool
+// schemas reflect custom columns.
+func (s *MCPServer) statusEnum() []string {
+	statuses, err := s.store.ListWorkflowStatuses()
+	if err != nil {
+		statuses = models.DefaultWorkflowStatuses()
+	}
+	keys := make([]string, len(statuses))
+	for i, st := range statuses {
+		keys[i] = st.Status
+	}
+	return keys
+}
+
+// customFieldProps describes the custom fields of every project as the
+// properties of a fields argument, so that ticket tool schemas follow what
+// projects define. A key several projects define with different types is
+// described as a string, which every field type accepts.
+func (s *MCPServer) customFieldProps() map[string]schemaProp {
+	fields, err := s.store.ListCustomFields("")
+	if err != nil || len(fields) == 0 {
+		return nil
+	}
+	projects, err := s.store.ListProjects("")
+	if err != nil {
+		return nil
+	}
+	prefixes := make(map[string]string, len(projects))
+	for _, p := range projects {
+		prefixes[p.ID] = p.Prefix
+	}
+
+	props := make(map[string]schemaProp)
+	for _, f := range fields {
+		prop := schemaProp{Type: "string", Description: fmt.Sprintf("%s, a %s field of %s", f.Name, f.Type, prefixes[f.ProjectID])}
+		switch f.Type {
+		case models.FieldNumber:
+			prop.Type = "number"
+		case models.FieldDate:
+			prop.Description += " (YYYY-MM-DD)"
+		case models.FieldSelect:
+			prop.Enum = f.Options
+		case models.FieldMultiSelect:
+			prop.Type = "array"
+			prop.Items = &jsonSchema{Type: "string", Enum: f.Options}
+		}
+		if other, ok := props[f.Key]; ok {
+			prop.Description = other.Description + "; " + prop.Description
+			if other.Type != prop.Type || other.Items != nil {
+				prop = schemaProp{Type: "string", Description: prop.Description}
+			} else if prop.Enum != nil {
+				prop.Enum = append(append([]string{}, other.Enum...), prop.Enum...)
+			}
+		}
+		props[f.Key] = prop
+	}
+	return props
+}
+
+// stringProps describes the same properties as props, each given as a
+// string, as filters take them.
+func stringProps(props map[string]schemaProp) map[string]schemaProp {
+	if props == nil {
+		return nil
+	}
+	strs := make(map[string]schemaProp, len(props))
+	for key, prop := range props {
+		strs[key] = schemaProp{Type: "string", Description: prop.Description}
+	}
+	return strs
+}
+
+func (s *MCPServer) toolDefinitions() []toolDef {
+	statuses := s.statusEnum()
+	fieldProps := s.customFieldProps()
+	return []toolDef{
+		// --- Projects (top-level grouping) ---
+		{
+			Name:        "list_projects",
+			Description: "List all projects with optional status filter. Projects are the top-level grouping — use them like epics or initiatives to organize related work.",
+			InputSchema: jsonSchema{
+				Type: "object",
 				Properties: map[string]schemaProp{
 					"status": {Type: "string", Description: "Filter by status", Enum: []string{"active", "archived"}},
 				},
@@ -379,6 +937,10 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 					"description": {Type: "string", Description: "Project description — goals, scope, context, and any high-level details about this body of work"},
 					"icon":        {Type: "string", Description: "Emoji icon"},
 					"color":       {Type: "string", Description: "Hex color code"},
+					"blockedMovePolicy": {Type: "string", Description: "What happens when a ticket with open blockers is moved into an active or done status (default allow)",
+						Enum: []string{models.BlockedMoveAllow, models.BlockedMoveWarn, models.BlockedMoveBlock}},
+					"estimateUnit": {Type: "string", Description: "What ticket estimates count (default points)",
+						Enum: []string{models.EstimatePoints, models.EstimateHours}},
 				},
 				Required: []string{"name", "prefix"},
 			},
@@ -396,19 +958,101 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 					"icon":        {Type: "string", Description: "Emoji icon"},
 					"color":       {Type: "string", Description: "Hex color"},
 					"status":      {Type: "string", Description: "Status", Enum: []string{"active", "archived"}},
+					"blockedMovePolicy": {Type: "string", Description: "What happens when a ticket with open blockers is moved into an active or done status",
+						Enum: []string{models.BlockedMoveAllow, models.BlockedMoveWarn, models.BlockedMoveBlock}},
+					"estimateUnit": {Type: "string", Description: "What ticket estimates count",
+						Enum: []string{models.EstimatePoints, models.EstimateHours}},
 				},
 				Required: []string{"id"},
 			},
 		},
+		{
+			Name: "clone_project",
+			Description: "Copy a project's settings, workflow, milestones and ticket templates into a new project, " +
+				"optionally with its tickets, subtasks, labels and the links between them. Use it to stamp out " +
+				"a standard project such as a service launch checklist.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":      {Type: "string", Description: "ID of the project to copy"},
+					"name":    {Type: "string", Description: "Name of the new project"},
+					"prefix":  {Type: "string", Description: "Ticket prefix of the new project, e.g. PAY"},
+					"tickets": {Type: "boolean", Description: "Copy the tickets too, renumbered from 1"},
+				},
+				Required: []string{"id", "name", "prefix"},
+			},
+		},
 		{
 			Name:        "delete_project",
-			Description: "Delete a project and all its tickets",
+			Description: "Move a project and all its tickets to the trash (see restore_from_trash)",
 			InputSchema: jsonSchema{
 				Type:       "object",
 				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Project ID"}},
 				Required:   []string{"id"},
 			},
 		},
+		{
+			Name: "get_workflow",
+			Description: "Get a project's workflow: the ordered status columns tickets move through. " +
+				"Each status has a category (backlog, active or done) describing how far along the work is.",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"projectId": {Type: "string", Description: "Project ID"}},
+				Required:   []string{"projectId"},
+			},
+		},
+		{
+			Name: "update_workflow",
+			Description: "Replace a project's workflow with a new ordered list of statuses. " +
+				"Statuses still used by tickets in the project cannot be removed.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Project ID"},
+					"statuses": {Type: "array", Description: "Ordered statuses, each with 'status', 'name' and 'category'", Items: &jsonSchema{
+						Type: "object",
+						Properties: map[string]schemaProp{
+							"status":   {Type: "string", Description: "Status key (e.g. review)"},
+							"name":     {Type: "string", Description: "Column display name"},
+							"category": {Type: "string", Description: "Status category", Enum: []string{models.StatusCategoryBacklog, models.StatusCategoryActive, models.StatusCategoryDone}},
+						},
+						Required: []string{"status"},
+					}},
+				},
+				Required: []string{"projectId", "statuses"},
+			},
+		},
+		{
+			Name:        "get_wip_limits",
+			Description: "Get a project's work-in-progress limits: the most tickets allowed in a status, project-wide or per team",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"projectId": {Type: "string", Description: "Project ID"}},
+				Required:   []string{"projectId"},
+			},
+		},
+		{
+			Name: "set_wip_limits",
+			Description: "Replace a project's work-in-progress limits. Moving a ticket into a full status fails with the " +
+				"block policy and returns the ticket with a warning with warn.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Project ID"},
+					"limits": {Type: "array", Description: "Every limit of the project; an empty list removes them all", Items: &jsonSchema{
+						Type: "object",
+						Properties: map[string]schemaProp{
+							"status": {Type: "string", Description: "Status key (e.g. in_progress)"},
+							"limit":  {Type: "number", Description: "Most tickets allowed in the status"},
+							"policy": {Type: "string", Description: "What happens past the limit (default warn)", Enum: []string{models.WIPWarn, models.WIPBlock}},
+							"teamId": {Type: "string", Description: "Limit only this team's tickets"},
+						},
+						Required: []string{"status", "limit"},
+					}},
+				},
+				Required: []string{"projectId", "limits"},
+			},
+		},
 		// --- Teams ---
 		{
 			Name:        "list_teams",
@@ -451,30 +1095,70 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 		},
 		{
 			Name:        "delete_team",
-			Description: "Delete a team",
+			Description: "Move a team to the trash (see restore_from_trash)",
 			InputSchema: jsonSchema{
 				Type:       "object",
 				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Team ID"}},
 				Required:   []string{"id"},
 			},
 		},
+		// --- Members ---
+		{
+			Name:        "list_members",
+			Description: "List members (people) that tickets can be assigned to, optionally only those on a team",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"teamId": {Type: "string", Description: "Only members of this team ID"},
+				},
+			},
+		},
 		// --- Tickets (tasks within a project) ---
 		{
-			Name:        "list_tickets",
-			Description: "List tickets with optional filters by project, team, status, and priority",
+			Name: "list_tickets",
+			Description: "List tickets with optional filters by project, team, assignee, status, and priority, or a query expression. " +
+				"Returns one page as {tickets, total, nextCursor}; pass nextCursor back as cursor, with the same filters and sort, for the next page.",
 			InputSchema: jsonSchema{
 				Type: "object",
 				Properties: map[string]schemaProp{
-					"projectId": {Type: "string", Description: "Filter by project ID"},
-					"teamId":    {Type: "string", Description: "Filter by team ID"},
-					"status":    {Type: "string", Description: "Filter by status", Enum: []string{"todo", "in_progress", "done"}},
-					"priority":  {Type: "string", Description: "Filter by priority", Enum: []string{"urgent", "high", "medium", "low"}},
+					"projectId":   {Type: "string", Description: "Filter by project ID"},
+					"teamId":      {Type: "string", Description: "Filter by team ID"},
+					"assigneeId":  {Type: "string", Description: "Filter by assignee member ID, or 'none' for unassigned"},
+					"sprintId":    {Type: "string", Description: "Filter by sprint ID, or 'none' for the backlog"},
+					"milestoneId": {Type: "string", Description: "Filter by milestone ID, or 'none' for tickets without one"},
+					"status":      {Type: "string", Description: "Filter by status", Enum: statuses},
+					"priority":    {Type: "string", Description: "Filter by priority", Enum: models.Priorities()},
+					"query": {Type: "string", Description: "Query expression, e.g. 'project:AUTH status:!done priority>=high label:bug due<2026-11-01 is:blocked'. " +
+						"Fields: project, status, priority, label, assignee (or none, me), team, sprint (or none, active), milestone (or none), due, created, updated, number, " +
+						"is (blocked, assigned, unassigned, overdue, open, done), text, and custom field keys (or none). Operators : :! < <= > >=; a,b matches either value; " +
+						"terms are ANDed, OR, NOT, -term and parentheses combine them, and bare words are full-text search"},
+					"fields": {Type: "object", Description: "Custom field values to match by key, e.g. {\"environment\": \"prod\"}; 'none' matches tickets without a value",
+						Properties: stringProps(fieldProps)},
+					"sort":   {Type: "string", Description: "Sort key (default position, i.e. board order)", Enum: models.TicketSorts()},
+					"order":  {Type: "string", Description: "Sort direction (default depends on the sort key)", Enum: []string{"asc", "desc"}},
+					"limit":  {Type: "number", Description: "Page size (default 50)"},
+					"cursor": {Type: "string", Description: "nextCursor from the previous page"},
+				},
+			},
+		},
+		{
+			Name: "search_tickets",
+			Description: "Full-text search across ticket titles, descriptions, subtasks and comments. " +
+				"Returns tickets ranked by relevance, each with a snippet where matches are wrapped in **.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"q":         {Type: "string", Description: "Search text (e.g. 'oauth refresh'); words are prefix-matched"},
+					"projectId": {Type: "string", Description: "Limit to a project ID"},
+					"status":    {Type: "string", Description: "Limit to a status", Enum: statuses},
+					"limit":     {Type: "number", Description: "Maximum results (default 20)"},
 				},
+				Required: []string{"q"},
 			},
 		},
 		{
 			Name:        "get_ticket",
-			Description: "Get detailed ticket information including subtasks, labels, and dependencies",
+			Description: "Get detailed ticket information including subtasks, labels, relations to other tickets, comments and attachments",
 			InputSchema: jsonSchema{
 				Type:       "object",
 				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Ticket ID"}},
@@ -491,14 +1175,20 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 				Type: "object",
 				Properties: map[string]schemaProp{
 					"projectId":   {Type: "string", Description: "Project ID"},
-					"title":       {Type: "string", Description: "Ticket title"},
+					"template":    {Type: "string", Description: "Name or ID of a project ticket template (see list_templates) to prefill the ticket from"},
+					"title":       {Type: "string", Description: "Ticket title, required unless the template sets one"},
 					"description": {Type: "string", Description: "Rich text description"},
-					"status":      {Type: "string", Description: "Initial status", Enum: []string{"todo", "in_progress", "done"}},
-					"priority":    {Type: "string", Description: "Priority level", Enum: []string{"urgent", "high", "medium", "low"}},
+					"status":      {Type: "string", Description: "Initial status", Enum: statuses},
+					"priority":    {Type: "string", Description: "Priority level", Enum: models.Priorities()},
 					"teamId":      {Type: "string", Description: "Team ID"},
+					"assigneeId":  {Type: "string", Description: "Assignee member ID (see list_members)"},
+					"sprintId":    {Type: "string", Description: "Sprint ID to schedule the ticket into (see list_sprints)"},
+					"milestoneId": {Type: "string", Description: "Milestone ID of the same project (see list_milestones)"},
 					"dueDate":     {Type: "string", Description: "Due date (YYYY-MM-DD)"},
+					"estimate":    {Type: "number", Description: "Estimate in the project's unit (story points or hours)"},
+					"fields":      {Type: "object", Description: "Custom field values by key (see list_custom_fields)", Properties: fieldProps},
 				},
-				Required: []string{"projectId", "title"},
+				Required: []string{"projectId"},
 			},
 		},
 		{
@@ -510,29 +1200,92 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 					"id":          {Type: "string", Description: "Ticket ID"},
 					"title":       {Type: "string", Description: "Ticket title"},
 					"description": {Type: "string", Description: "Description"},
-					"status":      {Type: "string", Description: "Status", Enum: []string{"todo", "in_progress", "done"}},
-					"priority":    {Type: "string", Description: "Priority", Enum: []string{"urgent", "high", "medium", "low"}},
-					"teamId":      {Type: "string", Description: "Team ID"},
-					"dueDate":     {Type: "string", Description: "Due date (YYYY-MM-DD)"},
+					"status":      {Type: "string", Description: "Status", Enum: statuses},
+					"priority":    {Type: "string", Description: "Priority", Enum: models.Priorities()},
+					"teamId":      {Type: "string", Description: "Team ID, or empty string to clear"},
+					"assigneeId":  {Type: "string", Description: "Assignee member ID, or empty string to unassign"},
+					"sprintId":    {Type: "string", Description: "Sprint ID, or empty string to move back to the backlog"},
+					"milestoneId": {Type: "string", Description: "Milestone ID, or empty string to clear"},
+					"dueDate":     {Type: "string", Description: "Due date (YYYY-MM-DD), or empty string to clear"},
+					"estimate":    {Type: "number", Description: "Estimate in the project's unit, or 0 to clear"},
+					"fields":      {Type: "object", Description: "Custom field values to set by key, null to clear one; others are kept", Properties: fieldProps},
 				},
 				Required: []string{"id"},
 			},
 		},
 		{
-			Name:        "move_ticket",
-			Description: "Move ticket to a different status column",
+			Name: "move_ticket",
+			Description: "Move ticket to a different status column. Depending on the project's blockedMovePolicy, moving a ticket " +
+				"with open blockers into an active or done status either fails or returns the ticket with a warning. " +
+				"Moving into a status at its WIP limit (see get_wip_limits) likewise fails or warns; finish or move " +
+				"another ticket out of that status before starting more work. Pass after and/or before to place the " +
+				"ticket next to other tickets of the column; otherwise it goes to the bottom.",
 			InputSchema: jsonSchema{
 				Type: "object",
 				Properties: map[string]schemaProp{
 					"id":     {Type: "string", Description: "Ticket ID"},
-					"status": {Type: "string", Description: "Target status", Enum: []string{"todo", "in_progress", "done"}},
+					"status": {Type: "string", Description: "Target status (default: the status of the before or after ticket)", Enum: statuses},
+					"after":  {Type: "string", Description: "ID of the ticket to place this one right after"},
+					"before": {Type: "string", Description: "ID of the ticket to place this one right before"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		{
+			Name: "clone_ticket",
+			Description: "Copy a ticket as new work in its first workflow status, with its description, priority, " +
+				"labels, blockers and unchecked subtasks",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":        {Type: "string", Description: "ID of the ticket to copy"},
+					"projectId": {Type: "string", Description: "Project for the copy (default: the same project)"},
+					"title":     {Type: "string", Description: "Title for the copy (default: the same title)"},
 				},
-				Required: []string{"id", "status"},
+				Required: []string{"id"},
 			},
 		},
 		{
 			Name:        "delete_ticket",
-			Description: "Delete a ticket",
+			Description: "Move a ticket to the trash (see restore_from_trash)",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Ticket ID"}},
+				Required:   []string{"id"},
+			},
+		},
+		{
+			Name: "link_tickets",
+			Description: "Link two tickets with a typed relation, read as '<id> <type> <relatedId>' (e.g. A blocks B). " +
+				"The inverse shows up on the other ticket automatically (blocks/blocked_by, duplicates/duplicated_by, " +
+				"caused_by/causes, follows_up/followed_by; relates_to is symmetric).",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":        {Type: "string", Description: "Ticket ID"},
+					"type":      {Type: "string", Description: "Relation type", Enum: models.RelationTypes()},
+					"relatedId": {Type: "string", Description: "ID of the ticket to link to"},
+				},
+				Required: []string{"id", "type", "relatedId"},
+			},
+		},
+		{
+			Name:        "unlink_tickets",
+			Description: "Remove a typed relation between two tickets, using the same arguments that created it",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":        {Type: "string", Description: "Ticket ID"},
+					"type":      {Type: "string", Description: "Relation type", Enum: models.RelationTypes()},
+					"relatedId": {Type: "string", Description: "ID of the linked ticket"},
+				},
+				Required: []string{"id", "type", "relatedId"},
+			},
+		},
+		{
+			Name: "get_ticket_history",
+			Description: "Get the full change history of a ticket, oldest first: creation, every field change " +
+				"(old and new value), moves between statuses and deletion, with who made each change and from where (web, cli, mcp)",
 			InputSchema: jsonSchema{
 				Type:       "object",
 				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Ticket ID"}},
@@ -542,11 +1295,293 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 		// --- Board ---
 		{
 			Name:        "get_board",
-			Description: "Get full Kanban board grouped by status columns (todo, in_progress, done)",
+			Description: "Get full Kanban board grouped by the project's workflow status columns, or only a sprint's tickets. Project board columns include their ticket count and WIP limits",
 			InputSchema: jsonSchema{
 				Type: "object",
 				Properties: map[string]schemaProp{
 					"projectId": {Type: "string", Description: "Filter by project ID (optional)"},
+					"sprintId":  {Type: "string", Description: "Show this sprint's board, or 'current' for the active sprint (of projectId, if given)"},
+				},
+			},
+		},
+		// --- Sprints (time boxes tickets are scheduled into) ---
+		{
+			Name: "list_sprints",
+			Description: "List sprints in start order with their status (planned, active, closed) and ticket counts. " +
+				"Sprints without a projectId span projects.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Only this project's sprints and cross-project ones"},
+				},
+			},
+		},
+		{
+			Name:        "create_sprint",
+			Description: "Create a sprint. Schedule tickets into it with create_ticket or update_ticket and sprintId.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"name":      {Type: "string", Description: "Sprint name (e.g. 'Sprint 14')"},
+					"goal":      {Type: "string", Description: "What the sprint should achieve"},
+					"startDate": {Type: "string", Description: "Start date (YYYY-MM-DD)"},
+					"endDate":   {Type: "string", Description: "End date (YYYY-MM-DD)"},
+					"projectId": {Type: "string", Description: "Project ID; omit for a cross-project sprint"},
+				},
+				Required: []string{"name", "startDate", "endDate"},
+			},
+		},
+		{
+			Name:        "update_sprint",
+			Description: "Update a sprint's name, goal or dates",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":        {Type: "string", Description: "Sprint ID"},
+					"name":      {Type: "string", Description: "Sprint name"},
+					"goal":      {Type: "string", Description: "Sprint goal"},
+					"startDate": {Type: "string", Description: "Start date (YYYY-MM-DD)"},
+					"endDate":   {Type: "string", Description: "End date (YYYY-MM-DD)"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		{
+			Name: "close_sprint",
+			Description: "Close a sprint. Tickets not in a done status roll into nextSprintId, or else the next open sprint " +
+				"of the same scope, or else back to the backlog. Returns the moved ticket IDs.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":           {Type: "string", Description: "Sprint ID"},
+					"nextSprintId": {Type: "string", Description: "Sprint to roll unfinished tickets into (optional)"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		// --- Milestones (release targets within a project) ---
+		{
+			Name: "list_milestones",
+			Description: "List milestones by target date with progress: ticket and subtask done/total counts, " +
+				"percent of tickets done, and whether the target date has passed with tickets still open",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Filter by project ID"},
+				},
+			},
+		},
+		{
+			Name:        "create_milestone",
+			Description: "Create a milestone (e.g. 'v1.2', 'beta launch') in a project. Add tickets with create_ticket or update_ticket and milestoneId.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId":   {Type: "string", Description: "Project ID"},
+					"name":        {Type: "string", Description: "Milestone name, unique within the project"},
+					"description": {Type: "string", Description: "What the milestone delivers"},
+					"targetDate":  {Type: "string", Description: "Target date (YYYY-MM-DD)"},
+				},
+				Required: []string{"projectId", "name"},
+			},
+		},
+		{
+			Name:        "update_milestone",
+			Description: "Update a milestone's name, description or target date",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":          {Type: "string", Description: "Milestone ID"},
+					"name":        {Type: "string", Description: "Milestone name"},
+					"description": {Type: "string", Description: "Milestone description"},
+					"targetDate":  {Type: "string", Description: "Target date (YYYY-MM-DD), or empty string to clear"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		// --- Ticket templates ---
+		{
+			Name: "list_templates",
+			Description: "List ticket templates (e.g. 'bug', 'feature') with their title pattern, description skeleton, " +
+				"default priority, team and labels, and subtask checklist. Pass a template's name to create_ticket to use it.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Filter by project ID"},
+				},
+			},
+		},
+		{
+			Name:        "create_template",
+			Description: "Create a ticket template in a project, for tickets that always start the same way",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId":   {Type: "string", Description: "Project ID"},
+					"name":        {Type: "string", Description: "Template name, unique within the project (e.g. 'bug')"},
+					"title":       {Type: "string", Description: "Title pattern: {title} becomes the ticket's title and {date} today's date, e.g. 'Bug: {title}'"},
+					"description": {Type: "string", Description: "Description skeleton"},
+					"priority":    {Type: "string", Description: "Default priority", Enum: models.Priorities()},
+					"teamId":      {Type: "string", Description: "Default team ID"},
+					"labels":      {Type: "array", Description: "Label IDs added to every ticket", Items: &jsonSchema{Type: "string"}},
+					"subtasks":    {Type: "array", Description: "Subtask titles added to every ticket", Items: &jsonSchema{Type: "string"}},
+				},
+				Required: []string{"projectId", "name"},
+			},
+		},
+		{
+			Name:        "update_template",
+			Description: "Update a ticket template. Labels and subtasks, when given, replace the current ones.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":          {Type: "string", Description: "Template ID"},
+					"name":        {Type: "string", Description: "Template name"},
+					"title":       {Type: "string", Description: "Title pattern"},
+					"description": {Type: "string", Description: "Description skeleton"},
+					"priority":    {Type: "string", Description: "Default priority, or empty string to clear"},
+					"teamId":      {Type: "string", Description: "Default team ID, or empty string to clear"},
+					"labels":      {Type: "array", Description: "Label IDs", Items: &jsonSchema{Type: "string"}},
+					"subtasks":    {Type: "array", Description: "Subtask titles", Items: &jsonSchema{Type: "string"}},
+				},
+				Required: []string{"id"},
+			},
+		},
+		// --- Custom fields ---
+		{
+			Name: "list_custom_fields",
+			Description: "List the custom fields projects add to their tickets (e.g. customer, environment, affected version), " +
+				"with their key, type and select options. Tickets carry their values under fields, by key.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Filter by project ID"},
+				},
+			},
+		},
+		{
+			Name:        "create_custom_field",
+			Description: "Add a typed custom field to a project's tickets",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Project ID"},
+					"key":       {Type: "string", Description: "Key used in ticket fields and queries: lowercase letters, digits and underscores (e.g. 'customer')"},
+					"name":      {Type: "string", Description: "Display name (default: the key)"},
+					"type":      {Type: "string", Description: "Value type", Enum: models.CustomFieldTypes()},
+					"options":   {Type: "array", Description: "Choices of a select or multi_select field", Items: &jsonSchema{Type: "string"}},
+				},
+				Required: []string{"projectId", "key", "type"},
+			},
+		},
+		{
+			Name:        "update_custom_field",
+			Description: "Rename a custom field or replace its options; the key and type cannot change",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":      {Type: "string", Description: "Field ID"},
+					"name":    {Type: "string", Description: "Display name"},
+					"options": {Type: "array", Description: "Choices of a select or multi_select field", Items: &jsonSchema{Type: "string"}},
+				},
+				Required: []string{"id"},
+			},
+		},
+		{
+			Name:        "delete_custom_field",
+			Description: "Delete a custom field and every ticket's value for it",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Field ID"}},
+				Required:   []string{"id"},
+			},
+		},
+		// --- Recurring tickets ---
+		{
+			Name:        "list_recurrences",
+			Description: "List recurring tickets with their schedule and next run time",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Filter by project ID"},
+				},
+			},
+		},
+		{
+			Name: "create_recurrence",
+			Description: "Create a ticket automatically on a schedule, e.g. a weekly dependency update. " +
+				"The server creates each occurrence once, even across restarts.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Project ID"},
+					"rule": {Type: "string", Description: "Cron expression in server local time (minute hour day-of-month month day-of-week, " +
+						"e.g. '0 9 * * MON' or '0 10 1 * *') or @hourly, @daily, @weekly, @monthly, @yearly"},
+					"ticket": {Type: "object", Description: "Ticket to create, with the fields of create_ticket except projectId " +
+						"(title, description, priority, teamId, assigneeId, labels, estimate, template). {date} in the title becomes the occurrence date."},
+				},
+				Required: []string{"projectId", "rule", "ticket"},
+			},
+		},
+		{
+			Name:        "update_recurrence",
+			Description: "Change a recurrence's schedule or ticket, or pause and resume it. Resuming skips missed occurrences.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"id":     {Type: "string", Description: "Recurrence ID"},
+					"rule":   {Type: "string", Description: "New cron expression or shorthand"},
+					"ticket": {Type: "object", Description: "Replacement ticket fields (see create_recurrence)"},
+					"paused": {Type: "boolean", Description: "Pause (true) or resume (false)"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		// --- Trash (deleted projects, tickets, teams and labels) ---
+		{
+			Name: "list_trash",
+			Description: "List deleted projects, tickets, teams and labels that can still be restored, most recent first. " +
+				"Tickets deleted with their project are counted on the project.",
+			InputSchema: jsonSchema{Type: "object"},
+		},
+		{
+			Name:        "restore_from_trash",
+			Description: "Restore a deleted project (with the tickets deleted along with it), ticket, team or label",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"id": {Type: "string", Description: "ID of the deleted item (see list_trash)"}},
+				Required:   []string{"id"},
+			},
+		},
+		// --- Undo ---
+		{
+			Name: "undo_last_change",
+			Description: "Undo your most recent change to a ticket, project or subtask (create, update, move, delete, " +
+				"check off), one change per call. Fails without changing anything if someone has since changed the same fields.",
+			InputSchema: jsonSchema{Type: "object"},
+		},
+		// --- Saved views (named ticket queries shared with the web UI and CLI) ---
+		{
+			Name:        "list_views",
+			Description: "List saved views: named ticket queries with a sort and optional grouping, such as \"my sprint backlog\"",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"projectId": {Type: "string", Description: "Only global views and those of this project ID"},
+				},
+			},
+		},
+		{
+			Name: "run_view",
+			Description: "Run a saved view by name or ID. Returns {view, tickets, groups, total, nextCursor}; groups lists ticket IDs per key " +
+				"when the view groups tickets. Pass nextCursor back as cursor for the next page.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"name":   {Type: "string", Description: "View name (case-insensitive)"},
+					"id":     {Type: "string", Description: "View ID, instead of name"},
+					"limit":  {Type: "number", Description: "Page size (default 50)"},
+					"cursor": {Type: "string", Description: "nextCursor from the previous page"},
 				},
 			},
 		},
@@ -601,5 +1636,85 @@ func (s *MCPServer) toolDefinitions() []toolDef {
 				Required:   []string{"id"},
 			},
 		},
+		// --- Comments (discussion on a ticket) ---
+		{
+			Name: "add_comment",
+			Description: "Add a comment to a ticket's discussion thread. Use comments for progress notes, findings and questions " +
+				"instead of rewriting the ticket description.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"ticketId": {Type: "string", Description: "Ticket ID"},
+					"body":     {Type: "string", Description: "Comment body (markdown)"},
+					"author":   {Type: "string", Description: "Author name (defaults to 'assistant')"},
+				},
+				Required: []string{"ticketId", "body"},
+			},
+		},
+		{
+			Name:        "list_comments",
+			Description: "List the comments on a ticket, oldest first",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"ticketId": {Type: "string", Description: "Ticket ID"}},
+				Required:   []string{"ticketId"},
+			},
+		},
+		// --- Attachments ---
+		{
+			Name:        "list_attachments",
+			Description: "List the files attached to a ticket (logs, screenshots, design docs) with their type and size",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"ticketId": {Type: "string", Description: "Ticket ID"}},
+				Required:   []string{"ticketId"},
+			},
+		},
+		{
+			Name: "read_attachment",
+			Description: fmt.Sprintf("Read the content of a text attachment such as a log file. Binary files such as "+
+				"images are refused; content past %d KB is cut off and marked truncated.", maxAttachmentText>>10),
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"id": {Type: "string", Description: "Attachment ID"}},
+				Required:   []string{"id"},
+			},
+		},
+		// --- Time tracking ---
+		{
+			Name:        "log_time",
+			Description: "Log time spent on a ticket",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"ticketId": {Type: "string", Description: "Ticket ID"},
+					"duration": {Type: "string", Description: "Time spent, e.g. 2h, 45m or 1h30m"},
+					"note":     {Type: "string", Description: "What the time went into"},
+					"date":     {Type: "string", Description: "Day the work was done (YYYY-MM-DD, default today)"},
+					"author":   {Type: "string", Description: "Who did the work (default: the connected client)"},
+				},
+				Required: []string{"ticketId", "duration"},
+			},
+		},
+		{
+			Name:        "list_time_entries",
+			Description: "List the time logged on a ticket, oldest first",
+			InputSchema: jsonSchema{
+				Type:       "object",
+				Properties: map[string]schemaProp{"ticketId": {Type: "string", Description: "Ticket ID"}},
+				Required:   []string{"ticketId"},
+			},
+		},
+		{
+			Name: "get_time_rollup",
+			Description: "Total estimated vs logged time per project or per team. Estimates are summed separately " +
+				"for story points and hours; logged time is in minutes.",
+			InputSchema: jsonSchema{
+				Type: "object",
+				Properties: map[string]schemaProp{
+					"by": {Type: "string", Description: "Group by project (default) or team", Enum: []string{models.RollupByProject, models.RollupByTeam}},
+				},
+			},
+		},
 	}
 }
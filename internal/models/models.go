@@ -3,17 +3,35 @@ package models
 import "time"
 
 type Project struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Prefix      string    `json:"prefix"`
-	Description string    `json:"description,omitempty"`
-	Icon        string    `json:"icon,omitempty"`
-	Color       string    `json:"color,omitempty"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Prefix      string `json:"prefix"`
+	Description string `json:"description,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Status      string `json:"status"`
+	// BlockedMovePolicy decides what happens when a ticket with open blockers
+	// is moved into an active or done status: allow, warn or block.
+	BlockedMovePolicy string `json:"blockedMovePolicy"`
+	// EstimateUnit says what ticket estimates count: points or hours.
+	EstimateUnit string    `json:"estimateUnit"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// Blocked move policies.
+const (
+	BlockedMoveAllow = "allow"
+	BlockedMoveWarn  = "warn"
+	BlockedMoveBlock = "block"
+)
+
+// Estimate units.
+const (
+	EstimatePoints = "points"
+	EstimateHours  = "hours"
+)
+
 type Team struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
@@ -21,25 +39,70 @@ type Team struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// Member is a person tickets can be assigned to.
+type Member struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Handle      string    `json:"handle"`
+	AvatarColor string    `json:"avatarColor,omitempty"`
+	TeamIDs     []string  `json:"teamIds"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
 type Ticket struct {
 	ID          string     `json:"id"`
 	ProjectID   string     `json:"projectId"`
 	TeamID      *string    `json:"teamId,omitempty"`
+	AssigneeID  *string    `json:"assigneeId,omitempty"`
+	SprintID    *string    `json:"sprintId,omitempty"`
+	MilestoneID *string    `json:"milestoneId,omitempty"`
 	Number      int        `json:"number"`
 	Title       string     `json:"title"`
 	Description string     `json:"description,omitempty"`
 	Status      string     `json:"status"`
 	Priority    string     `json:"priority"`
 	DueDate     *time.Time `json:"dueDate,omitempty"`
-	Position    float64    `json:"position"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	// Estimate is in the project's estimate unit.
+	Estimate  *float64  `json:"estimate,omitempty"`
+	Position  float64   `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 
 	// Populated fields (not stored directly)
-	ProjectPrefix string    `json:"projectPrefix,omitempty"`
-	Labels        []Label   `json:"labels,omitempty"`
-	Subtasks      []Subtask `json:"subtasks,omitempty"`
-	BlockedBy     []string  `json:"blockedBy,omitempty"`
+	ProjectPrefix string           `json:"projectPrefix,omitempty"`
+	LoggedMinutes int              `json:"loggedMinutes,omitempty"`
+	Labels        []Label          `json:"labels,omitempty"`
+	Subtasks      []Subtask        `json:"subtasks,omitempty"`
+	BlockedBy     []string         `json:"blockedBy,omitempty"`
+	Blocks        []string         `json:"blocks,omitempty"`
+	IsBlocked     bool             `json:"isBlocked"`
+	Relations     []TicketRelation `json:"relations,omitempty"`
+	Comments      []Comment        `json:"comments,omitempty"`
+	Attachments   []Attachment     `json:"attachments,omitempty"`
+	// Watchers are the IDs of the members notified when the ticket changes.
+	Watchers []string `json:"watchers,omitempty"`
+	// Fields holds the ticket's custom field values by key. See CustomField.
+	Fields map[string]any `json:"fields,omitempty"`
+
+	// Warnings explain rules that were bent by the change that returned this
+	// ticket, such as moving it forward while blocked.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Snippet is a highlighted excerpt of the matching text, set only on
+	// full-text search results.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+const (
+	PriorityUrgent = "urgent"
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Priorities lists the ticket priorities from most to least pressing.
+func Priorities() []string {
+	return []string{PriorityUrgent, PriorityHigh, PriorityMedium, PriorityLow}
 }
 
 // DisplayKey returns the human-readable ticket key like "AUTH-1"
@@ -84,28 +147,264 @@ type Subtask struct {
 	Position  int    `json:"position"`
 }
 
-type TicketDependency struct {
-	TicketID    string `json:"ticketId"`
-	BlockedByID string `json:"blockedById"`
+type Comment struct {
+	ID        string     `json:"id"`
+	TicketID  string     `json:"ticketId"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"createdAt"`
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+}
+
+// Attachment is a file attached to a ticket, such as a log, screenshot or
+// design doc. Files with the same content are stored once and share SHA256.
+type Attachment struct {
+	ID          string    `json:"id"`
+	TicketID    string    `json:"ticketId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Author      string    `json:"author,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Actor identifies who made a change and through which interface.
+type Actor struct {
+	Name   string `json:"name,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// Sources a change can come from.
+const (
+	SourceWeb = "web"
+	SourceCLI = "cli"
+	SourceMCP = "mcp"
+	// SourceScheduler marks tickets created by recurrence rules.
+	SourceScheduler = "scheduler"
+)
+
+// Ticket event actions recorded in the activity log.
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventMoved    = "moved"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+)
+
+// TicketEvent is one entry in the append-only activity log. Updates produce
+// one event per changed field.
+type TicketEvent struct {
+	ID        string    `json:"id"`
+	TicketID  string    `json:"ticketId"`
+	ProjectID string    `json:"projectId"`
+	TicketKey string    `json:"ticketKey"`
+	Action    string    `json:"action"`
+	Field     string    `json:"field,omitempty"`
+	OldValue  string    `json:"oldValue,omitempty"`
+	NewValue  string    `json:"newValue,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Change is a recorded change to a ticket, project or subtask that the actor
+// who made it can undo, and redo after undoing it.
+type Change struct {
+	ID        int64     `json:"id"`
+	Entity    string    `json:"entity"`
+	EntityID  string    `json:"entityId"`
+	Summary   string    `json:"summary"`
+	Actor     string    `json:"actor,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Kinds of entities whose changes can be undone.
+const (
+	ChangeTicket  = "ticket"
+	ChangeProject = "project"
+	ChangeSubtask = "subtask"
+)
+
+// Relation types. The first group is what gets stored; the second is the
+// same link seen from the other ticket.
+const (
+	RelationBlockedBy  = "blocked_by"
+	RelationRelatesTo  = "relates_to"
+	RelationDuplicates = "duplicates"
+	RelationCausedBy   = "caused_by"
+	RelationFollowsUp  = "follows_up"
+
+	RelationBlocks       = "blocks"
+	RelationDuplicatedBy = "duplicated_by"
+	RelationCauses       = "causes"
+	RelationFollowedBy   = "followed_by"
+)
+
+var relationInverses = map[string]string{
+	RelationBlockedBy:  RelationBlocks,
+	RelationRelatesTo:  RelationRelatesTo,
+	RelationDuplicates: RelationDuplicatedBy,
+	RelationCausedBy:   RelationCauses,
+	RelationFollowsUp:  RelationFollowedBy,
+}
+
+// RelationTypes lists every relation type a caller may use, stored and
+// inverse.
+func RelationTypes() []string {
+	return []string{
+		RelationBlockedBy, RelationBlocks,
+		RelationRelatesTo,
+		RelationDuplicates, RelationDuplicatedBy,
+		RelationCausedBy, RelationCauses,
+		RelationFollowsUp, RelationFollowedBy,
+	}
+}
+
+// InverseRelation returns how a relation type reads from the other ticket.
+func InverseRelation(relType string) string {
+	if inv, ok := relationInverses[relType]; ok {
+		return inv
+	}
+	for fwd, inv := range relationInverses {
+		if inv == relType {
+			return fwd
+		}
+	}
+	return ""
+}
+
+// StoredRelation maps any relation type to the stored type, reporting whether
+// the two tickets must be swapped. ok is false for unknown types.
+func StoredRelation(relType string) (stored string, swap bool, ok bool) {
+	if _, isStored := relationInverses[relType]; isStored {
+		return relType, false, true
+	}
+	inv := InverseRelation(relType)
+	return inv, true, inv != ""
+}
+
+// TicketRelation is a link from one ticket to another, described from the
+// point of view of the ticket it is attached to.
+type TicketRelation struct {
+	Type      string `json:"type"`
+	TicketID  string `json:"ticketId"`
+	TicketKey string `json:"ticketKey"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
 }
 
 // Board represents the kanban board view
 type Board struct {
 	ProjectID string   `json:"projectId,omitempty"`
+	SprintID  string   `json:"sprintId,omitempty"`
 	Columns   []Column `json:"columns"`
 }
 
 type Column struct {
-	Status  string   `json:"status"`
-	Tickets []Ticket `json:"tickets"`
+	Status   string   `json:"status"`
+	Name     string   `json:"name,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Tickets  []Ticket `json:"tickets"`
+
+	// Count is how many of the project's tickets are in the column, which
+	// is what WIPLimit applies to; on a cross-project board it is the
+	// number of tickets shown. WIPLimit is 0 when the column has no
+	// project-wide limit, and TeamLimits lists the limits for single teams.
+	Count      int            `json:"count"`
+	WIPLimit   int            `json:"wipLimit,omitempty"`
+	OverLimit  bool           `json:"overLimit,omitempty"`
+	TeamLimits []TeamWIPUsage `json:"teamLimits,omitempty"`
+}
+
+// TeamWIPUsage is how full a column is for one team's tickets.
+type TeamWIPUsage struct {
+	TeamID    string `json:"teamId"`
+	Count     int    `json:"count"`
+	WIPLimit  int    `json:"wipLimit"`
+	OverLimit bool   `json:"overLimit,omitempty"`
+}
+
+// WIPLimit caps how many of a project's tickets may be in a status at once,
+// or how many of one team's tickets when TeamID is set. Moving a ticket
+// past the limit is refused with the block policy and warned about with
+// warn.
+type WIPLimit struct {
+	Status string  `json:"status"`
+	TeamID *string `json:"teamId,omitempty"`
+	Limit  int     `json:"limit"`
+	Policy string  `json:"policy"`
+}
+
+// WIP limit policies.
+const (
+	WIPWarn  = "warn"
+	WIPBlock = "block"
+)
+
+// WIPLimits are the WIP limits of a project.
+type WIPLimits struct {
+	ProjectID string     `json:"projectId"`
+	Limits    []WIPLimit `json:"limits"`
+}
+
+// UpdateWIPLimitsRequest replaces every WIP limit of a project.
+type UpdateWIPLimitsRequest struct {
+	Limits []WIPLimit `json:"limits"`
+}
+
+// Status categories group workflow statuses by how far along the work is.
+const (
+	StatusCategoryBacklog = "backlog"
+	StatusCategoryActive  = "active"
+	StatusCategoryDone    = "done"
+)
+
+// WorkflowStatus is a single column in a project's workflow.
+type WorkflowStatus struct {
+	Status   string `json:"status"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Position int    `json:"position"`
+}
+
+// Workflow is the ordered list of statuses tickets in a project move through.
+type Workflow struct {
+	ProjectID string           `json:"projectId,omitempty"`
+	Statuses  []WorkflowStatus `json:"statuses"`
+}
+
+// DefaultWorkflowStatuses is the workflow new projects start with.
+func DefaultWorkflowStatuses() []WorkflowStatus {
+	return []WorkflowStatus{
+		{Status: "todo", Name: "Todo", Category: StatusCategoryBacklog, Position: 0},
+		{Status: "in_progress", Name: "In Progress", Category: StatusCategoryActive, Position: 1},
+		{Status: "done", Name: "Done", Category: StatusCategoryDone, Position: 2},
+	}
+}
+
+type UpdateWorkflowRequest struct {
+	Statuses []WorkflowStatus `json:"statuses"`
 }
 
 type CreateProjectRequest struct {
-	Name        string `json:"name"`
-	Prefix      string `json:"prefix"`
-	Description string `json:"description,omitempty"`
-	Icon        string `json:"icon,omitempty"`
-	Color       string `json:"color,omitempty"`
+	Name              string `json:"name"`
+	Prefix            string `json:"prefix"`
+	Description       string `json:"description,omitempty"`
+	Icon              string `json:"icon,omitempty"`
+	Color             string `json:"color,omitempty"`
+	BlockedMovePolicy string `json:"blockedMovePolicy,omitempty"`
+	EstimateUnit      string `json:"estimateUnit,omitempty"`
+}
+
+// CloneProjectRequest names the copy made by cloning a project. Tickets
+// says whether the project's tickets are copied along with its structure.
+type CloneProjectRequest struct {
+	Name    string `json:"name"`
+	Prefix  string `json:"prefix"`
+	Tickets bool   `json:"tickets,omitempty"`
 }
 
 type UpdateProjectRequest struct {
@@ -115,6 +414,9 @@ type UpdateProjectRequest struct {
 	Icon        *string `json:"icon,omitempty"`
 	Color       *string `json:"color,omitempty"`
 	Status      *string `json:"status,omitempty"`
+
+	BlockedMovePolicy *string `json:"blockedMovePolicy,omitempty"`
+	EstimateUnit      *string `json:"estimateUnit,omitempty"`
 }
 
 type CreateTeamRequest struct {
@@ -127,39 +429,144 @@ type UpdateTeamRequest struct {
 	Color *string `json:"color,omitempty"`
 }
 
+type CreateMemberRequest struct {
+	Name        string   `json:"name"`
+	Handle      string   `json:"handle"`
+	AvatarColor string   `json:"avatarColor,omitempty"`
+	TeamIDs     []string `json:"teamIds,omitempty"`
+}
+
+type UpdateMemberRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Handle      *string  `json:"handle,omitempty"`
+	AvatarColor *string  `json:"avatarColor,omitempty"`
+	TeamIDs     []string `json:"teamIds,omitempty"`
+}
+
 type CreateTicketRequest struct {
 	ProjectID   string   `json:"projectId"`
 	TeamID      *string  `json:"teamId,omitempty"`
+	AssigneeID  *string  `json:"assigneeId,omitempty"`
+	SprintID    *string  `json:"sprintId,omitempty"`
+	MilestoneID *string  `json:"milestoneId,omitempty"`
 	Title       string   `json:"title"`
 	Description string   `json:"description,omitempty"`
 	Status      string   `json:"status,omitempty"`
 	Priority    string   `json:"priority,omitempty"`
 	DueDate     *string  `json:"dueDate,omitempty"`
+	Estimate    *float64 `json:"estimate,omitempty"`
 	Labels      []string `json:"labels,omitempty"`
 	BlockedBy   []string `json:"blockedBy,omitempty"`
+	// Template is the ID or name of one of the project's ticket templates
+	// to fill in the fields left empty. See TicketTemplate.
+	Template string `json:"template,omitempty"`
+	// Fields sets custom field values by key. See CustomField.
+	Fields map[string]any `json:"fields,omitempty"`
 }
 
+// CloneTicketRequest places a ticket's copy: in ProjectID, or the same
+// project when empty, titled Title, or the same title when empty.
+type CloneTicketRequest struct {
+	ProjectID string `json:"projectId,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// UpdateTicketRequest changes the fields that are set. For TeamID,
+// AssigneeID, SprintID, MilestoneID and DueDate an empty string clears the
+// value, and for Estimate zero does. Fields sets the custom field values it
+// names and leaves the others; a null or empty value clears one.
 type UpdateTicketRequest struct {
 	TeamID      *string  `json:"teamId,omitempty"`
+	AssigneeID  *string  `json:"assigneeId,omitempty"`
+	SprintID    *string  `json:"sprintId,omitempty"`
+	MilestoneID *string  `json:"milestoneId,omitempty"`
 	Title       *string  `json:"title,omitempty"`
 	Description *string  `json:"description,omitempty"`
 	Status      *string  `json:"status,omitempty"`
 	Priority    *string  `json:"priority,omitempty"`
 	DueDate     *string  `json:"dueDate,omitempty"`
+	Estimate    *float64 `json:"estimate,omitempty"`
 	Position    *float64 `json:"position,omitempty"`
 	Labels      []string `json:"labels,omitempty"`
 	BlockedBy   []string `json:"blockedBy,omitempty"`
+
+	Fields map[string]any `json:"fields,omitempty"`
 }
 
+// MoveTicketRequest places a ticket in a status column, either at Position
+// or relative to the tickets with IDs Before and After in that column.
 type MoveTicketRequest struct {
 	Status   string   `json:"status"`
 	Position *float64 `json:"position,omitempty"`
+	Before   string   `json:"before,omitempty"`
+	After    string   `json:"after,omitempty"`
 }
 
 type CreateSubtaskRequest struct {
 	Title string `json:"title"`
 }
 
+type CreateCommentRequest struct {
+	Author string `json:"author,omitempty"`
+	Body   string `json:"body"`
+}
+
+type UpdateCommentRequest struct {
+	Body *string `json:"body,omitempty"`
+}
+
+// CreateAttachmentRequest describes an uploaded file. Without a ContentType
+// one is chosen from the filename's extension or the content.
+type CreateAttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType,omitempty"`
+	Author      string `json:"author,omitempty"`
+}
+
+// TimeEntry is time spent on a ticket on a given day.
+type TimeEntry struct {
+	ID        string    `json:"id"`
+	TicketID  string    `json:"ticketId"`
+	Minutes   int       `json:"minutes"`
+	Note      string    `json:"note,omitempty"`
+	Date      time.Time `json:"date"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LogTimeRequest records time on a ticket. Duration is written like 2h,
+// 45m or 1h30m; Date defaults to today and Author to the acting user.
+type LogTimeRequest struct {
+	Duration string `json:"duration"`
+	Note     string `json:"note,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Author   string `json:"author,omitempty"`
+}
+
+// TimeRollup totals estimated and logged time over the tickets of a project
+// or team. Estimates are summed separately per unit since a team can work
+// across projects that size tickets differently.
+type TimeRollup struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Tickets          int     `json:"tickets"`
+	EstimatedTickets int     `json:"estimatedTickets"`
+	EstimatedPoints  float64 `json:"estimatedPoints"`
+	EstimatedHours   float64 `json:"estimatedHours"`
+	LoggedMinutes    int     `json:"loggedMinutes"`
+}
+
+// Time rollup groupings.
+const (
+	RollupByProject = "project"
+	RollupByTeam    = "team"
+)
+
+type CreateRelationRequest struct {
+	Type     string `json:"type"`
+	TicketID string `json:"ticketId"`
+}
+
 type CreateLabelRequest struct {
 	Name  string `json:"name"`
 	Color string `json:"color"`
@@ -170,9 +577,408 @@ type UpdateLabelRequest struct {
 	Color *string `json:"color,omitempty"`
 }
 
+type CreateSprintRequest struct {
+	ProjectID *string `json:"projectId,omitempty"`
+	Name      string  `json:"name"`
+	Goal      string  `json:"goal,omitempty"`
+	StartDate string  `json:"startDate"`
+	EndDate   string  `json:"endDate"`
+}
+
+type UpdateSprintRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Goal      *string `json:"goal,omitempty"`
+	StartDate *string `json:"startDate,omitempty"`
+	EndDate   *string `json:"endDate,omitempty"`
+}
+
+// CloseSprintRequest names the sprint that unfinished tickets roll into.
+// When empty, the next open sprint of the same scope is used, and without
+// one the tickets go back to the backlog.
+type CloseSprintRequest struct {
+	NextSprintID string `json:"nextSprintId,omitempty"`
+}
+
+// CreateRecurrenceRequest sets up a recurring ticket. Ticket.ProjectID is
+// ignored in favour of ProjectID.
+type CreateRecurrenceRequest struct {
+	ProjectID string              `json:"projectId"`
+	Rule      string              `json:"rule"`
+	Ticket    CreateTicketRequest `json:"ticket"`
+}
+
+// UpdateRecurrenceRequest changes the fields that are set. Changing the rule
+// or resuming a paused recurrence schedules it from now, skipping missed
+// occurrences.
+type UpdateRecurrenceRequest struct {
+	Rule   *string              `json:"rule,omitempty"`
+	Ticket *CreateTicketRequest `json:"ticket,omitempty"`
+	Paused *bool                `json:"paused,omitempty"`
+}
+
+type CreateTemplateRequest struct {
+	ProjectID   string   `json:"projectId"`
+	Name        string   `json:"name"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Priority    string   `json:"priority,omitempty"`
+	TeamID      *string  `json:"teamId,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Subtasks    []string `json:"subtasks,omitempty"`
+}
+
+// UpdateTemplateRequest changes the fields that are set. An empty TeamID
+// or Priority clears it, and Labels and Subtasks replace the current ones.
+type UpdateTemplateRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Title       *string  `json:"title,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Priority    *string  `json:"priority,omitempty"`
+	TeamID      *string  `json:"teamId,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Subtasks    []string `json:"subtasks,omitempty"`
+}
+
+type CreateMilestoneRequest struct {
+	ProjectID   string `json:"projectId"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TargetDate  string `json:"targetDate,omitempty"`
+}
+
+// UpdateMilestoneRequest changes the fields that are set. An empty
+// TargetDate clears it.
+type UpdateMilestoneRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	TargetDate  *string `json:"targetDate,omitempty"`
+}
+
+type CreateViewRequest struct {
+	Name      string  `json:"name"`
+	ProjectID *string `json:"projectId,omitempty"`
+	Query     string  `json:"query"`
+	Sort      string  `json:"sort,omitempty"`
+	Order     string  `json:"order,omitempty"`
+	GroupBy   string  `json:"groupBy,omitempty"`
+}
+
+// UpdateViewRequest changes the fields that are set. An empty ProjectID
+// makes the view global again.
+type UpdateViewRequest struct {
+	Name      *string `json:"name,omitempty"`
+	ProjectID *string `json:"projectId,omitempty"`
+	Query     *string `json:"query,omitempty"`
+	Sort      *string `json:"sort,omitempty"`
+	Order     *string `json:"order,omitempty"`
+	GroupBy   *string `json:"groupBy,omitempty"`
+}
+
 type TicketFilter struct {
+	ProjectID string `json:"projectId,omitempty"`
+	TeamID    string `json:"teamId,omitempty"`
+	// AssigneeID filters by assignee; "none" matches unassigned tickets.
+	AssigneeID string `json:"assigneeId,omitempty"`
+	// SprintID filters by sprint; "none" matches unscheduled tickets.
+	SprintID string `json:"sprintId,omitempty"`
+	// MilestoneID filters by milestone; "none" matches tickets without one.
+	MilestoneID string `json:"milestoneId,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+	// Query is free text matched against titles, descriptions, subtasks and
+	// comments. Results are ordered by relevance unless Sort says otherwise.
+	Query string `json:"q,omitempty"`
+	// Expr is a ticket query such as "status:!done priority>=high
+	// label:bug", combined with the other filters. See package query.
+	Expr string `json:"query,omitempty"`
+	// Fields matches custom field values by key, like the key:value query
+	// term; "none" matches tickets without a value.
+	Fields map[string]string `json:"fields,omitempty"`
+	Limit  int               `json:"limit,omitempty"`
+	// Sort is one of TicketSorts and Order is "asc" or "desc"; both default
+	// per sort key. Cursor continues a previous page in the same order.
+	Sort   string `json:"sort,omitempty"`
+	Order  string `json:"order,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+const (
+	SortPosition  = "position"
+	SortCreated   = "created"
+	SortUpdated   = "updated"
+	SortDueDate   = "due"
+	SortPriority  = "priority"
+	SortNumber    = "number"
+	SortRelevance = "relevance"
+)
+
+// TicketSorts lists the keys tickets can be sorted by. Relevance only
+// applies to full-text searches.
+func TicketSorts() []string {
+	return []string{SortPosition, SortCreated, SortUpdated, SortDueDate, SortPriority, SortNumber, SortRelevance}
+}
+
+// TicketPage is one page of a ticket listing. NextCursor is empty on the
+// last page.
+type TicketPage struct {
+	Tickets    []Ticket `json:"tickets"`
+	Total      int      `json:"total"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// Sprint is a time box that tickets are scheduled into. A sprint without a
+// ProjectID spans every project.
+type Sprint struct {
+	ID        string     `json:"id"`
+	ProjectID *string    `json:"projectId,omitempty"`
+	Name      string     `json:"name"`
+	Goal      string     `json:"goal,omitempty"`
+	StartDate time.Time  `json:"startDate"`
+	EndDate   time.Time  `json:"endDate"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	// Populated fields (not stored directly)
+	Status      string `json:"status"`
+	TicketCount int    `json:"ticketCount"`
+	DoneCount   int    `json:"doneCount"`
+}
+
+// Sprint statuses, derived from the dates and whether the sprint was closed.
+// A sprint stays active past its end date until it is closed.
+const (
+	SprintPlanned = "planned"
+	SprintActive  = "active"
+	SprintClosed  = "closed"
+)
+
+// CloseSprintResult reports where a closed sprint's unfinished tickets went.
+// NextSprintID is empty when they went back to the backlog.
+type CloseSprintResult struct {
+	Sprint       *Sprint  `json:"sprint"`
+	NextSprintID string   `json:"nextSprintId,omitempty"`
+	Moved        []string `json:"moved"`
+}
+
+// Recurrence creates a ticket from Ticket every time Rule fires. Rule is a
+// cron expression such as "0 9 * * MON" or a shorthand like @weekly, read in
+// the server's local time. A {date} in the ticket title is replaced with the
+// occurrence's date.
+type Recurrence struct {
+	ID        string              `json:"id"`
+	ProjectID string              `json:"projectId"`
+	Rule      string              `json:"rule"`
+	Ticket    CreateTicketRequest `json:"ticket"`
+	Paused    bool                `json:"paused"`
+	NextRunAt time.Time           `json:"nextRunAt"`
+	LastRunAt *time.Time          `json:"lastRunAt,omitempty"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// RecurrenceRun is an occurrence of a recurrence and the ticket made for it.
+type RecurrenceRun struct {
+	RecurrenceID string    `json:"recurrenceId"`
+	Occurrence   time.Time `json:"occurrence"`
+	TicketID     string    `json:"ticketId"`
+	TicketKey    string    `json:"ticketKey"`
+}
+
+// TicketTemplate prefills tickets created from it, such as a bug report with
+// its description skeleton, labels and checklist. Title is a pattern in
+// which {title} is replaced with the title given for the ticket and {date}
+// with the current date. A pattern without {title} is only used when no
+// title is given. Fields the ticket request sets win over the template's,
+// and labels are combined.
+type TicketTemplate struct {
+	ID          string    `json:"id"`
+	ProjectID   string    `json:"projectId"`
+	Name        string    `json:"name"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Priority    string    `json:"priority,omitempty"`
+	TeamID      *string   `json:"teamId,omitempty"`
+	Labels      []Label   `json:"labels"`
+	Subtasks    []string  `json:"subtasks"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TrashItem is a deleted project, ticket, team or label waiting in the trash
+// to be restored or purged. Name is a ticket's key and title. Tickets
+// deleted along with their project are counted in Tickets rather than
+// listed on their own, and come back when the project is restored.
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ProjectID string    `json:"projectId,omitempty"`
+	Tickets   int       `json:"tickets,omitempty"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Kinds of trash items.
+const (
+	TrashProject = "project"
+	TrashTicket  = "ticket"
+	TrashTeam    = "team"
+	TrashLabel   = "label"
+)
+
+// CustomField is a typed field a project adds to its tickets, such as the
+// customer who reported a bug or the environment it happened in. Key names
+// the field in Ticket.Fields, in ticket requests and in ticket queries, and
+// cannot change. Options lists the choices of select and multi_select
+// fields.
+type CustomField struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"projectId"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Custom field types. Values are strings, except that number fields hold
+// numbers and multi_select fields lists of options. Dates are YYYY-MM-DD
+// and URLs absolute http or https links.
+const (
+	FieldText        = "text"
+	FieldNumber      = "number"
+	FieldDate        = "date"
+	FieldSelect      = "select"
+	FieldMultiSelect = "multi_select"
+	FieldURL         = "url"
+)
+
+// CustomFieldTypes lists the types a custom field can have.
+func CustomFieldTypes() []string {
+	return []string{FieldText, FieldNumber, FieldDate, FieldSelect, FieldMultiSelect, FieldURL}
+}
+
+// CreateCustomFieldRequest defines a custom field. Name defaults to the key.
+type CreateCustomFieldRequest struct {
+	ProjectID string   `json:"projectId"`
+	Key       string   `json:"key"`
+	Name      string   `json:"name,omitempty"`
+	Type      string   `json:"type"`
+	Options   []string `json:"options,omitempty"`
+}
+
+// UpdateCustomFieldRequest changes the fields that are set. Options replace
+// the current ones; tickets keep a removed option until their value is next
+// changed.
+type UpdateCustomFieldRequest struct {
+	Name    *string  `json:"name,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
+
+// Milestone groups a project's tickets toward a release or other target,
+// such as "v1.2" or "beta launch".
+type Milestone struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"projectId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	TargetDate  *time.Time `json:"targetDate,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+
+	// Populated fields (not stored directly). Progress is the percentage of
+	// tickets in a done status. A milestone is overdue once its target date
+	// has passed with tickets still open.
+	TicketCount      int  `json:"ticketCount"`
+	DoneCount        int  `json:"doneCount"`
+	SubtaskCount     int  `json:"subtaskCount"`
+	SubtaskDoneCount int  `json:"subtaskDoneCount"`
+	Progress         int  `json:"progress"`
+	Overdue          bool `json:"overdue"`
+}
+
+// SavedView is a named ticket query with its sort and grouping, shared by
+// the web UI, CLI and MCP. A view with a ProjectID only shows that project.
+type SavedView struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ProjectID *string   `json:"projectId,omitempty"`
+	Query     string    `json:"query"`
+	Sort      string    `json:"sort,omitempty"`
+	Order     string    `json:"order,omitempty"`
+	GroupBy   string    `json:"groupBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+const (
+	GroupByStatus   = "status"
+	GroupByPriority = "priority"
+	GroupByAssignee = "assignee"
+	GroupByTeam     = "team"
+	GroupByProject  = "project"
+)
+
+// ViewGroupings lists the keys a saved view can group its tickets by.
+func ViewGroupings() []string {
+	return []string{GroupByStatus, GroupByPriority, GroupByAssignee, GroupByTeam, GroupByProject}
+}
+
+// ViewResult is one page of a saved view's tickets. When the view groups
+// tickets, Groups splits the page by key, in the order each key first
+// appears in Tickets.
+type ViewResult struct {
+	View       SavedView     `json:"view"`
+	Tickets    []Ticket      `json:"tickets"`
+	Groups     []TicketGroup `json:"groups,omitempty"`
+	Total      int           `json:"total"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// TicketGroup names the tickets of a view that share a grouping key: a
+// status, priority, member handle, team name or project prefix, or "none".
+type TicketGroup struct {
+	Key       string   `json:"key"`
+	TicketIDs []string `json:"ticketIds"`
+}
+
+type ActivityFilter struct {
 	ProjectID string
-	TeamID    string
-	Status    string
-	Priority  string
+	TicketID  string
+	Limit     int
+}
+
+// Notification tells a member that a ticket they watch changed status, got
+// a comment or is no longer blocked.
+type Notification struct {
+	ID        string     `json:"id"`
+	MemberID  string     `json:"memberId"`
+	TicketID  string     `json:"ticketId"`
+	TicketKey string     `json:"ticketKey"`
+	Kind      string     `json:"kind"`
+	Message   string     `json:"message"`
+	Actor     string     `json:"actor,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+}
+
+// Notification kinds.
+const (
+	NotifyStatus    = "status"
+	NotifyComment   = "comment"
+	NotifyUnblocked = "unblocked"
+)
+
+// NotificationFilter selects a member's notifications, newest first. Limit
+// defaults to 50.
+type NotificationFilter struct {
+	MemberID string
+	Unread   bool
+	Limit    int
+}
+
+// WatchRequest adds a member to a ticket's watchers.
+type WatchRequest struct {
+	MemberID string `json:"memberId"`
 }
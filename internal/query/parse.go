@@ -0,0 +1,288 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Parse parses a query. An empty or blank query returns a nil Node, which
+// matches everything.
+//
+//	query   = or
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }
+//	unary   = ( "NOT" | "-" ) unary | primary
+//	primary = "(" or ")" | field | text
+//	field   = name op value { "," value }
+//	op      = ":" | ":!" | "=" | "!=" | "<" | "<=" | ">" | ">="
+//	text    = word | quoted
+func Parse(input string) (Node, error) {
+	p := &parser{src: []rune(input)}
+	p.skipSpace()
+	if p.done() {
+		return nil, nil
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		if p.peek() == ')' {
+			return nil, Errorf(p.pos+1, "unexpected \")\" without a matching \"(\"")
+		}
+		return nil, Errorf(p.pos+1, "unexpected %q", string(p.peek()))
+	}
+	return n, nil
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// keyword consumes word if it comes next as a whole, upper-case word.
+func (p *parser) keyword(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.src) || string(p.src[p.pos:end]) != word {
+		return false
+	}
+	if end < len(p.src) && !unicode.IsSpace(p.src[end]) && p.src[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) parseOr() (Node, error) {
+	start := p.pos + 1
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Node{first}
+	for {
+		p.skipSpace()
+		if !p.keyword("OR") {
+			break
+		}
+		p.skipSpace()
+		if p.done() || p.peek() == ')' {
+			return nil, Errorf(p.pos+1, "expected a term after OR")
+		}
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, next)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &Or{Terms: terms, Pos: start}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	start := p.pos + 1
+	var terms []Node
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ')' {
+			break
+		}
+		save := p.pos
+		if p.keyword("OR") {
+			p.pos = save
+			break
+		}
+		if p.keyword("AND") {
+			p.skipSpace()
+			if p.done() || p.peek() == ')' {
+				return nil, Errorf(p.pos+1, "expected a term after AND")
+			}
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, n)
+	}
+	switch len(terms) {
+	case 0:
+		return nil, Errorf(p.pos+1, "expected a term")
+	case 1:
+		return terms[0], nil
+	}
+	return &And{Terms: terms, Pos: start}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	start := p.pos + 1
+	negated := false
+	if p.keyword("NOT") {
+		negated = true
+		p.skipSpace()
+	} else if p.peek() == '-' && p.pos+1 < len(p.src) && !unicode.IsSpace(p.src[p.pos+1]) {
+		negated = true
+		p.pos++
+	}
+	if !negated {
+		return p.parsePrimary()
+	}
+	if p.done() {
+		return nil, Errorf(p.pos+1, "expected a term to negate")
+	}
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &Not{Term: n, Pos: start}, nil
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	start := p.pos + 1
+	switch p.peek() {
+	case '(':
+		p.pos++
+		p.skipSpace()
+		if p.peek() == ')' {
+			return nil, Errorf(p.pos+1, "empty parentheses")
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, Errorf(start, "missing \")\" to close this \"(\"")
+		}
+		p.pos++
+		return n, nil
+	case ')':
+		return nil, Errorf(start, "unexpected \")\"")
+	case '"':
+		text, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return &Text{Value: text, Pos: start}, nil
+	}
+
+	name := p.name()
+	if name != "" {
+		if op, ok := p.op(); ok {
+			return p.fieldValues(name, op, start)
+		}
+	}
+	p.pos = start - 1
+	return &Text{Value: p.bare(), Pos: start}, nil
+}
+
+// name reads a field name: letters, underscores and, after the first
+// character, digits.
+func (p *parser) name() string {
+	start := p.pos
+	for !p.done() && (unicode.IsLetter(p.peek()) || p.peek() == '_' || p.pos > start && unicode.IsDigit(p.peek())) {
+		p.pos++
+	}
+	return strings.ToLower(string(p.src[start:p.pos]))
+}
+
+func (p *parser) op() (Op, bool) {
+	rest := string(p.src[p.pos:min(p.pos+2, len(p.src))])
+	for _, op := range []struct {
+		text string
+		op   Op
+	}{
+		{":!", OpNe}, {"!=", OpNe}, {"<=", OpLe}, {">=", OpGe},
+		{":", OpEq}, {"=", OpEq}, {"<", OpLt}, {">", OpGt},
+	} {
+		if strings.HasPrefix(rest, op.text) {
+			p.pos += len([]rune(op.text))
+			return op.op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) fieldValues(name string, op Op, start int) (Node, error) {
+	f := &Field{Name: name, Op: op, Pos: start}
+	for {
+		valuePos := p.pos + 1
+		var value string
+		if p.peek() == '"' {
+			var err error
+			if value, err = p.quoted(); err != nil {
+				return nil, err
+			}
+		} else {
+			value = p.bareValue()
+		}
+		if value == "" {
+			return nil, Errorf(valuePos, "expected a value for %s", name)
+		}
+		f.Values = append(f.Values, Value{Text: value, Pos: valuePos})
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if op.Ordered() && len(f.Values) > 1 {
+		return nil, Errorf(f.Values[1].Pos, "%s cannot compare against a list of values", string(op))
+	}
+	return f, nil
+}
+
+// quoted reads a double-quoted string; \" and \\ are escapes.
+func (p *parser) quoted() (string, error) {
+	start := p.pos + 1
+	p.pos++
+	var b strings.Builder
+	for !p.done() {
+		r := p.peek()
+		p.pos++
+		switch {
+		case r == '\\' && !p.done():
+			b.WriteRune(p.peek())
+			p.pos++
+		case r == '"':
+			return b.String(), nil
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", Errorf(start, "unterminated quoted string")
+}
+
+// bare reads a word up to whitespace or a parenthesis.
+func (p *parser) bare() string {
+	start := p.pos
+	for !p.done() && !unicode.IsSpace(p.peek()) && p.peek() != '(' && p.peek() != ')' {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// bareValue reads one unquoted value of a list.
+func (p *parser) bareValue() string {
+	start := p.pos
+	for !p.done() && !unicode.IsSpace(p.peek()) && p.peek() != '(' && p.peek() != ')' && p.peek() != ',' {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// show renders a node compactly, so that expected trees fit on one line.
+func show(n Node) string {
+	switch n := n.(type) {
+	case nil:
+		return "<nil>"
+	case *And:
+		return "(AND " + showAll(n.Terms) + ")"
+	case *Or:
+		return "(OR " + showAll(n.Terms) + ")"
+	case *Not:
+		return "(NOT " + show(n.Term) + ")"
+	case *Text:
+		return fmt.Sprintf("%q", n.Value)
+	case *Field:
+		values := make([]string, len(n.Values))
+		for i, v := range n.Values {
+			values[i] = fmt.Sprintf("%q", v.Text)
+		}
+		return n.Name + string(n.Op) + strings.Join(values, ",")
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+func showAll(nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = show(n)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "<nil>"},
+		{"   ", "<nil>"},
+		{"login", `"login"`},
+		{`"login page"`, `"login page"`},
+		{`"say \"hi\" \\ bye"`, `"say \"hi\" \\ bye"`},
+		{"status:done", `status:"done"`},
+		{"Status=done", `status:"done"`},
+		{"status:!done", `status!="done"`},
+		{"status!=done", `status!="done"`},
+		{"priority>=high", `priority>="high"`},
+		{"number<10", `number<"10"`},
+		{"due<=2026-11-01", `due<="2026-11-01"`},
+		{"label:bug,ui", `label:"bug","ui"`},
+		{`assignee:"Ana Lima",bob`, `assignee:"Ana Lima","bob"`},
+		{"story_points2>3", `story_points2>"3"`},
+		{"2fa:on", `"2fa:on"`},
+
+		// AND binds tighter than OR, NOT tighter than both.
+		{"a b", `(AND "a" "b")`},
+		{"a AND b", `(AND "a" "b")`},
+		{"a b OR c", `(OR (AND "a" "b") "c")`},
+		{"a OR b c", `(OR "a" (AND "b" "c"))`},
+		{"a OR b OR c", `(OR "a" "b" "c")`},
+		{"NOT a b", `(AND (NOT "a") "b")`},
+		{"-a OR b", `(OR (NOT "a") "b")`},
+		{"NOT NOT a", `(NOT (NOT "a"))`},
+		{"-(a OR b) c", `(AND (NOT (OR "a" "b")) "c")`},
+		{"a (b OR c)", `(AND "a" (OR "b" "c"))`},
+		{"(a)", `"a"`},
+		{"-label:bug", `(NOT label:"bug")`},
+
+		// Keywords are upper case and whole words.
+		{"a or b", `(AND "a" "or" "b")`},
+		{"ORANGE", `"ORANGE"`},
+		{"NOTE", `"NOTE"`},
+		{"a - b", `(AND "a" "-" "b")`},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := show(n); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	n, err := Parse(`a label:"x y",z`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := n.(*And).Terms[1].(*Field)
+	if f.Pos != 3 || f.Values[0].Pos != 9 || f.Values[1].Pos != 15 {
+		t.Errorf("positions = %d, %d, %d, want 3, 9, 15", f.Pos, f.Values[0].Pos, f.Values[1].Pos)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"(a", 1, `missing ")"`},
+		{"a (b OR c", 3, `missing ")"`},
+		{"a)", 2, `")" without a matching "("`},
+		{"()", 2, "empty parentheses"},
+		{`a "open`, 3, "unterminated quoted string"},
+		{`label:"open`, 7, "unterminated quoted string"},
+		{"a OR", 5, "expected a term after OR"},
+		{"a OR )", 6, "expected a term after OR"},
+		{"a AND", 6, "expected a term after AND"},
+		{"NOT", 4, "expected a term to negate"},
+		{"label:", 7, "expected a value for label"},
+		{"label:bug,", 11, "expected a value for label"},
+		{"priority<high,low", 15, "cannot compare against a list"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("Parse(%q) = %v, want an *Error", tt.query, err)
+			continue
+		}
+		if qerr.Pos != tt.pos || !strings.Contains(qerr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) = %d: %s, want %d: ...%s...", tt.query, qerr.Pos, qerr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestErrorPointer(t *testing.T) {
+	got := Errorf(4, "oops").Pointer("a (b")
+	if want := "a (b\n   ^"; got != want {
+		t.Errorf("Pointer = %q, want %q", got, want)
+	}
+}
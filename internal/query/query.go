@@ -0,0 +1,105 @@
+// Package query parses the ticket query language, for example
+//
+//	project:AUTH status:!done priority>=high label:bug due<2026-11-01 is:blocked
+//
+// into an AST. Terms next to each other must all match; OR, NOT, a leading
+// "-" and parentheses combine them further. A bare word or quoted phrase is
+// a full-text search. Turning the AST into SQL is up to the caller.
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a parsed query expression.
+type Node interface {
+	node()
+}
+
+// And matches when every term matches.
+type And struct {
+	Terms []Node
+	Pos   int
+}
+
+// Or matches when any term matches.
+type Or struct {
+	Terms []Node
+	Pos   int
+}
+
+// Not matches when its term does not.
+type Not struct {
+	Term Node
+	Pos  int
+}
+
+// Field compares a named field against one or more values. A list such as
+// label:bug,ui matches any of its values.
+type Field struct {
+	Name   string
+	Op     Op
+	Values []Value
+	Pos    int
+}
+
+// Value is one value of a field, with its position for error reporting.
+type Value struct {
+	Text string
+	Pos  int
+}
+
+// Text is free text matched against the ticket's searchable content.
+type Text struct {
+	Value string
+	Pos   int
+}
+
+func (*And) node()   {}
+func (*Or) node()    {}
+func (*Not) node()   {}
+func (*Field) node() {}
+func (*Text) node()  {}
+
+// Op is a field comparison.
+type Op string
+
+const (
+	OpEq Op = ":"
+	OpNe Op = "!="
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// Ordered reports whether op compares by order rather than equality.
+func (op Op) Ordered() bool {
+	return op == OpLt || op == OpLe || op == OpGt || op == OpGe
+}
+
+// Error is a problem with a query at a 1-based character position.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Errorf returns an *Error at pos.
+func Errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Pointer renders the query with a caret under the error position, for
+// terminal output.
+func (e *Error) Pointer(query string) string {
+	col := e.Pos - 1
+	if col < 0 {
+		col = 0
+	}
+	return query + "\n" + strings.Repeat(" ", col) + "^"
+}
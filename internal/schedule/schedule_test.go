@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Thursday.
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rule string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"5-10/2 * * * *", time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{"30 8 1,15 * *", time.Date(2026, 1, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 12 10-12 * *", time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan,Jul *", time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+
+		// Both day fields restricted: either one matching fires.
+		{"0 9 13 * FRI", time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9 1-31/2 * MON", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)},
+		// A day field starting with * is not restricted, so only the other
+		// one counts.
+		{"0 9 */2 * MON", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 2 * */3", time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.rule, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next(%s) = %s, want %s", tt.rule, from, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"* * * *", "want 5 fields"},
+		{"* * * * * *", "want 5 fields"},
+		{"@every", "unknown shorthand"},
+		{"60 * * * *", "minute: 60 is outside 0-59"},
+		{"* 24 * * *", "hour: 24 is outside 0-23"},
+		{"* * 0 * *", "day of month: 0 is outside 1-31"},
+		{"* * * 13 *", "month: 13 is outside 1-12"},
+		{"* * * foo *", `month: "foo" is not a number`},
+		{"* * * * 8", "day of week: 8 is outside 0-7"},
+		{"*/0 * * * *", `minute: bad step "0"`},
+		{"5-1 * * * *", "minute: range 5-1 runs backwards"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.rule)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", tt.rule, err, tt.want)
+		}
+	}
+}
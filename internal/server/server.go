@@ -1,12 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"mime"
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/creack/pty"
@@ -16,6 +22,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/tcarac/taskboard/internal/db"
 	"github.com/tcarac/taskboard/internal/models"
+	"github.com/tcarac/taskboard/internal/query"
 )
 
 type Server struct {
@@ -45,7 +52,8 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", actorHeader},
+		ExposedHeaders:   []string{totalCountHeader, nextCursorHeader},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -57,6 +65,11 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 			r.Get("/{id}", s.getProject)
 			r.Put("/{id}", s.updateProject)
 			r.Delete("/{id}", s.deleteProject)
+			r.Post("/{id}/clone", s.cloneProject)
+			r.Get("/{id}/workflow", s.getWorkflow)
+			r.Put("/{id}/workflow", s.updateWorkflow)
+			r.Get("/{id}/wip-limits", s.getWIPLimits)
+			r.Put("/{id}/wip-limits", s.updateWIPLimits)
 		})
 
 		r.Route("/teams", func(r chi.Router) {
@@ -67,6 +80,14 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 			r.Delete("/{id}", s.deleteTeam)
 		})
 
+		r.Route("/members", func(r chi.Router) {
+			r.Get("/", s.listMembers)
+			r.Post("/", s.createMember)
+			r.Get("/{id}", s.getMember)
+			r.Put("/{id}", s.updateMember)
+			r.Delete("/{id}", s.deleteMember)
+		})
+
 		r.Route("/tickets", func(r chi.Router) {
 			r.Get("/", s.listTickets)
 			r.Post("/", s.createTicket)
@@ -74,9 +95,30 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 			r.Put("/{id}", s.updateTicket)
 			r.Post("/{id}/move", s.moveTicket)
 			r.Delete("/{id}", s.deleteTicket)
+			r.Post("/{id}/clone", s.cloneTicket)
 			r.Post("/{id}/subtasks", s.addSubtask)
+			r.Get("/{id}/history", s.getTicketHistory)
+			r.Get("/{id}/relations", s.listRelations)
+			r.Post("/{id}/relations", s.addRelation)
+			r.Delete("/{id}/relations/{type}/{relatedId}", s.removeRelation)
+			r.Get("/{id}/comments", s.listComments)
+			r.Post("/{id}/comments", s.addComment)
+			r.Put("/{id}/comments/{commentId}", s.updateComment)
+			r.Delete("/{id}/comments/{commentId}", s.deleteComment)
+			r.Get("/{id}/attachments", s.listAttachments)
+			r.Post("/{id}/attachments", s.addAttachment)
+			r.Get("/{id}/attachments/{attachmentId}", s.downloadAttachment)
+			r.Delete("/{id}/attachments/{attachmentId}", s.deleteAttachment)
+			r.Get("/{id}/watchers", s.listWatchers)
+			r.Post("/{id}/watchers", s.watchTicket)
+			r.Delete("/{id}/watchers/{memberId}", s.unwatchTicket)
+			r.Get("/{id}/time", s.listTimeEntries)
+			r.Post("/{id}/time", s.logTime)
+			r.Delete("/{id}/time/{entryId}", s.deleteTimeEntry)
 		})
 
+		r.Get("/time/rollup", s.timeRollups)
+
 		r.Route("/subtasks", func(r chi.Router) {
 			r.Post("/{id}/toggle", s.toggleSubtask)
 			r.Delete("/{id}", s.deleteSubtask)
@@ -89,6 +131,74 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 			r.Delete("/{id}", s.deleteLabel)
 		})
 
+		r.Route("/sprints", func(r chi.Router) {
+			r.Get("/", s.listSprints)
+			r.Post("/", s.createSprint)
+			r.Get("/current", s.currentSprint)
+			r.Get("/{id}", s.getSprint)
+			r.Put("/{id}", s.updateSprint)
+			r.Delete("/{id}", s.deleteSprint)
+			r.Get("/{id}/board", s.getSprintBoard)
+			r.Post("/{id}/close", s.closeSprint)
+		})
+
+		r.Route("/milestones", func(r chi.Router) {
+			r.Get("/", s.listMilestones)
+			r.Post("/", s.createMilestone)
+			r.Get("/{id}", s.getMilestone)
+			r.Put("/{id}", s.updateMilestone)
+			r.Delete("/{id}", s.deleteMilestone)
+		})
+
+		r.Route("/templates", func(r chi.Router) {
+			r.Get("/", s.listTemplates)
+			r.Post("/", s.createTemplate)
+			r.Get("/{id}", s.getTemplate)
+			r.Put("/{id}", s.updateTemplate)
+			r.Delete("/{id}", s.deleteTemplate)
+		})
+
+		r.Route("/fields", func(r chi.Router) {
+			r.Get("/", s.listCustomFields)
+			r.Post("/", s.createCustomField)
+			r.Get("/{id}", s.getCustomField)
+			r.Put("/{id}", s.updateCustomField)
+			r.Delete("/{id}", s.deleteCustomField)
+		})
+
+		r.Route("/recurrences", func(r chi.Router) {
+			r.Get("/", s.listRecurrences)
+			r.Post("/", s.createRecurrence)
+			r.Get("/{id}", s.getRecurrence)
+			r.Put("/{id}", s.updateRecurrence)
+			r.Delete("/{id}", s.deleteRecurrence)
+		})
+
+		r.Route("/trash", func(r chi.Router) {
+			r.Get("/", s.listTrash)
+			r.Delete("/", s.emptyTrash)
+			r.Post("/{id}/restore", s.restoreFromTrash)
+		})
+
+		r.Route("/views", func(r chi.Router) {
+			r.Get("/", s.listViews)
+			r.Post("/", s.createView)
+			r.Get("/{id}", s.getView)
+			r.Put("/{id}", s.updateView)
+			r.Delete("/{id}", s.deleteView)
+			r.Get("/{id}/tickets", s.runView)
+		})
+
+		r.Route("/notifications", func(r chi.Router) {
+			r.Get("/", s.listNotifications)
+			r.Get("/unread", s.unreadNotifications)
+			r.Post("/read", s.markAllNotificationsRead)
+			r.Post("/{id}/read", s.markNotificationRead)
+		})
+
+		r.Post("/undo", s.undo)
+		r.Post("/redo", s.redo)
+		r.Get("/activity", s.listActivity)
 		r.Get("/board", s.getBoard)
 		r.Get("/terminal/ws", s.handleTerminalWS)
 	})
@@ -106,6 +216,22 @@ func (s *Server) setupRoutes(webFS fs.FS) {
 	s.router = r
 }
 
+// actorHeader optionally names the person behind a web request so the
+// activity log can attribute the change.
+const actorHeader = "X-Taskboard-Actor"
+
+// Ticket listings return a plain array; paging details travel in headers so
+// existing clients keep working.
+const (
+	totalCountHeader = "X-Total-Count"
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// storeFor returns the store scoped to the actor making the request.
+func (s *Server) storeFor(r *http.Request) *db.Store {
+	return s.store.WithActor(models.Actor{Name: r.Header.Get(actorHeader), Source: models.SourceWeb})
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -116,6 +242,49 @@ func writeError(w http.ResponseWriter, status int, msg string) {
 	writeJSON(w, status, map[string]string{"error": msg})
 }
 
+// writeStoreError maps validation errors from the store to 400, conflicts
+// with the current state of the board to 409, oversized attachments to 413,
+// and anything else to 500.
+// Field-level validation errors are listed under "fields".
+func writeStoreError(w http.ResponseWriter, err error) {
+	var verr *db.ValidationError
+	var qerr *query.Error
+	var cerr *db.ConflictError
+	switch {
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "fields": verr.Fields})
+	case errors.As(err, &qerr):
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "position": qerr.Pos})
+	case errors.Is(err, db.ErrNoActor):
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%v; name yourself in the %s header", err, actorHeader))
+	case errors.Is(err, db.ErrInvalidStatus), errors.Is(err, db.ErrInvalidWorkflow),
+		errors.Is(err, db.ErrInvalidRelation), errors.Is(err, db.ErrInvalidProject):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, db.ErrDependencyCycle), errors.Is(err, db.ErrTicketBlocked), errors.Is(err, db.ErrSprintClosed),
+		errors.Is(err, db.ErrProjectInTrash), errors.Is(err, db.ErrWIPLimit), errors.As(err, &cerr):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, db.ErrAttachmentTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseLimit reads the optional limit query parameter, writing a 400 and
+// reporting false when it is not a non-negative number.
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, "limit must be a non-negative number")
+		return 0, false
+	}
+	return n, true
+}
+
 func decodeJSON(r *http.Request, v any) error {
 	defer r.Body.Close()
 	return json.NewDecoder(r.Body).Decode(v)
@@ -157,9 +326,9 @@ func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "name and prefix are required")
 		return
 	}
-	p, err := s.store.CreateProject(req)
+	p, err := s.storeFor(r).CreateProject(req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, p)
@@ -171,9 +340,9 @@ func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	p, err := s.store.UpdateProject(chi.URLParam(r, "id"), req)
+	p, err := s.storeFor(r).UpdateProject(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	if p == nil {
@@ -184,13 +353,93 @@ func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
-	if err := s.store.DeleteProject(chi.URLParam(r, "id")); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := s.storeFor(r).DeleteProject(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *Server) cloneProject(w http.ResponseWriter, r *http.Request) {
+	var req models.CloneProjectRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	p, err := s.storeFor(r).CloneProject(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if p == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) getWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, err := s.store.GetWorkflow(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, wf)
+}
+
+func (s *Server) updateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateWorkflowRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	wf, err := s.store.UpdateWorkflow(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, wf)
+}
+
+func (s *Server) getWIPLimits(w http.ResponseWriter, r *http.Request) {
+	limits, err := s.store.GetWIPLimits(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if limits == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, limits)
+}
+
+func (s *Server) updateWIPLimits(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateWIPLimitsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	limits, err := s.store.UpdateWIPLimits(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if limits == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, limits)
+}
+
 func (s *Server) listTeams(w http.ResponseWriter, r *http.Request) {
 	teams, err := s.store.ListTeams()
 	if err != nil {
@@ -228,7 +477,7 @@ func (s *Server) createTeam(w http.ResponseWriter, r *http.Request) {
 	}
 	t, err := s.store.CreateTeam(req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, t)
@@ -242,7 +491,7 @@ func (s *Server) updateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 	t, err := s.store.UpdateTeam(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	if t == nil {
@@ -254,28 +503,120 @@ func (s *Server) updateTeam(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) deleteTeam(w http.ResponseWriter, r *http.Request) {
 	if err := s.store.DeleteTeam(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := s.store.ListMembers(r.URL.Query().Get("teamId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if members == nil {
+		members = []models.Member{}
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) getMember(w http.ResponseWriter, r *http.Request) {
+	m, err := s.store.GetMember(chi.URLParam(r, "id"))
+	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if m == nil {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) createMember(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateMemberRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Name == "" || req.Handle == "" {
+		writeError(w, http.StatusBadRequest, "name and handle are required")
+		return
+	}
+	m, err := s.store.CreateMember(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func (s *Server) updateMember(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateMemberRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	m, err := s.store.UpdateMember(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if m == nil {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) deleteMember(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteMember(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) listTickets(w http.ResponseWriter, r *http.Request) {
 	filter := models.TicketFilter{
-		ProjectID: r.URL.Query().Get("projectId"),
-		TeamID:    r.URL.Query().Get("teamId"),
-		Status:    r.URL.Query().Get("status"),
-		Priority:  r.URL.Query().Get("priority"),
+		ProjectID:   r.URL.Query().Get("projectId"),
+		TeamID:      r.URL.Query().Get("teamId"),
+		AssigneeID:  r.URL.Query().Get("assigneeId"),
+		SprintID:    r.URL.Query().Get("sprintId"),
+		MilestoneID: r.URL.Query().Get("milestoneId"),
+		Status:      r.URL.Query().Get("status"),
+		Priority:    r.URL.Query().Get("priority"),
+		Query:       r.URL.Query().Get("q"),
+		Expr:        r.URL.Query().Get("query"),
+		Sort:        r.URL.Query().Get("sort"),
+		Order:       r.URL.Query().Get("order"),
+		Cursor:      r.URL.Query().Get("cursor"),
+	}
+	// Custom fields are filtered with field.<key>=<value>.
+	for name, values := range r.URL.Query() {
+		if key, ok := strings.CutPrefix(name, "field."); ok {
+			if filter.Fields == nil {
+				filter.Fields = map[string]string{}
+			}
+			filter.Fields[key] = values[0]
+		}
+	}
+	var ok bool
+	if filter.Limit, ok = parseLimit(w, r); !ok {
+		return
 	}
-	tickets, err := s.store.ListTickets(filter)
+	// The actor resolves assignee:me in query expressions.
+	page, err := s.storeFor(r).ListTicketPage(filter)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
-	if tickets == nil {
-		tickets = []models.Ticket{}
+	w.Header().Set(totalCountHeader, strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set(nextCursorHeader, page.NextCursor)
 	}
-	writeJSON(w, http.StatusOK, tickets)
+	writeJSON(w, http.StatusOK, page.Tickets)
 }
 
 func (s *Server) getTicket(w http.ResponseWriter, r *http.Request) {
@@ -297,13 +638,13 @@ func (s *Server) createTicket(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	if req.ProjectID == "" || req.Title == "" {
+	if req.ProjectID == "" || req.Title == "" && req.Template == "" {
 		writeError(w, http.StatusBadRequest, "projectId and title are required")
 		return
 	}
-	t, err := s.store.CreateTicket(req)
+	t, err := s.storeFor(r).CreateTicket(req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, t)
@@ -315,9 +656,9 @@ func (s *Server) updateTicket(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	t, err := s.store.UpdateTicket(chi.URLParam(r, "id"), req)
+	t, err := s.storeFor(r).UpdateTicket(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	if t == nil {
@@ -333,13 +674,13 @@ func (s *Server) moveTicket(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	if req.Status == "" {
-		writeError(w, http.StatusBadRequest, "status is required")
+	if req.Status == "" && req.Before == "" && req.After == "" {
+		writeError(w, http.StatusBadRequest, "status, before or after is required")
 		return
 	}
-	t, err := s.store.MoveTicket(chi.URLParam(r, "id"), req)
+	t, err := s.storeFor(r).MoveTicket(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	if t == nil {
@@ -350,13 +691,111 @@ func (s *Server) moveTicket(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) deleteTicket(w http.ResponseWriter, r *http.Request) {
-	if err := s.store.DeleteTicket(chi.URLParam(r, "id")); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := s.storeFor(r).DeleteTicket(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *Server) cloneTicket(w http.ResponseWriter, r *http.Request) {
+	// The body is optional: without one the copy stays in the same project.
+	var req models.CloneTicketRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	t, err := s.storeFor(r).CloneTicket(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) listRelations(w http.ResponseWriter, r *http.Request) {
+	relations, err := s.store.ListRelations(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if relations == nil {
+		relations = []models.TicketRelation{}
+	}
+	writeJSON(w, http.StatusOK, relations)
+}
+
+func (s *Server) addRelation(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRelationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	t, err := s.storeFor(r).AddRelation(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) removeRelation(w http.ResponseWriter, r *http.Request) {
+	t, err := s.storeFor(r).RemoveRelation(chi.URLParam(r, "id"), chi.URLParam(r, "type"), chi.URLParam(r, "relatedId"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) getTicketHistory(w http.ResponseWriter, r *http.Request) {
+	events, err := s.store.GetTicketHistory(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []models.TicketEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (s *Server) listActivity(w http.ResponseWriter, r *http.Request) {
+	filter := models.ActivityFilter{
+		ProjectID: r.URL.Query().Get("projectId"),
+		TicketID:  r.URL.Query().Get("ticketId"),
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "limit must be a number")
+			return
+		}
+		filter.Limit = n
+	}
+	events, err := s.store.ListActivity(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []models.TicketEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
 func (s *Server) addSubtask(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateSubtaskRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -367,87 +806,1002 @@ func (s *Server) addSubtask(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "title is required")
 		return
 	}
-	st, err := s.store.AddSubtask(chi.URLParam(r, "id"), req)
+	st, err := s.storeFor(r).AddSubtask(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, st)
 }
 
 func (s *Server) toggleSubtask(w http.ResponseWriter, r *http.Request) {
-	st, err := s.store.ToggleSubtask(chi.URLParam(r, "id"))
+	st, err := s.storeFor(r).ToggleSubtask(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, st)
 }
 
 func (s *Server) deleteSubtask(w http.ResponseWriter, r *http.Request) {
-	if err := s.store.DeleteSubtask(chi.URLParam(r, "id")); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := s.storeFor(r).DeleteSubtask(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
-	labels, err := s.store.ListLabels()
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request) {
+	comments, err := s.store.ListComments(chi.URLParam(r, "id"))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if labels == nil {
-		labels = []models.Label{}
+	if comments == nil {
+		comments = []models.Comment{}
 	}
-	writeJSON(w, http.StatusOK, labels)
+	writeJSON(w, http.StatusOK, comments)
 }
 
-func (s *Server) createLabel(w http.ResponseWriter, r *http.Request) {
-	var req models.CreateLabelRequest
+func (s *Server) addComment(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCommentRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	if req.Name == "" || req.Color == "" {
-		writeError(w, http.StatusBadRequest, "name and color are required")
+	if req.Body == "" {
+		writeError(w, http.StatusBadRequest, "body is required")
 		return
 	}
-	l, err := s.store.CreateLabel(req)
+	c, err := s.storeFor(r).AddComment(chi.URLParam(r, "id"), req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, l)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
 }
 
-func (s *Server) updateLabel(w http.ResponseWriter, r *http.Request) {
-	var req models.UpdateLabelRequest
+// commentForTicket loads a comment and checks that it belongs to the ticket in
+// the URL, writing a 404 otherwise.
+func (s *Server) commentForTicket(w http.ResponseWriter, r *http.Request) *models.Comment {
+	c, err := s.store.GetComment(chi.URLParam(r, "commentId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	if c == nil || c.TicketID != chi.URLParam(r, "id") {
+		writeError(w, http.StatusNotFound, "comment not found")
+		return nil
+	}
+	return c
+}
+
+func (s *Server) updateComment(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateCommentRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-	l, err := s.store.UpdateLabel(chi.URLParam(r, "id"), req)
+	if req.Body != nil && *req.Body == "" {
+		writeError(w, http.StatusBadRequest, "body cannot be empty")
+		return
+	}
+	c := s.commentForTicket(w, r)
+	if c == nil {
+		return
+	}
+	c, err := s.storeFor(r).UpdateComment(c.ID, req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeStoreError(w, err)
 		return
 	}
-	if l == nil {
-		writeError(w, http.StatusNotFound, "label not found")
+	if c == nil {
+		writeError(w, http.StatusNotFound, "comment not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, l)
+	writeJSON(w, http.StatusOK, c)
 }
 
-func (s *Server) deleteLabel(w http.ResponseWriter, r *http.Request) {
-	if err := s.store.DeleteLabel(chi.URLParam(r, "id")); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+func (s *Server) deleteComment(w http.ResponseWriter, r *http.Request) {
+	c := s.commentForTicket(w, r)
+	if c == nil {
+		return
+	}
+	if err := s.storeFor(r).DeleteComment(c.ID); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *Server) listAttachments(w http.ResponseWriter, r *http.Request) {
+	attachments, err := s.store.ListAttachments(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+	writeJSON(w, http.StatusOK, attachments)
+}
+
+// addAttachment stores the file sent in the "file" part of a multipart form.
+// The part is streamed to the store, so the body is only limited to a little
+// over the attachment size limit to allow for the multipart framing.
+func (s *Server) addAttachment(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, db.MaxAttachmentSize+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "expected a multipart/form-data upload with a \"file\" part")
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			writeError(w, http.StatusBadRequest, "expected a multipart/form-data upload with a \"file\" part")
+			return
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeStoreError(w, db.ErrAttachmentTooLarge)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		req := models.CreateAttachmentRequest{Filename: part.FileName(), ContentType: part.Header.Get("Content-Type")}
+		a, err := s.storeFor(r).AddAttachment(chi.URLParam(r, "id"), req, part)
+		if errors.As(err, &maxErr) {
+			err = db.ErrAttachmentTooLarge
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if a == nil {
+			writeError(w, http.StatusNotFound, "ticket not found")
+			return
+		}
+		writeJSON(w, http.StatusCreated, a)
+		return
+	}
+}
+
+// attachmentForTicket loads an attachment and checks that it belongs to the
+// ticket in the URL, writing a 404 otherwise.
+func (s *Server) attachmentForTicket(w http.ResponseWriter, r *http.Request) *models.Attachment {
+	a, err := s.store.GetAttachment(chi.URLParam(r, "attachmentId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	if a == nil || a.TicketID != chi.URLParam(r, "id") {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return nil
+	}
+	return a
+}
+
+// downloadAttachment serves an attachment's content with its content type.
+// Images are shown inline so the web UI can preview them; everything else is
+// sent as a download so that uploaded HTML never runs on the board's origin.
+func (s *Server) downloadAttachment(w http.ResponseWriter, r *http.Request) {
+	a := s.attachmentForTicket(w, r)
+	if a == nil {
+		return
+	}
+	a, data, err := s.store.ReadAttachment(a.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if a == nil {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	disposition := "attachment"
+	if strings.HasPrefix(a.ContentType, "image/") && !strings.HasPrefix(a.ContentType, "image/svg") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+a.SHA256+`"`)
+	http.ServeContent(w, r, a.Filename, a.CreatedAt, bytes.NewReader(data))
+}
+
+func (s *Server) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	a := s.attachmentForTicket(w, r)
+	if a == nil {
+		return
+	}
+	if err := s.storeFor(r).DeleteAttachment(a.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listWatchers(w http.ResponseWriter, r *http.Request) {
+	members, err := s.store.ListWatchers(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if members == nil {
+		members = []models.Member{}
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) watchTicket(w http.ResponseWriter, r *http.Request) {
+	var req models.WatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	t, err := s.store.WatchTicket(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) unwatchTicket(w http.ResponseWriter, r *http.Request) {
+	t, err := s.store.UnwatchTicket(chi.URLParam(r, "id"), chi.URLParam(r, "memberId"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) listTimeEntries(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.store.ListTimeEntries(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []models.TimeEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) logTime(w http.ResponseWriter, r *http.Request) {
+	var req models.LogTimeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	e, err := s.storeFor(r).LogTime(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if e == nil {
+		writeError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (s *Server) deleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	e, err := s.store.GetTimeEntry(chi.URLParam(r, "entryId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if e == nil || e.TicketID != chi.URLParam(r, "id") {
+		writeError(w, http.StatusNotFound, "time entry not found")
+		return
+	}
+	if err := s.store.DeleteTimeEntry(e.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// timeRollups totals estimated and logged time per project, or per team
+// with by=team.
+func (s *Server) timeRollups(w http.ResponseWriter, r *http.Request) {
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = models.RollupByProject
+	}
+	rollups, err := s.store.TimeRollups(by)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rollups)
+}
+
+func (s *Server) listLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := s.store.ListLabels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if labels == nil {
+		labels = []models.Label{}
+	}
+	writeJSON(w, http.StatusOK, labels)
+}
+
+func (s *Server) createLabel(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateLabelRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Name == "" || req.Color == "" {
+		writeError(w, http.StatusBadRequest, "name and color are required")
+		return
+	}
+	l, err := s.store.CreateLabel(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, l)
+}
+
+func (s *Server) updateLabel(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateLabelRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	l, err := s.store.UpdateLabel(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if l == nil {
+		writeError(w, http.StatusNotFound, "label not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (s *Server) deleteLabel(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteLabel(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listSprints(w http.ResponseWriter, r *http.Request) {
+	sprints, err := s.store.ListSprints(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sprints == nil {
+		sprints = []models.Sprint{}
+	}
+	writeJSON(w, http.StatusOK, sprints)
+}
+
+func (s *Server) getSprint(w http.ResponseWriter, r *http.Request) {
+	sp, err := s.store.GetSprint(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sp == nil {
+		writeError(w, http.StatusNotFound, "sprint not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, sp)
+}
+
+// currentSprint returns the active sprint, for the project given by
+// projectId if any.
+func (s *Server) currentSprint(w http.ResponseWriter, r *http.Request) {
+	sp, err := s.store.CurrentSprint(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sp == nil {
+		writeError(w, http.StatusNotFound, "no active sprint")
+		return
+	}
+	writeJSON(w, http.StatusOK, sp)
+}
+
+func (s *Server) createSprint(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSprintRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	sp, err := s.store.CreateSprint(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sp)
+}
+
+func (s *Server) updateSprint(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateSprintRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	sp, err := s.store.UpdateSprint(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if sp == nil {
+		writeError(w, http.StatusNotFound, "sprint not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, sp)
+}
+
+func (s *Server) deleteSprint(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteSprint(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getSprintBoard(w http.ResponseWriter, r *http.Request) {
+	board, err := s.store.GetSprintBoard(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if board == nil {
+		writeError(w, http.StatusNotFound, "sprint not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (s *Server) closeSprint(w http.ResponseWriter, r *http.Request) {
+	// The body is optional: without one the next sprint is picked for us.
+	var req models.CloseSprintRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	result, err := s.storeFor(r).CloseSprint(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if result == nil {
+		writeError(w, http.StatusNotFound, "sprint not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) listMilestones(w http.ResponseWriter, r *http.Request) {
+	milestones, err := s.store.ListMilestones(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if milestones == nil {
+		milestones = []models.Milestone{}
+	}
+	writeJSON(w, http.StatusOK, milestones)
+}
+
+func (s *Server) getMilestone(w http.ResponseWriter, r *http.Request) {
+	m, err := s.store.GetMilestone(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if m == nil {
+		writeError(w, http.StatusNotFound, "milestone not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateMilestoneRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	m, err := s.store.CreateMilestone(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func (s *Server) updateMilestone(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateMilestoneRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	m, err := s.store.UpdateMilestone(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if m == nil {
+		writeError(w, http.StatusNotFound, "milestone not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteMilestone(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.store.ListTemplates(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if templates == nil {
+		templates = []models.TicketTemplate{}
+	}
+	writeJSON(w, http.StatusOK, templates)
+}
+
+func (s *Server) getTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := s.store.GetTemplate(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) createTemplate(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	t, err := s.store.CreateTemplate(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) updateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	t, err := s.store.UpdateTemplate(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if t == nil {
+		writeError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteTemplate(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listCustomFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := s.store.ListCustomFields(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if fields == nil {
+		fields = []models.CustomField{}
+	}
+	writeJSON(w, http.StatusOK, fields)
+}
+
+func (s *Server) getCustomField(w http.ResponseWriter, r *http.Request) {
+	f, err := s.store.GetCustomField(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if f == nil {
+		writeError(w, http.StatusNotFound, "field not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (s *Server) createCustomField(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCustomFieldRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	f, err := s.store.CreateCustomField(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, f)
+}
+
+func (s *Server) updateCustomField(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateCustomFieldRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	f, err := s.store.UpdateCustomField(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if f == nil {
+		writeError(w, http.StatusNotFound, "field not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (s *Server) deleteCustomField(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteCustomField(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listRecurrences(w http.ResponseWriter, r *http.Request) {
+	recurrences, err := s.store.ListRecurrences(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if recurrences == nil {
+		recurrences = []models.Recurrence{}
+	}
+	writeJSON(w, http.StatusOK, recurrences)
+}
+
+func (s *Server) getRecurrence(w http.ResponseWriter, r *http.Request) {
+	rec, err := s.store.GetRecurrence(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "recurrence not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) createRecurrence(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRecurrenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	rec, err := s.store.CreateRecurrence(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rec)
+}
+
+func (s *Server) updateRecurrence(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRecurrenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	rec, err := s.store.UpdateRecurrence(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "recurrence not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) deleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteRecurrence(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := s.store.ListTrash()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if items == nil {
+		items = []models.TrashItem{}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) restoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	item, err := s.storeFor(r).RestoreFromTrash(chi.URLParam(r, "id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if item == nil {
+		writeError(w, http.StatusNotFound, "not in the trash")
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// emptyTrash permanently deletes everything in the trash.
+func (s *Server) emptyTrash(w http.ResponseWriter, r *http.Request) {
+	purged, err := s.store.EmptyTrash()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// undo reverts the latest change made by the actor of the request, which has
+// to be named in the actor header.
+func (s *Server) undo(w http.ResponseWriter, r *http.Request) {
+	c, err := s.storeFor(r).Undo()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if c == nil {
+		writeError(w, http.StatusNotFound, "nothing to undo")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) redo(w http.ResponseWriter, r *http.Request) {
+	c, err := s.storeFor(r).Redo()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if c == nil {
+		writeError(w, http.StatusNotFound, "nothing to redo")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// notificationMember returns the member whose inbox a request is about: the
+// memberId query parameter, or else the member whose handle is the request's
+// actor. It writes a 400 and returns "" when neither names a member.
+func (s *Server) notificationMember(w http.ResponseWriter, r *http.Request) string {
+	if id := r.URL.Query().Get("memberId"); id != "" {
+		return id
+	}
+	if handle := r.Header.Get(actorHeader); handle != "" {
+		m, err := s.store.GetMemberByHandle(handle)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return ""
+		}
+		if m != nil {
+			return m.ID
+		}
+	}
+	writeError(w, http.StatusBadRequest, "memberId is required")
+	return ""
+}
+
+func (s *Server) listNotifications(w http.ResponseWriter, r *http.Request) {
+	memberID := s.notificationMember(w, r)
+	if memberID == "" {
+		return
+	}
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	filter := models.NotificationFilter{MemberID: memberID, Unread: r.URL.Query().Get("unread") == "true", Limit: limit}
+	notifications, err := s.store.ListNotifications(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+// unreadNotifications returns a member's unread count for badges in the UI.
+func (s *Server) unreadNotifications(w http.ResponseWriter, r *http.Request) {
+	memberID := s.notificationMember(w, r)
+	if memberID == "" {
+		return
+	}
+	count, err := s.store.UnreadNotificationCount(memberID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"memberId": memberID, "unread": count})
+}
+
+func (s *Server) markNotificationRead(w http.ResponseWriter, r *http.Request) {
+	memberID := s.notificationMember(w, r)
+	if memberID == "" {
+		return
+	}
+	n, err := s.store.MarkNotificationRead(memberID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if n == nil {
+		writeError(w, http.StatusNotFound, "notification not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	memberID := s.notificationMember(w, r)
+	if memberID == "" {
+		return
+	}
+	count, err := s.store.MarkAllNotificationsRead(memberID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"read": count})
+}
+
+func (s *Server) listViews(w http.ResponseWriter, r *http.Request) {
+	views, err := s.store.ListViews(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if views == nil {
+		views = []models.SavedView{}
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) getView(w http.ResponseWriter, r *http.Request) {
+	v, err := s.store.GetView(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if v == nil {
+		writeError(w, http.StatusNotFound, "view not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) createView(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateViewRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	v, err := s.store.CreateView(req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, v)
+}
+
+func (s *Server) updateView(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateViewRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	v, err := s.store.UpdateView(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if v == nil {
+		writeError(w, http.StatusNotFound, "view not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) deleteView(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteView(chi.URLParam(r, "id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runView returns a page of the view's tickets, taking cursor and limit
+// like the ticket listing.
+func (s *Server) runView(w http.ResponseWriter, r *http.Request) {
+	v, err := s.store.GetView(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if v == nil {
+		writeError(w, http.StatusNotFound, "view not found")
+		return
+	}
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	result, err := s.storeFor(r).RunView(v, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (s *Server) getBoard(w http.ResponseWriter, r *http.Request) {
 	projectID := r.URL.Query().Get("projectId")
 	board, err := s.store.GetBoard(projectID)
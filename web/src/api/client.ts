@@ -6,6 +6,8 @@ export interface Project {
   icon: string;
   color: string;
   status: string;
+  blockedMovePolicy: "allow" | "warn" | "block";
+  estimateUnit: "points" | "hours";
   createdAt: string;
   updatedAt: string;
 }
@@ -17,6 +19,15 @@ export interface Team {
   createdAt: string;
 }
 
+export interface Member {
+  id: string;
+  name: string;
+  handle: string;
+  avatarColor: string;
+  teamIds: string[];
+  createdAt: string;
+}
+
 export interface Label {
   id: string;
   name: string;
@@ -31,16 +42,69 @@ export interface Subtask {
   position: number;
 }
 
+export interface Comment {
+  id: string;
+  ticketId: string;
+  author: string;
+  body: string;
+  createdAt: string;
+  editedAt?: string;
+}
+
+export interface Attachment {
+  id: string;
+  ticketId: string;
+  filename: string;
+  contentType: string;
+  size: number;
+  sha256: string;
+  author?: string;
+  createdAt: string;
+}
+
+export interface TicketRelation {
+  type: string;
+  ticketId: string;
+  ticketKey: string;
+  title: string;
+  status: string;
+}
+
+export interface TimeEntry {
+  id: string;
+  ticketId: string;
+  minutes: number;
+  note?: string;
+  date: string;
+  author: string;
+  createdAt: string;
+}
+
+export interface TimeRollup {
+  id: string;
+  name: string;
+  tickets: number;
+  estimatedTickets: number;
+  estimatedPoints: number;
+  estimatedHours: number;
+  loggedMinutes: number;
+}
+
 export interface Ticket {
   id: string;
   projectId: string;
   teamId?: string;
+  assigneeId?: string;
   number: number;
   title: string;
   description: string;
   status: string;
   priority: string;
   dueDate?: string;
+  sprintId?: string;
+  milestoneId?: string;
+  estimate?: number;
+  loggedMinutes?: number;
   position: number;
   createdAt: string;
   updatedAt: string;
@@ -48,18 +112,166 @@ export interface Ticket {
   labels: Label[];
   subtasks: Subtask[];
   blockedBy: string[];
+  blocks?: string[];
+  isBlocked: boolean;
+  warnings?: string[];
+  relations?: TicketRelation[];
+  comments?: Comment[];
+  attachments?: Attachment[];
+  watchers?: string[];
+  fields?: Record<string, unknown>;
 }
 
 export interface BoardColumn {
   status: string;
+  name?: string;
+  category?: string;
   tickets: Ticket[];
+  count: number;
+  wipLimit?: number;
+  overLimit?: boolean;
+  teamLimits?: TeamWIPUsage[];
+}
+
+export interface TeamWIPUsage {
+  teamId: string;
+  count: number;
+  wipLimit: number;
+  overLimit?: boolean;
+}
+
+export interface WIPLimit {
+  status: string;
+  teamId?: string;
+  limit: number;
+  policy: "warn" | "block";
 }
 
 export interface Board {
   projectId: string;
+  sprintId?: string;
   columns: BoardColumn[];
 }
 
+export interface Sprint {
+  id: string;
+  projectId?: string;
+  name: string;
+  goal?: string;
+  startDate: string;
+  endDate: string;
+  closedAt?: string;
+  status: "planned" | "active" | "closed";
+  ticketCount: number;
+  doneCount: number;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface Milestone {
+  id: string;
+  projectId: string;
+  name: string;
+  description?: string;
+  targetDate?: string;
+  ticketCount: number;
+  doneCount: number;
+  subtaskCount: number;
+  subtaskDoneCount: number;
+  progress: number;
+  overdue: boolean;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface TicketTemplate {
+  id: string;
+  projectId: string;
+  name: string;
+  title?: string;
+  description?: string;
+  priority?: string;
+  teamId?: string;
+  labels: Label[];
+  subtasks: string[];
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface CustomField {
+  id: string;
+  projectId: string;
+  key: string;
+  name: string;
+  type: "text" | "number" | "date" | "select" | "multi_select" | "url";
+  options?: string[];
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface Recurrence {
+  id: string;
+  projectId: string;
+  rule: string;
+  ticket: Omit<Partial<Ticket>, "labels"> & { title: string; labels?: string[] };
+  paused: boolean;
+  nextRunAt: string;
+  lastRunAt?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface TrashItem {
+  type: "project" | "ticket" | "team" | "label";
+  id: string;
+  name: string;
+  projectId?: string;
+  tickets?: number;
+  deletedAt: string;
+}
+
+export interface Notification {
+  id: string;
+  memberId: string;
+  ticketId: string;
+  ticketKey: string;
+  kind: "status" | "comment" | "unblocked";
+  message: string;
+  actor?: string;
+  createdAt: string;
+  readAt?: string;
+}
+
+export interface Change {
+  id: number;
+  entity: "ticket" | "project" | "subtask";
+  entityId: string;
+  summary: string;
+  actor?: string;
+  source?: string;
+  createdAt: string;
+}
+
+export interface SavedView {
+  id: string;
+  name: string;
+  projectId?: string;
+  query: string;
+  sort?: string;
+  order?: "asc" | "desc";
+  groupBy?: "status" | "priority" | "assignee" | "team" | "project";
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface ViewResult {
+  view: SavedView;
+  tickets: Ticket[];
+  groups?: { key: string; ticketIds: string[] }[];
+  total: number;
+  nextCursor?: string;
+}
+
 async function request<T>(url: string, options?: RequestInit): Promise<T> {
   const res = await fetch(url, {
     headers: { "Content-Type": "application/json" },
@@ -89,6 +301,18 @@ export const api = {
       }),
     delete: (id: string) =>
       request<void>(`/api/projects/${id}`, { method: "DELETE" }),
+    clone: (id: string, data: { name: string; prefix: string; tickets?: boolean }) =>
+      request<Project>(`/api/projects/${id}/clone`, {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    wipLimits: (id: string) =>
+      request<{ projectId: string; limits: WIPLimit[] }>(`/api/projects/${id}/wip-limits`),
+    updateWipLimits: (id: string, limits: WIPLimit[]) =>
+      request<{ projectId: string; limits: WIPLimit[] }>(`/api/projects/${id}/wip-limits`, {
+        method: "PUT",
+        body: JSON.stringify({ limits }),
+      }),
   },
 
   teams: {
@@ -111,7 +335,7 @@ export const api = {
   tickets: {
     list: () => request<Ticket[]>("/api/tickets"),
     get: (id: string) => request<Ticket>(`/api/tickets/${id}`),
-    create: (data: Partial<Ticket>) =>
+    create: (data: Partial<Ticket> & { template?: string }) =>
       request<Ticket>("/api/tickets", {
         method: "POST",
         body: JSON.stringify(data),
@@ -123,16 +347,56 @@ export const api = {
       }),
     delete: (id: string) =>
       request<void>(`/api/tickets/${id}`, { method: "DELETE" }),
-    move: (id: string, status: string, position?: number) =>
+    clone: (id: string, data: { projectId?: string; title?: string } = {}) =>
+      request<Ticket>(`/api/tickets/${id}/clone`, {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    move: (
+      id: string,
+      status: string,
+      at: { position?: number; before?: string; after?: string } = {},
+    ) =>
       request<Ticket>(`/api/tickets/${id}/move`, {
         method: "POST",
-        body: JSON.stringify({ status, position }),
+        body: JSON.stringify({ status, ...at }),
       }),
     addSubtask: (id: string, title: string) =>
       request<Subtask>(`/api/tickets/${id}/subtasks`, {
         method: "POST",
         body: JSON.stringify({ title }),
       }),
+    time: (id: string) => request<TimeEntry[]>(`/api/tickets/${id}/time`),
+    logTime: (id: string, data: { duration: string; note?: string; date?: string }) =>
+      request<TimeEntry>(`/api/tickets/${id}/time`, {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    deleteTime: (id: string, entryId: string) =>
+      request<void>(`/api/tickets/${id}/time/${entryId}`, { method: "DELETE" }),
+    attachments: (id: string) => request<Attachment[]>(`/api/tickets/${id}/attachments`),
+    attach: (id: string, file: File) => {
+      const body = new FormData();
+      body.append("file", file);
+      // No Content-Type header: the browser sets the multipart boundary.
+      return request<Attachment>(`/api/tickets/${id}/attachments`, { method: "POST", headers: {}, body });
+    },
+    attachmentUrl: (id: string, attachmentId: string) => `/api/tickets/${id}/attachments/${attachmentId}`,
+    deleteAttachment: (id: string, attachmentId: string) =>
+      request<void>(`/api/tickets/${id}/attachments/${attachmentId}`, { method: "DELETE" }),
+    watchers: (id: string) => request<Member[]>(`/api/tickets/${id}/watchers`),
+    watch: (id: string, memberId: string) =>
+      request<Ticket>(`/api/tickets/${id}/watchers`, {
+        method: "POST",
+        body: JSON.stringify({ memberId }),
+      }),
+    unwatch: (id: string, memberId: string) =>
+      request<Ticket>(`/api/tickets/${id}/watchers/${memberId}`, { method: "DELETE" }),
+  },
+
+  time: {
+    rollup: (by: "project" | "team" = "project") =>
+      request<TimeRollup[]>(`/api/time/rollup?by=${by}`),
   },
 
   subtasks: {
@@ -158,6 +422,138 @@ export const api = {
       request<void>(`/api/labels/${id}`, { method: "DELETE" }),
   },
 
+  views: {
+    list: () => request<SavedView[]>("/api/views"),
+    create: (data: Partial<SavedView>) =>
+      request<SavedView>("/api/views", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: Partial<SavedView>) =>
+      request<SavedView>(`/api/views/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/views/${id}`, { method: "DELETE" }),
+    run: (id: string, cursor?: string) =>
+      request<ViewResult>(
+        `/api/views/${id}/tickets${cursor ? `?cursor=${encodeURIComponent(cursor)}` : ""}`,
+      ),
+  },
+
+  sprints: {
+    list: (projectId?: string) =>
+      request<Sprint[]>(`/api/sprints${projectId ? `?projectId=${projectId}` : ""}`),
+    create: (data: Partial<Sprint>) =>
+      request<Sprint>("/api/sprints", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: Partial<Sprint>) =>
+      request<Sprint>(`/api/sprints/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/sprints/${id}`, { method: "DELETE" }),
+    board: (id: string) => request<Board>(`/api/sprints/${id}/board`),
+    close: (id: string, nextSprintId?: string) =>
+      request<{ sprint: Sprint; nextSprintId?: string; moved: string[] }>(
+        `/api/sprints/${id}/close`,
+        { method: "POST", body: JSON.stringify({ nextSprintId }) },
+      ),
+  },
+
+  milestones: {
+    list: (projectId?: string) =>
+      request<Milestone[]>(`/api/milestones${projectId ? `?projectId=${projectId}` : ""}`),
+    create: (data: Partial<Milestone>) =>
+      request<Milestone>("/api/milestones", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: Partial<Milestone>) =>
+      request<Milestone>(`/api/milestones/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/milestones/${id}`, { method: "DELETE" }),
+  },
+
+  templates: {
+    list: (projectId?: string) =>
+      request<TicketTemplate[]>(`/api/templates${projectId ? `?projectId=${projectId}` : ""}`),
+    create: (data: Omit<Partial<TicketTemplate>, "labels"> & { labels?: string[] }) =>
+      request<TicketTemplate>("/api/templates", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: Omit<Partial<TicketTemplate>, "labels"> & { labels?: string[] }) =>
+      request<TicketTemplate>(`/api/templates/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/templates/${id}`, { method: "DELETE" }),
+  },
+
+  fields: {
+    list: (projectId?: string) =>
+      request<CustomField[]>(`/api/fields${projectId ? `?projectId=${projectId}` : ""}`),
+    create: (data: Partial<CustomField>) =>
+      request<CustomField>("/api/fields", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: { name?: string; options?: string[] }) =>
+      request<CustomField>(`/api/fields/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/fields/${id}`, { method: "DELETE" }),
+  },
+
+  recurrences: {
+    list: (projectId?: string) =>
+      request<Recurrence[]>(`/api/recurrences${projectId ? `?projectId=${projectId}` : ""}`),
+    create: (data: Pick<Recurrence, "projectId" | "rule" | "ticket">) =>
+      request<Recurrence>("/api/recurrences", {
+        method: "POST",
+        body: JSON.stringify(data),
+      }),
+    update: (id: string, data: Partial<Pick<Recurrence, "rule" | "ticket" | "paused">>) =>
+      request<Recurrence>(`/api/recurrences/${id}`, {
+        method: "PUT",
+        body: JSON.stringify(data),
+      }),
+    delete: (id: string) =>
+      request<void>(`/api/recurrences/${id}`, { method: "DELETE" }),
+  },
+
+  trash: {
+    list: () => request<TrashItem[]>("/api/trash"),
+    restore: (id: string) =>
+      request<TrashItem>(`/api/trash/${id}/restore`, { method: "POST" }),
+    empty: () => request<{ purged: number }>("/api/trash", { method: "DELETE" }),
+  },
+
+  notifications: {
+    list: (memberId: string, unread = false) =>
+      request<Notification[]>(`/api/notifications?memberId=${memberId}${unread ? "&unread=true" : ""}`),
+    unread: (memberId: string) =>
+      request<{ memberId: string; unread: number }>(`/api/notifications/unread?memberId=${memberId}`),
+    markRead: (id: string) =>
+      request<Notification>(`/api/notifications/${id}/read`, { method: "POST" }),
+    markAllRead: (memberId: string) =>
+      request<{ read: number }>(`/api/notifications/read?memberId=${memberId}`, { method: "POST" }),
+  },
+
+  undo: () => request<Change>("/api/undo", { method: "POST" }),
+  redo: () => request<Change>("/api/redo", { method: "POST" }),
+
   board: {
     get: (projectId?: string) =>
       request<Board>(`/api/board${projectId ? `?projectId=${projectId}` : ""}`),